@@ -0,0 +1,178 @@
+package udm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// benchmarkPayloadSize is the number of bytes downloaded for each thread count (8MB)
+const benchmarkPayloadSize = 8 * 1024 * 1024
+
+// benchmarkTimeout bounds the time spent on a single thread count
+const benchmarkTimeout = 60 * time.Second
+
+// BenchmarkResult contains the measured throughput of a network benchmark
+type BenchmarkResult struct {
+	TestServer     string          // URL used for the benchmark
+	PayloadSize    int64           // Bytes downloaded for each thread count
+	Throughput     map[int]float64 // Bytes per second measured for each thread count
+	OptimalThreads int             // Thread count with the highest throughput
+	Error          error           // Error if the benchmark could not be completed
+}
+
+// BenchmarkNetwork measures download throughput with different thread counts.
+// For every thread count a fixed size payload is downloaded from testServer using
+// concurrent range requests, the fastest thread count is written to the settings file
+// as Settings.BenchmarkedOptimalThreads. getOptimalThreadCount uses it for later downloads
+// when no thread count was chosen for them, see ApplySettingsToDownloader.
+//
+// Parameters:
+//   - testServer: URL of a file of at least 8MB on a server supporting range requests
+//   - threadCounts: Thread counts to compare, e.g. []int{1, 2, 4, 8, 16}
+//
+// Returns:
+//   - BenchmarkResult: Measured throughput, Error is set if the benchmark failed
+//
+// Example:
+//
+//	result := BenchmarkNetwork("https://speed.example.com/100MB.bin", []int{1, 2, 4, 8, 16})
+//	if result.Error != nil {
+//		fmt.Println("Error:", result.Error)
+//		return
+//	}
+//	fmt.Printf("Optimal thread count: %d\n", result.OptimalThreads)
+func BenchmarkNetwork(testServer string, threadCounts []int) BenchmarkResult {
+	result := BenchmarkResult{
+		TestServer:  testServer,
+		PayloadSize: benchmarkPayloadSize,
+		Throughput:  make(map[int]float64),
+	}
+
+	if len(threadCounts) == 0 {
+		result.Error = fmt.Errorf("no thread counts to benchmark")
+		return result
+	}
+
+	// Use the same transport settings as regular downloads
+	client := (&Downloader{}).buildHTTPClient()
+
+	var bestThroughput float64
+	for _, threadCount := range threadCounts {
+		if threadCount <= 0 {
+			continue
+		}
+
+		throughput, err := benchmarkThreadCount(client, testServer, threadCount)
+		if err != nil {
+			result.Error = fmt.Errorf("benchmark with %d threads failed: %w", threadCount, err)
+			return result
+		}
+
+		result.Throughput[threadCount] = throughput
+		if throughput > bestThroughput {
+			bestThroughput = throughput
+			result.OptimalThreads = threadCount
+		}
+	}
+
+	// Persist the result so later downloads can use it, the settings file is read again
+	// so only this field changes and nothing else set in memory is written to it
+	settings, err := LoadSettings(CONFIG_FILE_PATH)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to load settings: %w", err)
+		return result
+	}
+
+	settings.BenchmarkedOptimalThreads = result.OptimalThreads
+	if err := settings.SaveSettings(CONFIG_FILE_PATH); err != nil {
+		result.Error = fmt.Errorf("failed to save settings: %w", err)
+		return result
+	}
+
+	if UDMSettings != nil {
+		UDMSettings.BenchmarkedOptimalThreads = result.OptimalThreads
+	}
+
+	return result
+}
+
+// benchmarkThreadCount downloads the benchmark payload split over threadCount range requests.
+//
+// Parameters:
+//   - client: HTTP client used for the requests
+//   - testServer: URL of the test file
+//   - threadCount: Number of concurrent range requests
+//
+// Returns:
+//   - float64: Throughput in bytes per second
+//   - error: Error if any request fails
+func benchmarkThreadCount(client *http.Client, testServer string, threadCount int) (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), benchmarkTimeout)
+	defer cancel()
+
+	chunkSizes := DivideChunks(benchmarkPayloadSize, threadCount)
+
+	var wg sync.WaitGroup
+	var totalBytes int64
+	errorChan := make(chan error, threadCount)
+
+	start := time.Now()
+
+	var offset int64
+	for _, size := range chunkSizes {
+		if size <= 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func(startByte, endByte int64) {
+			defer wg.Done()
+
+			req, err := http.NewRequestWithContext(ctx, "GET", testServer, nil)
+			if err != nil {
+				errorChan <- err
+				return
+			}
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", startByte, endByte))
+
+			resp, err := client.Do(req)
+			if err != nil {
+				errorChan <- err
+				return
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusPartialContent {
+				errorChan <- fmt.Errorf("test server does not support range requests (status %d)", resp.StatusCode)
+				return
+			}
+
+			n, err := io.Copy(io.Discard, resp.Body)
+			atomic.AddInt64(&totalBytes, n)
+			if err != nil {
+				errorChan <- err
+			}
+		}(offset, offset+size-1)
+
+		offset += size
+	}
+
+	wg.Wait()
+	close(errorChan)
+
+	if err := <-errorChan; err != nil {
+		return 0, err
+	}
+
+	elapsed := time.Since(start).Seconds()
+	if elapsed <= 0 {
+		return 0, fmt.Errorf("benchmark finished too quickly to measure")
+	}
+
+	return float64(totalBytes) / elapsed, nil
+}
@@ -0,0 +1,52 @@
+package udm
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBenchmarkNetworkWritesSettingsFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("downloads the benchmark payload for every thread count")
+	}
+
+	payload := make([]byte, benchmarkPayloadSize)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "payload.bin", time.Time{}, bytes.NewReader(payload))
+	}))
+	defer server.Close()
+
+	previousPath, previousSettings := CONFIG_FILE_PATH, UDMSettings
+	defer func() { CONFIG_FILE_PATH, UDMSettings = previousPath, previousSettings }()
+
+	// The user's own values in the settings file are kept
+	CONFIG_FILE_PATH = filepath.Join(t.TempDir(), "udmConfigs.json")
+	if err := (&Settings{MaxRetries: 7}).SaveSettings(CONFIG_FILE_PATH); err != nil {
+		t.Fatalf("SaveSettings() error = %v", err)
+	}
+	UDMSettings = &Settings{ThreadCount: 4}
+
+	result := BenchmarkNetwork(server.URL, []int{1, 2})
+	if result.Error != nil {
+		t.Fatalf("BenchmarkNetwork() error = %v", result.Error)
+	}
+
+	saved, err := LoadSettings(CONFIG_FILE_PATH)
+	if err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+	if saved.BenchmarkedOptimalThreads != result.OptimalThreads || saved.MaxRetries != 7 || saved.ThreadCount != 0 {
+		t.Errorf("saved settings = %+v, want BenchmarkedOptimalThreads %d and only the user's MaxRetries", saved, result.OptimalThreads)
+	}
+
+	// Downloads without a thread count of their own use the result
+	d := &Downloader{}
+	UDMSettings.ThreadCount = 0
+	if got := d.getOptimalThreadCount(); got != result.OptimalThreads {
+		t.Errorf("getOptimalThreadCount() = %d, want %d", got, result.OptimalThreads)
+	}
+}
@@ -0,0 +1,51 @@
+package udm
+
+// DEFAULT_BUFFER_SIZE is the read buffer size used when UserPreferences.BufferSizeBytes is not set (32KB)
+const DEFAULT_BUFFER_SIZE = 32 * 1024
+
+// minBufferSize is the smallest buffer used even under a very tight memory cap (4KB)
+const minBufferSize = 4 * 1024
+
+// SetMaxMemoryUsage bounds the total memory used by read buffers of this download.
+// The cap is divided by the thread count to compute the buffer size of each
+// chunk, which never exceeds UserPreferences.BufferSizeBytes.
+//
+// Parameters:
+//   - bytes: Maximum total buffer memory in bytes (0 or less removes the cap)
+//
+// Example:
+//
+//	downloader.Prefs.BufferSizeBytes = 4 * 1024 * 1024
+//	downloader.SetMaxMemoryUsage(64 * 1024 * 1024) // 64 threads get 1MB buffers each
+func (d *Downloader) SetMaxMemoryUsage(bytes int64) {
+	d.maxMemoryUsage = bytes
+}
+
+// getBufferSize returns the read buffer size for a single stream or chunk.
+//
+// Returns:
+//   - int: Buffer size in bytes, respecting the memory cap set with SetMaxMemoryUsage
+func (d *Downloader) getBufferSize() int {
+	bufferSize := int64(DEFAULT_BUFFER_SIZE)
+	if d.Prefs.BufferSizeBytes > 0 {
+		bufferSize = int64(d.Prefs.BufferSizeBytes)
+	}
+
+	if d.maxMemoryUsage > 0 {
+		unlock := d.lockChunks()
+		chunkCount := len(d.Chunks)
+		unlock()
+
+		threadCount := int64(1)
+		if chunkCount > 1 && !d.Prefs.UseMultipartRange {
+			threadCount = int64(chunkCount)
+		}
+
+		effectiveBufferSize := d.maxMemoryUsage / threadCount
+		if effectiveBufferSize < bufferSize {
+			bufferSize = effectiveBufferSize
+		}
+	}
+
+	return int(max(bufferSize, minBufferSize))
+}
@@ -20,7 +20,7 @@ func (d *Downloader) StopDownload() {
 	//}
 
 	// Clear callbacks to prevent memory leaks
-	d.Callbacks = nil
+	d.setCallbacks(nil)
 
 	// Mark as stopped
 	d.isStopped = true
@@ -36,5 +36,22 @@ func (d *Downloader) Dispose() {
 func (d *Downloader) ClearCallbacks() {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	d.Callbacks = nil
+	d.setCallbacks(nil)
+}
+
+// callbacks returns the current callbacks, they can be replaced by AttachProgressBar
+// while the download is running so they are read under the callbacks lock
+func (d *Downloader) callbacks() *Callbacks {
+	d.callbacksMu.RLock()
+	defer d.callbacksMu.RUnlock()
+
+	return d.Callbacks
+}
+
+// setCallbacks replaces the callbacks under the callbacks lock
+func (d *Downloader) setCallbacks(callbacks *Callbacks) {
+	d.callbacksMu.Lock()
+	defer d.callbacksMu.Unlock()
+
+	d.Callbacks = callbacks
 }
@@ -0,0 +1,343 @@
+package udm
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// sctExtensionOID is the X.509 extension holding embedded signed certificate timestamps (RFC 6962 section 3.3)
+var sctExtensionOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+// Merkle tree leaf entry types (RFC 6962 section 3.4)
+const (
+	ctX509Entry    = 0
+	ctPrecertEntry = 1
+)
+
+// EnableCTVerification requires the server certificate to be logged in a Certificate Transparency log.
+// Every TLS connection made for the download is checked against the log at logURL,
+// connections to servers whose certificate can't be found in the log are rejected.
+//
+// Parameters:
+//   - logURL: Base URL of an RFC 6962 log, e.g. "https://ct.googleapis.com/logs/us1/argon2025h2"
+//
+// Example:
+//
+//	downloader := &Downloader{Url: "https://example.com/firmware.bin"}
+//	downloader.EnableCTVerification("https://ct.googleapis.com/logs/us1/argon2025h2")
+//	downloader.StartDownload()
+//
+// Notes:
+//   - Only signed certificate timestamps sent in the TLS handshake or embedded in the certificate are checked
+//   - Has no effect when a custom transport is set with UseTransport
+//   - Successful lookups are cached per certificate so chunk connections don't query the log again
+func (d *Downloader) EnableCTVerification(logURL string) {
+	d.ctLogURL = strings.TrimSuffix(logURL, "/")
+}
+
+// applyCTVerification adds the certificate transparency check to a TLS configuration.
+//
+// Parameters:
+//   - config: TLS configuration to modify
+func (d *Downloader) applyCTVerification(config *tls.Config) {
+	if d.ctLogURL == "" {
+		return
+	}
+	config.VerifyConnection = d.verifyCertificateTransparency
+}
+
+// verifyCertificateTransparency checks that the leaf certificate of a connection is logged.
+// It is used as tls.Config.VerifyConnection since signed certificate timestamps
+// delivered in the handshake are only available in the connection state.
+//
+// Parameters:
+//   - cs: State of the TLS connection after the handshake
+//
+// Returns:
+//   - error: Error if the certificate is not found in the log
+func (d *Downloader) verifyCertificateTransparency(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("certificate transparency: no peer certificate")
+	}
+	leaf := cs.PeerCertificates[0]
+
+	fingerprint := sha256.Sum256(leaf.Raw)
+	if _, ok := d.ctVerified.Load(fingerprint); ok {
+		return nil
+	}
+
+	leafHashes, err := ctLeafHashes(cs)
+	if err != nil {
+		return fmt.Errorf("certificate transparency: %v", err)
+	}
+	if len(leafHashes) == 0 {
+		return fmt.Errorf("certificate transparency: certificate for %s has no signed certificate timestamps", leaf.Subject.CommonName)
+	}
+
+	client := d.ctLogClient()
+
+	treeSize, err := ctTreeSize(client, d.ctLogURL)
+	if err != nil {
+		return fmt.Errorf("certificate transparency: %v", err)
+	}
+
+	for _, leafHash := range leafHashes {
+		logged, err := ctHasLeaf(client, d.ctLogURL, leafHash, treeSize)
+		if err != nil {
+			return fmt.Errorf("certificate transparency: %v", err)
+		}
+		if logged {
+			d.ctVerified.Store(fingerprint, true)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("certificate transparency: certificate for %s not found in log %s", leaf.Subject.CommonName, d.ctLogURL)
+}
+
+// ctLogClient creates the client used for log lookups.
+// It honors the proxy, TLS and local address preferences like the download clients,
+// but leaves out the transparency check itself since the log's own certificate
+// would otherwise be looked up recursively.
+//
+// Returns:
+//   - *http.Client: Client with a total timeout suitable for short API requests
+func (d *Downloader) ctLogClient() *http.Client {
+	client := makeHTTPClient(&d.Prefs)
+	client.Timeout = 15 * time.Second
+	return client
+}
+
+// signedCertificateTimestamp holds the parts of an SCT needed to rebuild its Merkle tree leaf
+type signedCertificateTimestamp struct {
+	Timestamp  uint64
+	Extensions []byte
+}
+
+// ctLeafHashes computes the Merkle leaf hashes the log would hold for the connection's certificate.
+// SCTs from the TLS handshake refer to the certificate itself, embedded SCTs refer to the precertificate.
+//
+// Parameters:
+//   - cs: State of the TLS connection
+//
+// Returns:
+//   - [][]byte: Candidate leaf hashes, one per SCT
+//   - error: Error if an SCT or the certificate can't be parsed
+func ctLeafHashes(cs tls.ConnectionState) ([][]byte, error) {
+	leaf := cs.PeerCertificates[0]
+	var hashes [][]byte
+
+	// SCTs delivered in the TLS handshake
+	for _, raw := range cs.SignedCertificateTimestamps {
+		sct, err := parseSCT(raw)
+		if err != nil {
+			return nil, err
+		}
+		entry := append(uint24Prefixed(leaf.Raw), uint16Prefixed(sct.Extensions)...)
+		hashes = append(hashes, ctMerkleLeafHash(sct.Timestamp, ctX509Entry, entry))
+	}
+
+	// SCTs embedded in the certificate
+	embedded, err := embeddedSCTs(leaf)
+	if err != nil {
+		return nil, err
+	}
+	if len(embedded) > 0 {
+		issuer := ctIssuer(cs)
+		if issuer == nil {
+			return nil, fmt.Errorf("issuer certificate not available for embedded timestamps")
+		}
+
+		tbs, err := precertTBS(leaf)
+		if err != nil {
+			return nil, err
+		}
+
+		issuerKeyHash := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+		for _, sct := range embedded {
+			entry := append([]byte{}, issuerKeyHash[:]...)
+			entry = append(entry, uint24Prefixed(tbs)...)
+			entry = append(entry, uint16Prefixed(sct.Extensions)...)
+			hashes = append(hashes, ctMerkleLeafHash(sct.Timestamp, ctPrecertEntry, entry))
+		}
+	}
+
+	return hashes, nil
+}
+
+// ctIssuer returns the issuer of the leaf certificate from the verified or presented chain.
+func ctIssuer(cs tls.ConnectionState) *x509.Certificate {
+	if len(cs.VerifiedChains) > 0 && len(cs.VerifiedChains[0]) > 1 {
+		return cs.VerifiedChains[0][1]
+	}
+	if len(cs.PeerCertificates) > 1 {
+		return cs.PeerCertificates[1]
+	}
+	return nil
+}
+
+// ctMerkleLeafHash hashes a MerkleTreeLeaf structure (RFC 6962 section 3.4 and 2.1).
+func ctMerkleLeafHash(timestamp uint64, entryType uint16, signedEntry []byte) []byte {
+	leaf := []byte{0, 0} // version v1, leaf type timestamped_entry
+	leaf = binary.BigEndian.AppendUint64(leaf, timestamp)
+	leaf = binary.BigEndian.AppendUint16(leaf, entryType)
+	leaf = append(leaf, signedEntry...)
+
+	hash := sha256.Sum256(append([]byte{0}, leaf...))
+	return hash[:]
+}
+
+// parseSCT parses the timestamp and extensions of a TLS encoded SCT.
+func parseSCT(raw []byte) (signedCertificateTimestamp, error) {
+	var sct signedCertificateTimestamp
+
+	// version (1) + log id (32) + timestamp (8) + extensions length (2)
+	if len(raw) < 43 || raw[0] != 0 {
+		return sct, fmt.Errorf("invalid signed certificate timestamp")
+	}
+
+	sct.Timestamp = binary.BigEndian.Uint64(raw[33:41])
+	extLen := int(binary.BigEndian.Uint16(raw[41:43]))
+	if len(raw) < 43+extLen {
+		return sct, fmt.Errorf("truncated signed certificate timestamp")
+	}
+	sct.Extensions = raw[43 : 43+extLen]
+
+	return sct, nil
+}
+
+// embeddedSCTs returns the SCTs embedded in a certificate extension.
+func embeddedSCTs(cert *x509.Certificate) ([]signedCertificateTimestamp, error) {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(sctExtensionOID) {
+			continue
+		}
+
+		var list []byte
+		if _, err := asn1.Unmarshal(ext.Value, &list); err != nil {
+			return nil, fmt.Errorf("invalid embedded timestamp list: %v", err)
+		}
+		if len(list) < 2 {
+			return nil, fmt.Errorf("invalid embedded timestamp list")
+		}
+
+		var scts []signedCertificateTimestamp
+		rest := list[2:]
+		for len(rest) >= 2 {
+			sctLen := int(binary.BigEndian.Uint16(rest))
+			if len(rest) < 2+sctLen {
+				return nil, fmt.Errorf("truncated embedded timestamp list")
+			}
+
+			sct, err := parseSCT(rest[2 : 2+sctLen])
+			if err != nil {
+				return nil, err
+			}
+			scts = append(scts, sct)
+			rest = rest[2+sctLen:]
+		}
+		return scts, nil
+	}
+
+	return nil, nil
+}
+
+// tbsCertificate mirrors the TBSCertificate ASN.1 structure so extensions can be rewritten
+type tbsCertificate struct {
+	Version            int `asn1:"optional,explicit,default:0,tag:0"`
+	SerialNumber       *big.Int
+	SignatureAlgorithm asn1.RawValue
+	Issuer             asn1.RawValue
+	Validity           asn1.RawValue
+	Subject            asn1.RawValue
+	PublicKey          asn1.RawValue
+	UniqueID           asn1.BitString   `asn1:"optional,tag:1"`
+	SubjectUniqueID    asn1.BitString   `asn1:"optional,tag:2"`
+	Extensions         []pkix.Extension `asn1:"omitempty,optional,explicit,tag:3"`
+}
+
+// precertTBS rebuilds the precertificate TBSCertificate by removing the embedded SCT extension.
+func precertTBS(cert *x509.Certificate) ([]byte, error) {
+	var tbs tbsCertificate
+	if _, err := asn1.Unmarshal(cert.RawTBSCertificate, &tbs); err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %v", err)
+	}
+
+	extensions := tbs.Extensions[:0]
+	for _, ext := range tbs.Extensions {
+		if !ext.Id.Equal(sctExtensionOID) {
+			extensions = append(extensions, ext)
+		}
+	}
+	tbs.Extensions = extensions
+
+	return asn1.Marshal(tbs)
+}
+
+// ctTreeSize fetches the current tree size of the log (get-sth).
+func ctTreeSize(client *http.Client, logURL string) (uint64, error) {
+	resp, err := client.Get(logURL + "/ct/v1/get-sth")
+	if err != nil {
+		return 0, fmt.Errorf("failed to query log: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("log returned status code %d for get-sth", resp.StatusCode)
+	}
+
+	var sth struct {
+		TreeSize uint64 `json:"tree_size"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&sth); err != nil {
+		return 0, fmt.Errorf("invalid get-sth response: %v", err)
+	}
+
+	return sth.TreeSize, nil
+}
+
+// ctHasLeaf checks whether the log contains a leaf by requesting its inclusion proof (get-proof-by-hash).
+func ctHasLeaf(client *http.Client, logURL string, leafHash []byte, treeSize uint64) (bool, error) {
+	query := url.Values{}
+	query.Set("hash", base64.StdEncoding.EncodeToString(leafHash))
+	query.Set("tree_size", fmt.Sprintf("%d", treeSize))
+
+	resp, err := client.Get(logURL + "/ct/v1/get-proof-by-hash?" + query.Encode())
+	if err != nil {
+		return false, fmt.Errorf("failed to query log: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusBadRequest, http.StatusNotFound:
+		// The log answers with 400 or 404 for unknown leaves
+		return false, nil
+	default:
+		return false, fmt.Errorf("log returned status code %d for get-proof-by-hash", resp.StatusCode)
+	}
+}
+
+// uint16Prefixed prepends a 2 byte big endian length to data.
+func uint16Prefixed(data []byte) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, uint16(len(data))), data...)
+}
+
+// uint24Prefixed prepends a 3 byte big endian length to data.
+func uint24Prefixed(data []byte) []byte {
+	n := len(data)
+	return append([]byte{byte(n >> 16), byte(n >> 8), byte(n)}, data...)
+}
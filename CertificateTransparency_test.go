@@ -0,0 +1,31 @@
+package udm
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCTLogClientUsesProxy(t *testing.T) {
+	// An HTTP proxy receives the absolute URL of the log request
+	var proxiedURL string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedURL = r.URL.String()
+		io.WriteString(w, `{"tree_size": 42}`)
+	}))
+	defer proxy.Close()
+
+	d := &Downloader{Prefs: UserPreferences{ProxyURL: proxy.URL}}
+
+	treeSize, err := ctTreeSize(d.ctLogClient(), "http://ct.example.invalid")
+	if err != nil {
+		t.Fatalf("ctTreeSize() error = %v", err)
+	}
+	if treeSize != 42 {
+		t.Errorf("tree size = %d, want 42", treeSize)
+	}
+	if want := "http://ct.example.invalid/ct/v1/get-sth"; proxiedURL != want {
+		t.Errorf("proxy received %q, want %q", proxiedURL, want)
+	}
+}
@@ -0,0 +1,77 @@
+package udm
+
+import (
+	"math/rand/v2"
+	"sort"
+)
+
+// ChunkOrderStrategy decides in which order chunk downloads are started
+type ChunkOrderStrategy int
+
+// Chunk ordering strategies
+const (
+	ChunkOrderSequential   ChunkOrderStrategy = iota // Start chunks in file order (default)
+	ChunkOrderRandom                                 // Start chunk 0 first, then the rest in random order
+	ChunkOrderCenterOut                              // Start the middle chunks first, useful for seeking
+	ChunkOrderLargestFirst                           // Start the largest chunks first
+)
+
+// String returns the name of the strategy
+func (s ChunkOrderStrategy) String() string {
+	switch s {
+	case ChunkOrderRandom:
+		return "random"
+	case ChunkOrderCenterOut:
+		return "center-out"
+	case ChunkOrderLargestFirst:
+		return "largest-first"
+	default:
+		return "sequential"
+	}
+}
+
+// chunkLaunchOrder returns the chunk indexes in the order they should be started.
+//
+// Returns:
+//   - []int: Permutation of the indexes of d.Chunks
+func (d *Downloader) chunkLaunchOrder() []int {
+	order := make([]int, len(d.Chunks))
+	for i := range order {
+		order[i] = i
+	}
+
+	// Sequential chunks are always downloaded in file order
+	if len(order) < 2 || d.Prefs.SequentialChunks {
+		return order
+	}
+
+	switch d.Prefs.ChunkOrder {
+	case ChunkOrderRandom:
+		// Keep the first chunk first so the beginning of the file can be previewed early
+		rest := order[1:]
+		rand.Shuffle(len(rest), func(i, j int) {
+			rest[i], rest[j] = rest[j], rest[i]
+		})
+
+	case ChunkOrderCenterOut:
+		// Alternate outwards from the middle chunk: mid, mid-1, mid+1, mid-2, ...
+		mid := (len(order) - 1) / 2
+		order = order[:0]
+		order = append(order, mid)
+		for offset := 1; len(order) < len(d.Chunks); offset++ {
+			if mid-offset >= 0 {
+				order = append(order, mid-offset)
+			}
+			if mid+offset < len(d.Chunks) {
+				order = append(order, mid+offset)
+			}
+		}
+
+	case ChunkOrderLargestFirst:
+		sort.SliceStable(order, func(i, j int) bool {
+			return d.Chunks[order[i]].Size > d.Chunks[order[j]].Size
+		})
+	}
+
+	return order
+}
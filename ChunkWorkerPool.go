@@ -0,0 +1,114 @@
+package udm
+
+import (
+	"context"
+	"sync"
+)
+
+// ChunkWorkerPool runs chunk tasks on a fixed number of worker goroutines.
+// Files divided into many small chunks then don't start hundreds of goroutines
+// and connections at once, the remaining chunks wait for a free worker instead.
+// The first failed task cancels the context of the pool, tasks that did not start yet
+// are skipped and running tasks using Context stop early.
+//
+// Example:
+//
+//	pool := NewChunkWorkerPoolWithContext(ctx, 4)
+//	for i := range chunks {
+//		pool.Submit(ChunkTask{Chunk: chunks[i], Run: func() error {
+//			return downloadChunk(pool.Context(), i)
+//		}})
+//	}
+//	if err := pool.Wait(); err != nil {
+//		log.Println("Chunk failed:", err)
+//	}
+type ChunkWorkerPool struct {
+	tasks  chan ChunkTask
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	errOnce  sync.Once
+	firstErr error
+}
+
+// NewChunkWorkerPool creates a pool and starts its workers.
+//
+// Parameters:
+//   - workers: Maximum number of tasks running at the same time (at least 1)
+//
+// Returns:
+//   - *ChunkWorkerPool: Pool ready to accept tasks using Submit
+func NewChunkWorkerPool(workers int) *ChunkWorkerPool {
+	return NewChunkWorkerPoolWithContext(context.Background(), workers)
+}
+
+// NewChunkWorkerPoolWithContext creates a pool whose context is derived from ctx
+// and starts its workers.
+//
+// Parameters:
+//   - ctx: Parent of the pool context, cancelling it stops the pool like a failed task
+//   - workers: Maximum number of tasks running at the same time (at least 1)
+//
+// Returns:
+//   - *ChunkWorkerPool: Pool ready to accept tasks using Submit
+func NewChunkWorkerPoolWithContext(ctx context.Context, workers int) *ChunkWorkerPool {
+	workers = max(workers, 1)
+
+	pool := &ChunkWorkerPool{
+		tasks: make(chan ChunkTask, workers),
+	}
+	pool.ctx, pool.cancel = context.WithCancel(ctx)
+
+	pool.wg.Add(workers)
+	for range workers {
+		go pool.worker()
+	}
+
+	return pool
+}
+
+// Context returns the context of the pool, cancelled when a task fails or Wait returns.
+// Tasks pass it to their requests so they stop once another task failed.
+func (p *ChunkWorkerPool) Context() context.Context {
+	return p.ctx
+}
+
+// Submit queues a task, blocking while all workers are busy and the queue is full.
+// Submit must not be called after Wait.
+//
+// Parameters:
+//   - task: Task to run
+func (p *ChunkWorkerPool) Submit(task ChunkTask) {
+	p.tasks <- task
+}
+
+// Wait stops accepting tasks and blocks until all submitted tasks have finished.
+//
+// Returns:
+//   - error: Error of the first task that failed, nil if all succeeded
+func (p *ChunkWorkerPool) Wait() error {
+	close(p.tasks)
+	p.wg.Wait()
+	p.cancel()
+	return p.firstErr
+}
+
+// worker runs tasks until the task channel is closed.
+// Tasks received after the pool context was cancelled are skipped.
+func (p *ChunkWorkerPool) worker() {
+	defer p.wg.Done()
+
+	for task := range p.tasks {
+		if p.ctx.Err() != nil {
+			continue
+		}
+
+		if err := task.Run(); err != nil {
+			p.errOnce.Do(func() {
+				p.firstErr = err
+				p.cancel()
+			})
+		}
+	}
+}
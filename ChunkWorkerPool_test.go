@@ -0,0 +1,64 @@
+package udm
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChunkWorkerPoolCancelsOnFirstError(t *testing.T) {
+	errChunk := errors.New("chunk failed")
+	pool := NewChunkWorkerPoolWithContext(context.Background(), 2)
+
+	// A running task stops once another task fails
+	running := make(chan struct{})
+	pool.Submit(ChunkTask{Run: func() error {
+		close(running)
+		select {
+		case <-pool.Context().Done():
+			return pool.Context().Err()
+		case <-time.After(5 * time.Second):
+			return errors.New("running task was not cancelled")
+		}
+	}})
+
+	<-running
+	pool.Submit(ChunkTask{Run: func() error { return errChunk }})
+
+	// Tasks waiting for a worker are not started anymore
+	var started atomic.Int32
+	for range 4 {
+		pool.Submit(ChunkTask{Run: func() error {
+			started.Add(1)
+			return nil
+		}})
+	}
+
+	if err := pool.Wait(); !errors.Is(err, errChunk) {
+		t.Errorf("Wait() = %v, want the first error %v", err, errChunk)
+	}
+	if n := started.Load(); n != 0 {
+		t.Errorf("%d tasks started after the failure, want 0", n)
+	}
+}
+
+func TestChunkWorkerPoolRunsAllTasks(t *testing.T) {
+	pool := NewChunkWorkerPool(3)
+
+	var completed atomic.Int32
+	for range 10 {
+		pool.Submit(ChunkTask{Run: func() error {
+			completed.Add(1)
+			return nil
+		}})
+	}
+
+	if err := pool.Wait(); err != nil {
+		t.Fatalf("Wait() = %v, want nil", err)
+	}
+	if n := completed.Load(); n != 10 {
+		t.Errorf("%d tasks completed, want 10", n)
+	}
+}
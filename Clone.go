@@ -0,0 +1,89 @@
+package udm
+
+// Clone creates a new downloader with the same configuration as this one, e.g. to
+// download many files with the same authentication headers and callbacks.
+// Runtime state like server headers, progress, chunks, status and errors is not copied,
+// the clone gets its own progress tracker, pause controller and time stats and a new ID,
+// so it can be started independently of the original.
+//
+// Returns:
+//   - *Downloader: A new downloader sharing URL, preferences, headers and callbacks,
+//     as well as the transport, CT log, memory cap, URL refresher and tracer set on it
+//
+// Example:
+//
+//	for _, url := range urls {
+//		clone := template.Clone()
+//		clone.Url = url
+//		go clone.StartDownload()
+//	}
+func (d *Downloader) Clone() *Downloader {
+	clone := &Downloader{
+		ID:              newDownloadID(d.Prefs.IDPrefix),
+		Url:             d.requestURL(),
+		Prefs:           d.Prefs,
+		UseProgressBar:  d.UseProgressBar,
+		ExpectedSHA256:  d.ExpectedSHA256,
+		History:         d.History,
+		customTransport: d.customTransport,
+		ctLogURL:        d.ctLogURL,
+		maxMemoryUsage:  d.maxMemoryUsage,
+		tracer:          d.tracer,
+		Progress:        &ProgressTracker{},
+		PauseControl:    NewPauseController(),
+		TimeStats:       &TimeInfo{},
+	}
+
+	// The refresher may be replaced while the original is downloading
+	d.urlMu.RLock()
+	clone.urlRefresher = d.urlRefresher
+	d.urlMu.RUnlock()
+
+	// Copy slices of the preferences so changes to the clone don't affect the original
+	clone.Prefs.TLSCipherSuites = append([]uint16(nil), d.Prefs.TLSCipherSuites...)
+
+	// Copy mirrors so changes to the clone don't affect the original
+	clone.FallbackURLs = append([]string(nil), d.FallbackURLs...)
+
+	// Copy headers so changes to the clone don't affect the original
+	clone.Headers.Cookies = d.Headers.Cookies
+	clone.Headers.BasicAuthUser = d.Headers.BasicAuthUser
+	clone.Headers.BasicAuthPass = d.Headers.BasicAuthPass
+	if d.Headers.Headers != nil {
+		clone.Headers.Headers = make(map[string]string, len(d.Headers.Headers))
+		for key, value := range d.Headers.Headers {
+			clone.Headers.Headers[key] = value
+		}
+	}
+
+	// Copy callbacks so they can be replaced on the clone independently
+	if callbacks := d.callbacks(); callbacks != nil {
+		callbacksCopy := *callbacks
+		clone.Callbacks = &callbacksCopy
+	}
+
+	return clone
+}
+
+// CloneWithURL creates a clone of the downloader that downloads from a different URL.
+// This is intended for mirror failover, like Clone no server headers are copied so a
+// fresh Prefetch is performed for the new URL. Chunk ranges are never reused since a
+// different server may serve different content.
+//
+// Parameters:
+//   - newURL: The mirror URL to download from
+//
+// Returns:
+//   - *Downloader: A new downloader with the same configuration and the new URL
+//
+// Example:
+//
+//	downloader.Callbacks.OnError = func(d *Downloader, err error) {
+//		mirror := d.CloneWithURL("https://mirror.example.com/file.zip")
+//		go mirror.StartDownload()
+//	}
+func (d *Downloader) CloneWithURL(newURL string) *Downloader {
+	clone := d.Clone()
+	clone.Url = newURL
+	return clone
+}
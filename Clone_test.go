@@ -0,0 +1,58 @@
+package udm
+
+import (
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestCloneCopiesConfiguration(t *testing.T) {
+	d := &Downloader{Url: "https://example.com/file.zip"}
+	d.EnableCTVerification("https://ct.example.com/log/")
+	d.SetMaxMemoryUsage(64 * 1024 * 1024)
+	d.SetTracer(noop.NewTracerProvider().Tracer("test"))
+	d.SetURLRefresher(func() (string, error) {
+		return "https://example.com/file.zip?token=new", nil
+	})
+
+	for name, clone := range map[string]*Downloader{
+		"Clone":        d.Clone(),
+		"CloneWithURL": d.CloneWithURL("https://mirror.example.com/file.zip"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if clone.ctLogURL != d.ctLogURL {
+				t.Errorf("ctLogURL = %q, want %q", clone.ctLogURL, d.ctLogURL)
+			}
+			if clone.maxMemoryUsage != d.maxMemoryUsage {
+				t.Errorf("maxMemoryUsage = %d, want %d", clone.maxMemoryUsage, d.maxMemoryUsage)
+			}
+			if clone.tracer != d.tracer {
+				t.Errorf("tracer was not copied")
+			}
+			if clone.urlRefresher == nil {
+				t.Fatalf("urlRefresher was not copied")
+			}
+			if url, _ := clone.urlRefresher(); url != "https://example.com/file.zip?token=new" {
+				t.Errorf("urlRefresher() = %q, want the original refresher's URL", url)
+			}
+		})
+	}
+}
+
+func TestCloneLeavesRuntimeStateZero(t *testing.T) {
+	d := &Downloader{Url: "https://example.com/file.zip", Status: DOWNLOAD_COMPLETED}
+	d.ServerHeaders = ServerData{Filesize: 1024, FinalURL: "https://cdn.example.com/file.zip", AcceptsRanges: true}
+	d.Chunks = []ChunkData{{Start: 0, End: 1023}}
+
+	clone := d.Clone()
+	if !reflect.DeepEqual(clone.ServerHeaders, ServerData{}) {
+		t.Errorf("ServerHeaders = %+v, want the zero value", clone.ServerHeaders)
+	}
+	if clone.Status != "" || clone.Chunks != nil {
+		t.Errorf("Status = %q, Chunks = %v, want both empty", clone.Status, clone.Chunks)
+	}
+	if clone.ID == d.ID {
+		t.Errorf("clone shares the ID %q of the original", d.ID)
+	}
+}
@@ -0,0 +1,194 @@
+package udm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"udl/udm/ufs"
+)
+
+// METADATA_FILE_EXTENSION is the extension of the sidecar file written next to a completed
+// download when UserPreferences.SkipIfUnchanged is set
+const METADATA_FILE_EXTENSION = ".udmmeta"
+
+// fileMetadata is the JSON content of the sidecar metadata file
+type fileMetadata struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified time.Time `json:"last_modified,omitzero"`
+	Filesize     int64     `json:"filesize"`
+	DownloadedAt time.Time `json:"downloaded_at"`
+}
+
+// parseLastModified parses the value of a Last-Modified header.
+//
+// Parameters:
+//   - header: Header value in one of the formats accepted by http.ParseTime
+//
+// Returns:
+//   - time.Time: Time the file was last modified on the server, zero if the header is missing or invalid
+func parseLastModified(header string) time.Time {
+	if header == "" {
+		return time.Time{}
+	}
+
+	modified, err := http.ParseTime(header)
+	if err != nil {
+		return time.Time{}
+	}
+	return modified
+}
+
+// MetadataFilePath returns the path of the sidecar file holding the ETag and
+// Last-Modified validators of the downloaded file.
+//
+// Returns:
+//   - string: Output path followed by METADATA_FILE_EXTENSION
+func (d *Downloader) MetadataFilePath() string {
+	return d.fileInfo.FullPath + METADATA_FILE_EXTENSION
+}
+
+// IsFileStale asks the server whether the file at the output path is outdated, using a
+// conditional HEAD request with the validators stored in the sidecar metadata file.
+// Without a sidecar file the modification time of the local file is used instead.
+//
+// Returns:
+//   - bool: False if the server responded 304 Not Modified, true otherwise
+//   - error: Error if the request failed or the server responded with an error status
+//
+// Example:
+//
+//	stale, err := downloader.IsFileStale()
+//	if err == nil && !stale {
+//	    fmt.Println("Already up to date")
+//	}
+func (d *Downloader) IsFileStale() (bool, error) {
+	info, err := os.Stat(d.fileInfo.FullPath)
+	if err != nil {
+		// Nothing downloaded yet
+		return true, nil
+	}
+
+	ctx := d.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "HEAD", d.requestURL(), nil)
+	if err != nil {
+		return true, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	for key, value := range d.Headers.Headers {
+		req.Header.Set(key, value)
+	}
+
+	if d.Headers.Cookies != "" {
+		req.Header.Set("Cookie", d.Headers.Cookies)
+	}
+
+	if metadata, err := d.readMetadataFile(); err == nil && (metadata.ETag != "" || !metadata.LastModified.IsZero()) {
+		if metadata.ETag != "" {
+			req.Header.Set("If-None-Match", metadata.ETag)
+		}
+		if !metadata.LastModified.IsZero() {
+			req.Header.Set("If-Modified-Since", metadata.LastModified.UTC().Format(http.TimeFormat))
+		}
+	} else {
+		req.Header.Set("If-Modified-Since", info.ModTime().UTC().Format(http.TimeFormat))
+	}
+
+	resp, err := d.buildHTTPClient().Do(req)
+	if err != nil {
+		return true, &NetworkError{Op: "request", Host: req.URL.Host, Err: err}
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return false, nil
+	case resp.StatusCode >= 400:
+		return true, &ServerError{StatusCode: resp.StatusCode, URL: req.URL.String()}
+	default:
+		return true, nil
+	}
+}
+
+// skipIfUnchanged completes the download without transferring anything when
+// SkipIfUnchanged is set and the server confirms the existing file is up to date.
+// An outdated file is replaced by the download instead of getting a "filename (N).ext"
+// copy, so the next run checks the new version against its metadata.
+//
+// Returns:
+//   - bool: True if the download was skipped
+func (d *Downloader) skipIfUnchanged() bool {
+	if !d.Prefs.SkipIfUnchanged || !ufs.FileExists(d.fileInfo.FullPath) {
+		return false
+	}
+
+	stale, err := d.IsFileStale()
+	if err != nil || stale {
+		d.replaceOutput = true
+		return false
+	}
+
+	d.SkippedUnchanged = true
+	d.OutputPath = d.fileInfo.FullPath
+
+	d.setStatus(DOWNLOAD_IN_PROGRESS)
+	d.setStatus(DOWNLOAD_COMPLETED)
+	d.TimeStats.StartTime = time.Now()
+	d.TimeStats.EndTime = d.TimeStats.StartTime
+	d.recordHistory()
+	d.endDownloadSpan(nil)
+	d.logEvent(EVENT_COMPLETED, "skipped, %s is unchanged on the server", d.OutputPath)
+	d.sendWebhook(WEBHOOK_EVENT_FINISH)
+
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnFinish != nil {
+		callbacks.OnFinish(d)
+	}
+
+	return true
+}
+
+// writeMetadataFile stores the validators of the completed download in the sidecar file.
+// Failures are only logged, the download itself succeeded.
+func (d *Downloader) writeMetadataFile() {
+	metadata := fileMetadata{
+		URL:          d.requestURL(),
+		ETag:         d.ServerHeaders.ETag,
+		LastModified: d.ServerHeaders.LastModified,
+		Filesize:     d.ServerHeaders.Filesize,
+		DownloadedAt: time.Now(),
+	}
+
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err == nil {
+		err = os.WriteFile(d.MetadataFilePath(), data, 0644)
+	}
+	if err != nil {
+		logf("Failed to write download metadata: %v\n", err)
+	}
+}
+
+// readMetadataFile reads the sidecar file of the output path.
+//
+// Returns:
+//   - *fileMetadata: Decoded metadata
+//   - error: Error if the file cannot be read or decoded
+func (d *Downloader) readMetadataFile() (*fileMetadata, error) {
+	data, err := os.ReadFile(d.MetadataFilePath())
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &fileMetadata{}
+	if err := json.Unmarshal(data, metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
@@ -0,0 +1,76 @@
+package udm
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSkipIfUnchangedReplacesStaleFile(t *testing.T) {
+	previous := UDMSettings
+	UDMSettings = &Settings{}
+	defer func() { UDMSettings = previous }()
+
+	var mu sync.Mutex
+	content, etag := []byte("first version"), `"v1"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "report.txt", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	download := func() *Downloader {
+		d := &Downloader{Url: server.URL + "/report.txt"}
+		d.Prefs.DownloadDir = dir
+		d.Prefs.SkipIfUnchanged = true
+		d.StartDownload()
+		if d.Error != nil {
+			t.Fatalf("download failed: %v", d.Error)
+		}
+		return d
+	}
+
+	first := download()
+	path := filepath.Join(dir, "report.txt")
+	if first.OutputPath != path {
+		t.Fatalf("OutputPath = %q, want %q", first.OutputPath, path)
+	}
+
+	if second := download(); !second.SkippedUnchanged {
+		t.Error("unchanged file was downloaded again")
+	}
+
+	mu.Lock()
+	content, etag = []byte("second version"), `"v2"`
+	mu.Unlock()
+
+	third := download()
+	if third.SkippedUnchanged {
+		t.Fatal("stale file was skipped")
+	}
+	if third.OutputPath != path {
+		t.Errorf("stale file downloaded to %q, want it replaced at %q", third.OutputPath, path)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "second version" {
+		t.Errorf("file content = %q, want the new version", got)
+	}
+
+	if fourth := download(); !fourth.SkippedUnchanged {
+		t.Error("replaced file was downloaded again")
+	}
+
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if name := entry.Name(); name != "report.txt" && name != "report.txt"+METADATA_FILE_EXTENSION {
+			t.Errorf("unexpected file %s left in the download directory", name)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package udm
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// diagnosticOutput receives the warnings the library prints while a download keeps going,
+// e.g. retries, mirror switches or chunk restarts.
+// It is stderr so stdout only carries the JSON lines of JSONProgressMode.
+var diagnosticOutput io.Writer = os.Stderr
+
+// logf writes a diagnostic message to diagnosticOutput
+//
+// Parameters:
+//   - format: fmt format string, should end with a newline
+//   - args: values for the format string
+//
+// Example:
+//
+//	logf("Restarting chunk %d: %v\n", chunkIndex, err)
+func logf(format string, args ...any) {
+	fmt.Fprintf(diagnosticOutput, format, args...)
+}
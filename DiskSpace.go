@@ -0,0 +1,91 @@
+package udm
+
+import (
+	"os"
+
+	"udl/udm/ufs"
+)
+
+// checkDiskSpace verifies that the download directory has room for required more bytes.
+// The check is skipped when the free space cannot be determined on this platform.
+//
+// Parameters:
+//   - required: Number of bytes that will be written
+//
+// Returns:
+//   - error: InsufficientDiskSpaceError if there is not enough room, nil otherwise
+func (d *Downloader) checkDiskSpace(required int64) error {
+	if required <= 0 {
+		return nil
+	}
+
+	available, err := ufs.AvailableDiskSpace(d.fileInfo.Dir)
+	if err != nil {
+		return nil
+	}
+
+	if available < required {
+		return &InsufficientDiskSpaceError{Required: required, Available: available}
+	}
+	return nil
+}
+
+// multiStreamSpaceRequired returns the free space a multi-stream download still needs.
+// Besides the bytes not downloaded yet, merging writes a full copy of the file before
+// the chunk files are deleted. Multipart range downloads write the output file directly.
+//
+// Parameters:
+//   - reuseChunks: Whether the chunk files of a previous run are resumed
+//
+// Returns:
+//   - int64: Number of bytes, 0 if the file size is unknown
+func (d *Downloader) multiStreamSpaceRequired(reuseChunks bool) int64 {
+	filesize := d.ServerHeaders.Filesize
+	if filesize <= 0 {
+		return 0
+	}
+
+	// Bytes kept from a single-stream download before an elevation become the first chunk
+	existing := max(d.elevationOffset, 0)
+	if reuseChunks {
+		existing += d.existingChunkBytes()
+	}
+
+	remaining := max(filesize-existing, 0)
+	if d.Prefs.UseMultipartRange {
+		return remaining
+	}
+	return remaining + filesize
+}
+
+// existingChunkBytes returns the number of bytes of d.Chunks already in their chunk files.
+//
+// Returns:
+//   - int64: Bytes that don't have to be downloaded again
+func (d *Downloader) existingChunkBytes() int64 {
+	var existing int64
+
+	chunkFileNames := ufs.GenerateChunkFileNames(d.fileInfo.Name, len(d.Chunks), d.fileInfo.Dir)
+	for i, chunkFileName := range chunkFileNames {
+		if info, err := os.Stat(chunkFileName); err == nil {
+			existing += min(info.Size(), d.Chunks[i].Size)
+		}
+	}
+	return existing
+}
+
+// preallocate reserves disk space for a newly created file unless DisablePreallocation is set.
+// It is best effort, the download works the same when space cannot be reserved.
+// The space is reserved on the handle the file is written with, Windows releases it
+// when the last handle is closed.
+//
+// Parameters:
+//   - file: The new file, open for writing
+//   - size: Number of bytes the file will hold, nothing is done if unknown
+func (d *Downloader) preallocate(file *os.File, size int64) {
+	if d.Prefs.DisablePreallocation || size <= 0 {
+		return
+	}
+
+	ufs.PreallocateOpenFile(file, size)
+}
@@ -0,0 +1,60 @@
+package udm
+
+import (
+	"os"
+	"testing"
+
+	"udl/udm/ufs"
+)
+
+func TestMultiStreamSpaceRequired(t *testing.T) {
+	dir := t.TempDir()
+
+	d := &Downloader{}
+	d.fileInfo = FileInfo{Name: "video.mp4", Dir: dir}
+	d.ServerHeaders.Filesize = 1000
+	d.Chunks = []ChunkData{
+		{Index: 0, Start: 0, End: 499, Size: 500},
+		{Index: 1, Start: 500, End: 999, Size: 500},
+	}
+
+	// The first chunk is complete, the second one has 100 bytes
+	chunkFileNames := ufs.GenerateChunkFileNames(d.fileInfo.Name, 2, dir)
+	if err := os.WriteFile(chunkFileNames[0], make([]byte, 500), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(chunkFileNames[1], make([]byte, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		reuseChunks     bool
+		multipartRange  bool
+		elevationOffset int64
+		unknownSize     bool
+		want            int64
+	}{
+		{name: "new chunks need the file and its merged copy", want: 2000},
+		{name: "resumed chunks only need the missing bytes and the merged copy", reuseChunks: true, want: 400 + 1000},
+		{name: "elevated bytes are kept", elevationOffset: 300, want: 700 + 1000},
+		{name: "multipart range writes the output directly", multipartRange: true, want: 1000},
+		{name: "resumed multipart range", reuseChunks: true, multipartRange: true, want: 400},
+		{name: "unknown size", unknownSize: true, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d.Prefs.UseMultipartRange = tt.multipartRange
+			d.elevationOffset = tt.elevationOffset
+			d.ServerHeaders.Filesize = 1000
+			if tt.unknownSize {
+				d.ServerHeaders.Filesize = -1
+			}
+
+			if got := d.multiStreamSpaceRequired(tt.reuseChunks); got != tt.want {
+				t.Errorf("multiStreamSpaceRequired(%v) = %d, want %d", tt.reuseChunks, got, tt.want)
+			}
+		})
+	}
+}
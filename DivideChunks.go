@@ -13,7 +13,9 @@ package udm
 // Notes:
 //   - The function ensures that the sum of all chunk sizes equals fileSize.
 //   - Any remainder bytes (if fileSize is not evenly divisible by chunkCount)
-//     are added to the second-to-last chunk to avoid underflow in the last chunk.
+//     are spread over the first chunks, one byte each, so chunk sizes differ by at most one byte.
+//   - No chunk is ever empty, fewer chunks are returned if fileSize is smaller than chunkCount.
+//   - A chunkCount below 1 is treated as 1, an empty slice is returned if fileSize is not positive.
 //
 // Example:
 //
@@ -26,22 +28,81 @@ package udm
 //
 // fmt.Printf("TOtal chunk size got :: %d", totalChunkSize)
 func DivideChunks(fileSize int64, chunkCount int) []int64 {
-	chunks := make([]int64, chunkCount)
+	if fileSize <= 0 {
+		return []int64{}
+	}
 
-	chunkSize := int64(fileSize / int64(chunkCount)) // Ensure it is a floor value
+	// Every chunk must contain at least one byte
+	chunkCount = max(chunkCount, 1)
+	if int64(chunkCount) > fileSize {
+		chunkCount = int(fileSize)
+	}
 
-	totalAllocatedSize := chunkCount * int(chunkSize)
-	underFlowSize := fileSize - int64(totalAllocatedSize)
+	chunks := make([]int64, chunkCount)
 
-	for i := 0; i < chunkCount; i++ {
-		// Include underFLow into the last chunk info Ensure no underflow Exists
-		if i == chunkCount-2 {
-			chunks[i] = chunkSize + underFlowSize
-			continue
-		}
+	chunkSize := fileSize / int64(chunkCount) // Floor value
+	remainder := fileSize % int64(chunkCount)
 
+	for i := range chunks {
 		chunks[i] = chunkSize
+
+		// Spread the remaining bytes over the first chunks
+		if int64(i) < remainder {
+			chunks[i]++
+		}
 	}
 
 	return chunks
 }
+
+// DEFAULT_MIN_CHUNK_SIZE_BYTES is the smallest chunk size used when Settings.MinChunkSizeBytes is not set
+const DEFAULT_MIN_CHUNK_SIZE_BYTES = 1024 * 1024 // 1MB
+
+// MAX_CHUNK_COUNT limits how many chunks DivideChunksWithConstraints creates to respect maxChunkBytes
+const MAX_CHUNK_COUNT = 64
+
+// DivideChunksWithConstraints divides a file into chunks like DivideChunks, adjusting the
+// number of chunks so that every chunk is between minChunkBytes and maxChunkBytes in size.
+//
+// Parameters:
+//   - fileSize:       The total size of the file in bytes.
+//   - requestedCount: The preferred number of chunks.
+//   - minChunkBytes:  Smallest allowed chunk size, fewer chunks are used for small files (0 for no minimum).
+//   - maxChunkBytes:  Largest allowed chunk size, more chunks are used for large files (0 for no maximum).
+//
+// Returns:
+//   - []int64: A slice containing the size of each chunk in bytes.
+//   - int: The number of chunks actually used.
+//
+// Notes:
+//   - The chunk count is reduced until chunks are at least minChunkBytes, down to a single chunk.
+//   - It is then increased until chunks are at most maxChunkBytes, up to MAX_CHUNK_COUNT chunks.
+//   - maxChunkBytes wins if both limits cannot be met.
+//
+// Example:
+//
+//	// 5MB with 8 requested chunks and a 1MB minimum gives 5 chunks of 1MB
+//	chunks, count := DivideChunksWithConstraints(5*1024*1024, 8, 1024*1024, 0)
+func DivideChunksWithConstraints(fileSize int64, requestedCount int, minChunkBytes, maxChunkBytes int64) ([]int64, int) {
+	if fileSize <= 0 {
+		return []int64{}, 0
+	}
+
+	chunkCount := max(requestedCount, 1)
+
+	// Fewer chunks for small files so no chunk is below the minimum
+	if minChunkBytes > 0 && fileSize/int64(chunkCount) < minChunkBytes {
+		chunkCount = int(max(fileSize/minChunkBytes, 1))
+	}
+
+	// More chunks for large files so no chunk is above the maximum
+	if maxChunkBytes > 0 {
+		needed := (fileSize + maxChunkBytes - 1) / maxChunkBytes
+		if needed > int64(chunkCount) {
+			chunkCount = int(min(needed, MAX_CHUNK_COUNT))
+		}
+	}
+
+	chunks := DivideChunks(fileSize, chunkCount)
+	return chunks, len(chunks)
+}
@@ -0,0 +1,71 @@
+package udm
+
+import "testing"
+
+func TestDivideChunks(t *testing.T) {
+	tests := []struct {
+		name       string
+		fileSize   int64
+		chunkCount int
+		want       []int64
+	}{
+		{name: "evenly divisible", fileSize: 100, chunkCount: 4, want: []int64{25, 25, 25, 25}},
+		{name: "remainder spread over first chunks", fileSize: 10, chunkCount: 4, want: []int64{3, 3, 2, 2}},
+		{name: "prime file size", fileSize: 97, chunkCount: 8, want: []int64{13, 12, 12, 12, 12, 12, 12, 12}},
+		{name: "single chunk", fileSize: 12345, chunkCount: 1, want: []int64{12345}},
+		{name: "fewer bytes than chunks", fileSize: 3, chunkCount: 8, want: []int64{1, 1, 1}},
+		{name: "single byte", fileSize: 1, chunkCount: 8, want: []int64{1}},
+		{name: "zero chunks", fileSize: 50, chunkCount: 0, want: []int64{50}},
+		{name: "negative chunks", fileSize: 50, chunkCount: -3, want: []int64{50}},
+		{name: "empty file", fileSize: 0, chunkCount: 4, want: []int64{}},
+		{name: "negative file size", fileSize: -1, chunkCount: 4, want: []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DivideChunks(tt.fileSize, tt.chunkCount)
+			if len(got) != len(tt.want) {
+				t.Fatalf("DivideChunks(%d, %d) = %v, want %v", tt.fileSize, tt.chunkCount, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("DivideChunks(%d, %d) = %v, want %v", tt.fileSize, tt.chunkCount, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDivideChunksSumsToFileSize(t *testing.T) {
+	fileSizes := []int64{1, 2, 3, 7, 10, 31, 97, 100, 101, 127, 509, 1000, 1023, 1024, 1025,
+		4096, 65521, 65536, 1000003, 1048576, 1048583, 104857601, 2147483647, 4294967311}
+
+	for _, fileSize := range fileSizes {
+		for chunkCount := 1; chunkCount <= 32; chunkCount++ {
+			chunks := DivideChunks(fileSize, chunkCount)
+
+			wantCount := int(min(int64(chunkCount), fileSize))
+			if len(chunks) != wantCount {
+				t.Fatalf("DivideChunks(%d, %d) returned %d chunks, want %d", fileSize, chunkCount, len(chunks), wantCount)
+			}
+
+			var sum int64
+			smallest, largest := chunks[0], chunks[0]
+			for _, chunk := range chunks {
+				if chunk <= 0 {
+					t.Fatalf("DivideChunks(%d, %d) returned an empty chunk: %v", fileSize, chunkCount, chunks)
+				}
+				sum += chunk
+				smallest = min(smallest, chunk)
+				largest = max(largest, chunk)
+			}
+
+			if sum != fileSize {
+				t.Fatalf("DivideChunks(%d, %d) sums to %d, want %d", fileSize, chunkCount, sum, fileSize)
+			}
+			if largest-smallest > 1 {
+				t.Fatalf("DivideChunks(%d, %d) chunk sizes differ by %d bytes", fileSize, chunkCount, largest-smallest)
+			}
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package udm
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// HistoryEntry is one finished download in the history log.
+// It holds the fields of GetFinishedMap plus the URL, error and finish time.
+type HistoryEntry struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"`
+	Filename   string     `json:"filename"`
+	OutputDir  string     `json:"output_dir"`
+	FilePath   string     `json:"filepath"`
+	FileSize   int64      `json:"filesize"`
+	TimeTaken  int64      `json:"time_taken"` // Seconds
+	AvgSpeed   float64    `json:"avg_speed"`  // Bytes per second
+	RetryStats RetryStats `json:"retry_stats"`
+
+	URL        string    `json:"url"`
+	Error      string    `json:"error,omitempty"`
+	ErrorCode  string    `json:"error_code,omitempty"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// HistoryFilter selects entries returned by DownloadHistory.List, zero fields match everything
+type HistoryFilter struct {
+	Status      string    // Only entries with this status, e.g. DOWNLOAD_COMPLETED
+	From        time.Time // Only entries finished at or after this time
+	To          time.Time // Only entries finished at or before this time
+	URLPattern  string    // Regular expression the URL must match
+	MinFileSize int64     // Only entries of at least this many bytes
+}
+
+// DownloadHistory records completed and failed downloads to a JSON Lines file,
+// one HistoryEntry per line. Set it as Downloader.History to record a download.
+//
+// Example:
+//
+//	history, err := NewDownloadHistory("")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	downloader.History = history
+//	downloader.StartDownload()
+//
+//	failed, _ := history.List(HistoryFilter{Status: DOWNLOAD_FAILED})
+//	for _, entry := range failed {
+//		fmt.Println(entry.URL, entry.Error)
+//	}
+type DownloadHistory struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewDownloadHistory creates a history stored at path.
+//
+// Parameters:
+//   - path: Path of the history file, empty for ~/.udm/history.jsonl
+//
+// Returns:
+//   - *DownloadHistory: History ready to use, the file is created on the first Record
+//   - error: Error if the home directory cannot be determined
+func NewDownloadHistory(path string) (*DownloadHistory, error) {
+	if path == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %v", err)
+		}
+		path = filepath.Join(homeDir, ".udm", "history.jsonl")
+	}
+
+	return &DownloadHistory{path: path}, nil
+}
+
+// Path returns the path of the history file
+func (h *DownloadHistory) Path() string {
+	return h.path
+}
+
+// Record appends the current state of a finished download to the history file.
+//
+// Parameters:
+//   - d: The completed or failed downloader
+//
+// Returns:
+//   - error: Error if the entry cannot be written
+func (h *DownloadHistory) Record(d *Downloader) error {
+	entry := HistoryEntry{
+		ID:         d.GetID(),
+		Status:     d.GetStatus(),
+		Filename:   d.GetFilename(),
+		OutputDir:  d.GetOutputDir(),
+		FilePath:   d.GetFilePath(),
+		FileSize:   d.GetFileSize(),
+		TimeTaken:  int64(d.GetTimeTaken().Seconds()),
+		AvgSpeed:   d.GetAverageSpeed(),
+		RetryStats: d.GetRetryStats(),
+		URL:        d.GetURL(),
+		ErrorCode:  d.ErrorCode,
+		FinishedAt: time.Now(),
+	}
+	if d.Error != nil {
+		entry.Error = d.Error.Error()
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode history entry: %v", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %v", err)
+	}
+
+	file, err := os.OpenFile(h.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history entry: %v", err)
+	}
+
+	return nil
+}
+
+// List returns the history entries matching the filter, oldest first.
+// Lines that cannot be decoded are skipped.
+//
+// Parameters:
+//   - f: Filter to apply
+//
+// Returns:
+//   - []HistoryEntry: Matching entries (empty if there is no history yet)
+//   - error: Error if the URL pattern is invalid or the file cannot be read
+func (h *DownloadHistory) List(f HistoryFilter) ([]HistoryEntry, error) {
+	var urlPattern *regexp.Regexp
+	if f.URLPattern != "" {
+		pattern, err := regexp.Compile(f.URLPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL pattern: %v", err)
+		}
+		urlPattern = pattern
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	file, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		return []HistoryEntry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %v", err)
+	}
+	defer file.Close()
+
+	entries := []HistoryEntry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if f.matches(entry, urlPattern) {
+			entries = append(entries, entry)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %v", err)
+	}
+
+	return entries, nil
+}
+
+// matches reports whether an entry passes the filter.
+//
+// Parameters:
+//   - entry: Entry to check
+//   - urlPattern: Compiled URLPattern, nil to match any URL
+//
+// Returns:
+//   - bool: True if all set filter fields match
+func (f HistoryFilter) matches(entry HistoryEntry, urlPattern *regexp.Regexp) bool {
+	if f.Status != "" && entry.Status != f.Status {
+		return false
+	}
+	if !f.From.IsZero() && entry.FinishedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && entry.FinishedAt.After(f.To) {
+		return false
+	}
+	if urlPattern != nil && !urlPattern.MatchString(entry.URL) {
+		return false
+	}
+	if f.MinFileSize > 0 && entry.FileSize < f.MinFileSize {
+		return false
+	}
+	return true
+}
+
+// recordHistory adds the download to its history, if one is set.
+// Failures are only logged, they must not affect the download result.
+func (d *Downloader) recordHistory() {
+	if d.History == nil {
+		return
+	}
+
+	if err := d.History.Record(d); err != nil {
+		logf("Failed to record download history: %v\n", err)
+	}
+}
@@ -0,0 +1,41 @@
+package udm
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// newDownloadID generates a unique ID for a downloader.
+//
+// Parameters:
+//   - prefix: Prepended to the ID, e.g. UserPreferences.IDPrefix to namespace a batch
+//
+// Returns:
+//   - string: Prefix, creation time in nanoseconds and 8 random hex characters,
+//     e.g. "batch-A-1760750000000000000-3f9a0c17"
+func newDownloadID(prefix string) string {
+	return fmt.Sprintf("%s%d-%s", prefix, time.Now().UnixNano(), randomHex(4))
+}
+
+// randomHex returns n random bytes from crypto/rand as hex.
+//
+// Parameters:
+//   - n: Number of random bytes
+//
+// Returns:
+//   - string: 2*n hex characters
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ensureID assigns a generated ID to a downloader without one. Downloads are identified
+// by ID in the history, queues and progress output, so an empty ID is never used.
+func (d *Downloader) ensureID() {
+	if d.ID == "" {
+		d.ID = newDownloadID(d.Prefs.IDPrefix)
+	}
+}
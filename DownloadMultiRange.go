@@ -0,0 +1,205 @@
+package udm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// executeMultiRangeDownload downloads all chunks over a single connection
+// using one multi-range request and writes them directly into the output file.
+// It is used instead of concurrent chunk downloads when UserPreferences.UseMultipartRange is set.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+func (d *Downloader) executeMultiRangeDownload(ctx context.Context) {
+	file, err := os.OpenFile(d.incompletePath(), os.O_RDWR|os.O_CREATE|os.O_TRUNC, d.getFileMode())
+	if err != nil {
+		d.handleDownloadError(&DiskError{Op: "open", Path: d.incompletePath(), Err: err})
+		return
+	}
+
+	err = d.downloadMultiRange(ctx, d.Chunks, file)
+	file.Close()
+
+	if err != nil {
+		if ctx.Err() == context.Canceled {
+			d.handleDownloadStop(ctx.Err())
+		} else {
+			d.handleDownloadError(err)
+		}
+		return
+	}
+
+	// Download completed successfully
+	d.finalizeDownload()
+}
+
+// downloadMultiRange fetches several byte ranges with a single request.
+// The server answers with a multipart/byteranges body where every part carries
+// its own Content-Range header, each part is written to its offset in writer.
+// Servers that merge the ranges into a single part or ignore the Range header
+// entirely are handled as well.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - ranges: Chunks to download, only Start and End are used
+//   - writer: Destination supporting writes at arbitrary offsets (usually the output file)
+//
+// Returns:
+//   - error: Error if the request fails or the response cannot be parsed
+func (d *Downloader) downloadMultiRange(ctx context.Context, ranges []ChunkData, writer io.WriterAt) error {
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	client := d.buildHTTPClient()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", d.requestURL(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+
+	// Add custom headers
+	for key, value := range d.Headers.Headers {
+		req.Header.Set(key, value)
+	}
+
+	if d.Headers.Cookies != "" {
+		req.Header.Set("Cookie", d.Headers.Cookies)
+	}
+
+	// Request all ranges at once, e.g. "bytes=0-1023,2048-4095"
+	rangeSpecs := make([]string, len(ranges))
+	for i, chunk := range ranges {
+		rangeSpecs[i] = fmt.Sprintf("%d-%d", chunk.Start, chunk.End)
+	}
+	req.Header.Set("Range", "bytes="+strings.Join(rangeSpecs, ","))
+
+	// Make request, retrying on status codes like 429 and 503 and then on the fallback URLs
+	resp, err := d.doWithFallback(req, func(r *http.Request) (*http.Response, error) {
+		return d.doWithStatusRetry(ctx, client, r)
+	}, func(statusCode int) bool {
+		return statusCode == http.StatusOK || statusCode == http.StatusPartialContent
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return &NetworkError{Op: "request", Host: req.URL.Host, Err: err}
+	}
+	defer resp.Body.Close()
+
+	totalSize := d.ServerHeaders.Filesize
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// Server ignored the Range header and is sending the whole file
+		return d.copyRangeAt(ctx, resp.Body, writer, 0, totalSize)
+
+	case http.StatusPartialContent:
+		mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if err != nil || mediaType != "multipart/byteranges" {
+			// Server merged the ranges into a single part
+			start, _, err := parseContentRange(resp.Header.Get("Content-Range"))
+			if err != nil {
+				return err
+			}
+			return d.copyRangeAt(ctx, resp.Body, writer, start, totalSize)
+		}
+
+		reader := multipart.NewReader(resp.Body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return &NetworkError{Op: "read", Host: d.requestHost(), Err: err}
+			}
+
+			start, _, err := parseContentRange(part.Header.Get("Content-Range"))
+			if err != nil {
+				part.Close()
+				return err
+			}
+
+			err = d.copyRangeAt(ctx, part, writer, start, totalSize)
+			part.Close()
+			if err != nil {
+				return err
+			}
+		}
+
+	default:
+		return &ServerError{StatusCode: resp.StatusCode, URL: d.requestURL()}
+	}
+}
+
+// copyRangeAt copies a response body to writer starting at offset with pause support.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - reader: Source reader (response body or multipart part)
+//   - writer: Destination writer
+//   - offset: Byte offset of the first byte in the destination
+//   - totalSize: Total expected download size for progress reporting
+//
+// Returns:
+//   - error: Error if reading or writing fails
+func (d *Downloader) copyRangeAt(ctx context.Context, reader io.Reader, writer io.WriterAt, offset int64, totalSize int64) error {
+	buffer := make([]byte, d.getBufferSize())
+
+	for {
+		// Check for pause
+		d.checkPauseState()
+
+		// Check for cancellation
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		n, err := reader.Read(buffer)
+		if n > 0 {
+			written, writeErr := writer.WriteAt(buffer[:n], offset)
+			if writeErr != nil {
+				return &DiskError{Op: "write", Path: d.incompletePath(), Err: writeErr}
+			}
+			offset += int64(written)
+
+			// Update progress
+			d.updateProgress(int64(written), totalSize)
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return &NetworkError{Op: "read", Host: d.requestHost(), Err: err}
+		}
+	}
+}
+
+// parseContentRange parses a Content-Range header value like "bytes 0-1023/4096".
+//
+// Parameters:
+//   - contentRange: Header value
+//
+// Returns:
+//   - int64: First byte of the range
+//   - int64: Last byte of the range
+//   - error: Error if the header is missing or malformed
+func parseContentRange(contentRange string) (int64, int64, error) {
+	var start, end int64
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d", &start, &end); err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range header %q: %v", contentRange, err)
+	}
+	return start, end, nil
+}
@@ -0,0 +1,102 @@
+package udm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// bufferAt is an in-memory io.WriterAt of a fixed size
+type bufferAt []byte
+
+func (b bufferAt) WriteAt(p []byte, off int64) (int, error) {
+	return copy(b[off:], p), nil
+}
+
+func TestDownloadMultiRangeFallsBackToMirror(t *testing.T) {
+	previous := diagnosticOutput
+	defer func() { diagnosticOutput = previous }()
+	diagnosticOutput = io.Discard
+
+	content := "0123456789"
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer primary.Close()
+
+	// The mirror ignores the Range header and sends the whole file
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, content)
+	}))
+	defer mirror.Close()
+
+	d := &Downloader{
+		Url:          primary.URL,
+		FallbackURLs: []string{mirror.URL},
+		PauseControl: NewPauseController(),
+		Progress:     &ProgressTracker{},
+	}
+	d.ServerHeaders.Filesize = int64(len(content))
+
+	ranges := []ChunkData{{Start: 0, End: 4}, {Start: 5, End: 9}}
+	buffer := make(bufferAt, len(content))
+	if err := d.downloadMultiRange(context.Background(), ranges, buffer); err != nil {
+		t.Fatalf("downloadMultiRange() error = %v", err)
+	}
+
+	if string(buffer) != content {
+		t.Errorf("downloaded %q, want %q", buffer, content)
+	}
+	if got := d.GetURL(); got != mirror.URL {
+		t.Errorf("GetURL() = %q, want the mirror %q", got, mirror.URL)
+	}
+}
+
+func TestDownloadMultiRangeRetriesStatus(t *testing.T) {
+	previousOutput := diagnosticOutput
+	previousSettings := UDMSettings
+	defer func() {
+		diagnosticOutput = previousOutput
+		UDMSettings = previousSettings
+	}()
+	diagnosticOutput = io.Discard
+	UDMSettings = &Settings{}
+
+	// The first request is rate limited without a delay, the retry gets the ranges merged into one part
+	content := "0123456789"
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Range", "bytes 0-9/10")
+		w.WriteHeader(http.StatusPartialContent)
+		io.WriteString(w, content)
+	}))
+	defer server.Close()
+
+	d := &Downloader{
+		Url:          server.URL,
+		PauseControl: NewPauseController(),
+		Progress:     &ProgressTracker{},
+	}
+	d.ServerHeaders.Filesize = int64(len(content))
+
+	ranges := []ChunkData{{Start: 0, End: 4}, {Start: 5, End: 9}}
+	buffer := make(bufferAt, len(content))
+	if err := d.downloadMultiRange(context.Background(), ranges, buffer); err != nil {
+		t.Fatalf("downloadMultiRange() error = %v", err)
+	}
+
+	if string(buffer) != content {
+		t.Errorf("downloaded %q, want %q", buffer, content)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("server received %d requests, want 2", got)
+	}
+}
@@ -2,15 +2,21 @@ package udm
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
-	"net"
 	"net/http"
 	"os"
-	"sync"
+	"path/filepath"
 	"sync/atomic"
 	"time"
 	"udl/udm/ufs"
+
+	"github.com/utsav-56/ulog"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // DownloadMultiStream performs a multi-threaded download with pause/resume/cancel functionality.
@@ -73,8 +79,9 @@ func (d *Downloader) DownloadMultiStream() {
 //   - error: Error if initialization fails
 func (d *Downloader) initializeMultiStreamDownload() error {
 	// Set initial status
-	d.Status = DOWNLOAD_IN_PROGRESS
+	d.setStatus(DOWNLOAD_IN_PROGRESS)
 	d.TimeStats.StartTime = time.Now()
+	d.streamedSHA256 = ""
 
 	// Initialize progress tracker if not exists
 	if d.Progress == nil {
@@ -91,7 +98,7 @@ func (d *Downloader) initializeMultiStreamDownload() error {
 
 	// Setup file paths
 	if err := d.setupDownloadPaths(); err != nil {
-		return fmt.Errorf("failed to setup download paths: %v", err)
+		return fmt.Errorf("failed to setup download paths: %w", err)
 	}
 
 	// Validate server supports ranges
@@ -104,9 +111,17 @@ func (d *Downloader) initializeMultiStreamDownload() error {
 		return fmt.Errorf("file size unknown - cannot divide into chunks")
 	}
 
+	// Claim the output name before creating chunk files, they are named after it
+	if err := d.claimOutputPath(); err != nil {
+		return &DiskError{Op: "create", Path: d.fileInfo.FullPath, Err: err}
+	}
+
+	d.logEvent(EVENT_STARTED, "multi-stream download of %s to %s", d.requestURL(), d.fileInfo.FullPath)
+	d.sendWebhook(WEBHOOK_EVENT_START)
+
 	// Call start callback
-	if d.Callbacks != nil && d.Callbacks.OnStart != nil {
-		d.Callbacks.OnStart(d)
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnStart != nil {
+		callbacks.OnStart(d)
 	}
 
 	return nil
@@ -118,37 +133,135 @@ func (d *Downloader) initializeMultiStreamDownload() error {
 //   - ctx: Context for cancellation
 //   - cancel: Cancel function for stopping download
 func (d *Downloader) executeMultiStreamDownload(ctx context.Context, cancel context.CancelFunc) {
-	// Determine optimal thread count
-	threadCount := d.getOptimalThreadCount()
+	var threadCount int // Number of chunks
+	var workers int     // Number of chunks downloaded at the same time
+	reuseChunks := !d.ServerHeaders.Changed && len(d.Chunks) > 0
+
+	if reuseChunks {
+		// File is unchanged since the previous run, keep the existing chunks so they can be resumed
+		threadCount = len(d.Chunks)
+		workers = threadCount
+	} else {
+		// Make sure all chunks will receive the same version of the file
+		if d.Prefs.VerifyURLConsistency {
+			if _, err := d.fingerprintURL(); err != nil {
+				d.handleDownloadError(fmt.Errorf("failed to verify URL consistency: %w", err))
+				return
+			}
+		}
+
+		// Determine optimal thread count
+		workers = d.getOptimalThreadCount()
+
+		// Divide file into chunks within the configured chunk size limits,
+		// keeping bytes downloaded before an elevation as the first chunk
+		chunkSizes := d.divideChunksFromOffset(d.constrainedChunkCount(workers))
+
+		// Initialize chunk data structures
+		if err := d.initializeChunks(chunkSizes); err != nil {
+			d.handleDownloadError(fmt.Errorf("failed to initialize chunks: %w", err))
+			return
+		}
 
-	// Divide file into chunks
-	chunkSizes := DivideChunks(d.ServerHeaders.Filesize, threadCount)
+		// Small files can be divided into fewer chunks than requested, large files into more
+		threadCount = len(chunkSizes)
+		workers = min(workers, threadCount)
+	}
 
-	// Initialize chunk data structures
-	if err := d.initializeChunks(chunkSizes); err != nil {
-		d.handleDownloadError(fmt.Errorf("failed to initialize chunks: %v", err))
+	// Fail early instead of filling up the disk halfway through or while merging
+	if err := d.checkDiskSpace(d.multiStreamSpaceRequired(reuseChunks)); err != nil {
+		d.handleDownloadError(err)
 		return
 	}
 
-	// Create chunk files
-	chunkFileNames := ufs.GenerateChunkFileNames(d.fileInfo.Name, threadCount, d.fileInfo.Dir)
-	if err := ufs.GenerateChunkFiles(chunkFileNames); err != nil {
-		d.handleDownloadError(fmt.Errorf("failed to create chunk files: %v", err))
+	// Fetch all chunks over a single connection if requested
+	if d.Prefs.UseMultipartRange {
+		d.Progress.UpdateProgress(0, d.ServerHeaders.Filesize)
+		d.executeMultiRangeDownload(ctx)
 		return
 	}
 
+	// Create chunk files
+	var chunkFileNames []string
+	if reuseChunks {
+		// Only create missing chunk files so partial chunks can be resumed
+		chunkFileNames = ufs.GenerateChunkFileNames(d.fileInfo.Name, threadCount, d.fileInfo.Dir)
+		for _, chunkFileName := range chunkFileNames {
+			if ufs.FileExists(chunkFileName) {
+				continue
+			}
+			if err := ufs.CreateFile(chunkFileName); err != nil {
+				d.handleDownloadError(&DiskError{Op: "create", Path: chunkFileName, Err: err})
+				return
+			}
+		}
+	} else {
+		fileNames, actualCount, err := d.tryCreateChunksWithFallback(threadCount)
+		if err != nil {
+			// Not even two chunk files could be created, download into the output file directly
+			logf("Falling back to single-stream download: %v\n", err)
+			d.Chunks = nil
+			d.restoreElevatedChunk()
+			d.executeSingleStreamDownload(ctx, cancel)
+			return
+		}
+
+		// Divide the file again if fewer chunk files could be created
+		if actualCount != threadCount {
+			chunkSizes := d.divideChunksFromOffset(actualCount)
+			if err := d.initializeChunks(chunkSizes); err != nil {
+				d.handleDownloadError(fmt.Errorf("failed to initialize chunks: %w", err))
+				return
+			}
+			threadCount = len(chunkSizes)
+			workers = min(workers, threadCount)
+		}
+		chunkFileNames = fileNames
+
+		// Continue an elevated single-stream download from where it stopped
+		if err := d.placeElevatedChunk(chunkFileNames[0]); err != nil {
+			ufs.CleanupChunkFiles(chunkFileNames)
+			d.handleDownloadError(err)
+			return
+		}
+	}
+
+	// Load or reset the chunk references used to validate resumed chunks
+	if d.Prefs.ValidateResumedChunks {
+		if reuseChunks {
+			d.loadResumeState()
+		} else {
+			d.resetResumeState()
+		}
+	}
+
 	// Initialize progress tracking for total size
 	d.Progress.UpdateProgress(0, d.ServerHeaders.Filesize)
 
+	// Periodically verify the connection to the server during long downloads
+	pingCtx, stopPing := context.WithCancel(ctx)
+	defer stopPing()
+	go d.keepAlivePing(pingCtx)
+
+	// Keep expiring URLs valid for the whole download
+	go d.refreshURLPeriodically(pingCtx)
+
 	// Start concurrent chunk downloads
-	if err := d.downloadChunksConcurrently(ctx, chunkFileNames); err != nil {
+	// Share one client between all chunks so connections are reused
+	client := d.buildSharedHTTPClient(workers)
+	defer client.CloseIdleConnections()
+
+	err := d.downloadChunksConcurrently(ctx, client, chunkFileNames, workers)
+
+	// Include the chunks added by splitting slow chunks, in the order of their bytes in the file
+	chunkFileNames = d.chunkFilesInFileOrder()
+
+	if err != nil {
 		// Cleanup chunk files on failure
 		ufs.CleanupChunkFiles(chunkFileNames)
+		d.removeResumeState()
 		if ctx.Err() == context.Canceled {
-			d.Status = DOWNLOAD_STOPPED
-			if d.Callbacks != nil && d.Callbacks.OnStop != nil {
-				d.Callbacks.OnStop(d)
-			}
+			d.handleDownloadStop(ctx.Err())
 		} else {
 			d.handleDownloadError(err)
 		}
@@ -157,19 +270,48 @@ func (d *Downloader) executeMultiStreamDownload(ctx context.Context, cancel cont
 
 	// Merge chunks into final file
 	if err := d.mergeChunksToFinalFile(chunkFileNames); err != nil {
-		d.handleDownloadError(fmt.Errorf("failed to merge chunks: %v", err))
+		var diskErr *DiskError
+		if !errors.As(err, &diskErr) {
+			err = &DiskError{Op: "merge", Path: d.incompletePath(), Err: err}
+		}
+		d.handleDownloadError(err)
 		return
 	}
+	d.removeResumeState()
 
 	// Download completed successfully
 	d.finalizeDownload()
 }
 
+// constrainedChunkCount adjusts the number of chunks to the chunk size limits of the settings.
+//
+// Parameters:
+//   - threadCount: The preferred number of chunks
+//
+// Returns:
+//   - int: Number of chunks to divide the file into
+func (d *Downloader) constrainedChunkCount(threadCount int) int {
+	minChunkBytes := int64(DEFAULT_MIN_CHUNK_SIZE_BYTES)
+	var maxChunkBytes int64
+	if UDMSettings != nil {
+		minChunkBytes = UDMSettings.GetMinChunkSizeBytes()
+		maxChunkBytes = UDMSettings.MaxChunkSizeBytes
+	}
+
+	_, chunkCount := DivideChunksWithConstraints(d.ServerHeaders.Filesize, threadCount, minChunkBytes, maxChunkBytes)
+	return max(chunkCount, 1)
+}
+
 // getOptimalThreadCount determines the optimal number of threads for download.
 //
 // Returns:
-//   - int: Optimal thread count based on file size and user preferences
+//   - int: Optimal thread count based on user preferences, the benchmark result or the file size
 func (d *Downloader) getOptimalThreadCount() int {
+	// Use the benchmark result when no thread count was chosen for this download
+	if d.Prefs.threadCount == 0 && UDMSettings != nil && UDMSettings.BenchmarkedOptimalThreads > 0 {
+		return UDMSettings.BenchmarkedOptimalThreads
+	}
+
 	userThreadCount := d.getThreadCount()
 	if userThreadCount > 0 {
 		return userThreadCount
@@ -211,6 +353,9 @@ func (d *Downloader) initializeChunks(chunkSizes []int64) error {
 		currentOffset += size
 	}
 
+	// Trace each chunk as a child of the download span
+	d.startChunkSpans()
+
 	// Initialize chunk manager
 	d.ChunkManager = &ChunkManager{
 		Chunks:         d.Chunks,
@@ -222,72 +367,211 @@ func (d *Downloader) initializeChunks(chunkSizes []int64) error {
 	return nil
 }
 
+// tryCreateChunksWithFallback creates the chunk files, halving the chunk count each time
+// creation fails, e.g. because of a per-directory file limit: 16 → 8 → 4 → 2.
+//
+// Parameters:
+//   - threadCount: The preferred number of chunks
+//
+// Returns:
+//   - []string: Paths of the created chunk files
+//   - int: Number of chunk files actually created
+//   - error: Error if not even two chunk files could be created, single-stream should be used instead
+func (d *Downloader) tryCreateChunksWithFallback(threadCount int) ([]string, int, error) {
+	var lastErr error
+
+	for count := threadCount; count >= 2; count /= 2 {
+		chunkFileNames := ufs.GenerateChunkFileNames(d.fileInfo.Name, count, d.fileInfo.Dir)
+
+		err := ufs.GenerateChunkFiles(chunkFileNames)
+		if err == nil {
+			return chunkFileNames, count, nil
+		}
+
+		// Remove the files that were created before trying with fewer chunks
+		ufs.CleanupChunkFiles(chunkFileNames)
+		lastErr = err
+		logf("Failed to create %d chunk files, retrying with fewer chunks: %v\n", count, err)
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("chunk count %d is too low for a multi-stream download", threadCount)
+	}
+	return nil, 1, fmt.Errorf("failed to create chunk files: %w", lastErr)
+}
+
 // downloadChunksConcurrently starts concurrent workers to download all chunks.
 //
 // Parameters:
 //   - ctx: Context for cancellation
+//   - client: HTTP client shared by all chunk downloads
 //   - chunkFileNames: Array of chunk file paths
+//   - threadCount: Maximum number of chunks downloaded at the same time
 //
 // Returns:
 //   - error: Error if download fails
-func (d *Downloader) downloadChunksConcurrently(ctx context.Context, chunkFileNames []string) error {
-	var wg sync.WaitGroup
-	errorChan := make(chan error, len(d.Chunks))
-
+func (d *Downloader) downloadChunksConcurrently(ctx context.Context, client *http.Client, chunkFileNames []string, threadCount int) error {
 	// Track completed bytes atomically
 	var totalCompletedBytes int64
 
-	// Start workers for each chunk
-	for i, chunk := range d.Chunks {
-		wg.Add(1)
-		go func(chunkIndex int, chunkData ChunkData, chunkFile string) {
-			defer wg.Done()
+	// Start with fresh connections for this download
+	d.resetConnections()
+	defer d.resetConnections()
 
-			// Check for existing partial chunk
-			resumeOffset, err := d.detectChunkResumeOffset(chunkFile, chunkData.Size)
-			if err != nil {
-				errorChan <- fmt.Errorf("chunk %d resume detection failed: %v", chunkIndex, err)
-				return
+	// Chunks reused from a previous run don't have spans yet
+	if d.downloadSpan != nil && len(d.chunkSpans) != len(d.Chunks) {
+		d.startChunkSpans()
+	}
+
+	// Track the range and progress of each chunk so chunks can be split
+	d.prepareChunkManager()
+	defer d.syncChunks()
+
+	// Note if the previous run crashed while writing the chunk files
+	endChunkWrites := d.beginChunkWrites()
+	defer endChunkWrites()
+
+	// Monitor progress while the chunks are downloading
+	go d.monitorMultiStreamProgress(ctx, &totalCompletedBytes)
+
+	// Avoid parallel range requests if the server doesn't tolerate them
+	if d.Prefs.SequentialChunks {
+		threadCount = 1
+	}
+
+	// Download the chunks in the configured order, at most threadCount at a time
+	order := d.chunkLaunchOrder()
+	var pending atomic.Int64
+	pending.Store(int64(len(order)))
+
+	// The first failed chunk cancels the others, the download fails anyway
+	pool := NewChunkWorkerPoolWithContext(ctx, threadCount)
+	poolCtx := pool.Context()
+	for _, i := range order {
+		chunkIndex, chunkFile := i, chunkFileNames[i]
+		pool.Submit(ChunkTask{Chunk: d.ChunkManager.chunk(i), URL: d.requestURL(), Run: func() error {
+			pending.Add(-1)
+			if err := d.downloadChunk(poolCtx, client, chunkIndex, chunkFile, &totalCompletedBytes); err != nil {
+				return err
 			}
 
-			// Skip if chunk is already complete
-			if resumeOffset >= chunkData.Size {
-				atomic.AddInt64(&totalCompletedBytes, chunkData.Size)
-				d.Chunks[chunkIndex].IsCompleted = true
-				if d.Callbacks != nil && d.Callbacks.OnChunkFinish != nil {
-					d.Callbacks.OnChunkFinish(d, chunkIndex, chunkData.Start, chunkData.End, chunkData.Size)
-				}
-				return
+			// Help the slowest chunks once no chunk is waiting for a worker anymore
+			if d.Prefs.DisableWorkStealing || pending.Load() > 0 {
+				return nil
 			}
+			return d.stealChunks(poolCtx, client, &totalCompletedBytes)
+		}})
+	}
 
-			// Download chunk
-			if err := d.downloadSingleChunk(ctx, chunkIndex, chunkData, chunkFile, resumeOffset, &totalCompletedBytes); err != nil {
-				errorChan <- fmt.Errorf("chunk %d download failed: %v", chunkIndex, err)
-				return
+	// Wait for all chunks to complete
+	return pool.Wait()
+}
+
+// downloadChunk downloads a chunk, resuming a partial chunk file. Failed attempts are retried
+// following the retry policy, a chunk whose connection was reset by the health check ping is
+// reconnected right away without counting as a retry.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - client: HTTP client shared by all chunk downloads
+//   - chunkIndex: Index of the chunk
+//   - chunkFile: Path to chunk file
+//   - totalCompletedBytes: Pointer to atomic counter for total progress
+//
+// Returns:
+//   - error: Error if the chunk failed after all retries
+func (d *Downloader) downloadChunk(ctx context.Context, client *http.Client, chunkIndex int, chunkFile string, totalCompletedBytes *int64) error {
+	chunkData := d.ChunkManager.chunk(chunkIndex)
+
+	// Check for existing partial chunk
+	resumeOffset, err := d.detectChunkResumeOffset(chunkFile, chunkData.Size, d.uncleanShutdown)
+	if err != nil {
+		return fmt.Errorf("chunk %d resume detection failed: %w", chunkIndex, err)
+	}
+
+	// Restart corrupt chunks from the beginning instead of resuming them
+	if resumeOffset > 0 && d.Prefs.ValidateResumedChunks {
+		if err := d.validateResumedChunk(chunkIndex, chunkFile); err != nil {
+			ulog.Error(fmt.Sprintf("Restarting chunk %d: %v", chunkIndex, err), "UDM_CHUNK_VALIDATION_ERROR")
+			if err := os.Truncate(chunkFile, 0); err != nil {
+				return &DiskError{Op: "truncate", Path: chunkFile, Err: err}
 			}
+			resumeOffset = 0
+		}
+	}
 
-		}(i, chunk, chunkFileNames[i])
+	// Skip if chunk is already complete
+	if resumeOffset >= chunkData.Size {
+		atomic.AddInt64(totalCompletedBytes, chunkData.Size)
+		d.ChunkManager.stopChunk(chunkIndex, true)
+		d.logEvent(EVENT_CHUNK_COMPLETED, "chunk %d already complete", chunkIndex)
+		if callbacks := d.callbacks(); callbacks != nil && callbacks.OnChunkFinish != nil {
+			callbacks.OnChunkFinish(d, chunkIndex, chunkData.Start, chunkData.End, chunkData.Size)
+		}
+		d.addChunkEvent(chunkIndex, "chunk.end", attribute.Bool("chunk.resumed", true))
+		d.endChunkSpan(chunkIndex, nil)
+		return nil
 	}
 
-	// Monitor progress and wait for completion
-	go d.monitorMultiStreamProgress(ctx, &totalCompletedBytes)
+	// Download chunk, failed attempts are retried following the retry policy
+	maxRetries := d.getRetryCount()
+	attempt := 0
+	for {
+		attemptCtx := d.connectionContext(ctx)
+		d.addChunkEvent(chunkIndex, "chunk.start", attribute.Int("chunk.attempt", attempt), attribute.Int64("chunk.resume_offset", resumeOffset))
+
+		d.ChunkManager.startChunk(chunkIndex, resumeOffset)
+		err := d.downloadSingleChunk(attemptCtx, client, chunkIndex, chunkData, chunkFile, resumeOffset, totalCompletedBytes)
+		d.recordChunkReference(chunkIndex, chunkFile)
+		if err == nil {
+			d.ChunkManager.stopChunk(chunkIndex, true)
+			d.addChunkEvent(chunkIndex, "chunk.end")
+			d.endChunkSpan(chunkIndex, nil)
+			return nil
+		}
 
-	// Wait for all chunks to complete
-	wg.Wait()
-	close(errorChan)
+		if ctx.Err() == nil && attemptCtx.Err() != nil {
+			// The health check reset the connection, reconnect right away without using up a retry
+			// and don't reuse pooled connections to a server that stopped responding
+			client.CloseIdleConnections()
+			d.addChunkEvent(chunkIndex, "chunk.reconnect")
+		} else {
+			if ctx.Err() != nil || attempt >= maxRetries || !d.isRetryableError(err) {
+				d.ChunkManager.stopChunk(chunkIndex, false)
+				d.endChunkSpan(chunkIndex, err)
+				return fmt.Errorf("chunk %d download failed: %w", chunkIndex, err)
+			}
+			d.addChunkEvent(chunkIndex, "chunk.retry", attribute.Int("chunk.attempt", attempt), attribute.String("chunk.error", err.Error()))
+			attempt++
+			d.recordRetry(chunkIndex, attempt, err)
+
+			// Wait before retrying, unless the host is rate limited, the next attempt then waits in waitForRateLimit
+			var serverErr *ServerError
+			if !errors.As(err, &serverErr) || serverErr.StatusCode != http.StatusTooManyRequests {
+				select {
+				case <-time.After(currentRetryPolicy().NextDelay(attempt)):
+				case <-ctx.Done():
+					d.ChunkManager.stopChunk(chunkIndex, false)
+					return fmt.Errorf("chunk %d download failed: %w", chunkIndex, ctx.Err())
+				}
+			}
+		}
 
-	// Check for errors
-	if len(errorChan) > 0 {
-		return <-errorChan
+		// Continue from the bytes already written to the chunk file, the chunk may have been split meanwhile
+		chunkData = d.ChunkManager.chunk(chunkIndex)
+		resumeOffset, err = d.detectChunkResumeOffset(chunkFile, chunkData.Size, false)
+		if err != nil {
+			d.ChunkManager.stopChunk(chunkIndex, false)
+			return fmt.Errorf("chunk %d resume detection failed: %w", chunkIndex, err)
+		}
 	}
-
-	return nil
 }
 
 // downloadSingleChunk downloads a single chunk with progress tracking and pause support.
 //
 // Parameters:
 //   - ctx: Context for cancellation
+//   - client: HTTP client shared by all chunk downloads
 //   - chunkIndex: Index of the chunk
 //   - chunkData: Chunk metadata
 //   - chunkFile: Path to chunk file
@@ -296,26 +580,16 @@ func (d *Downloader) downloadChunksConcurrently(ctx context.Context, chunkFileNa
 //
 // Returns:
 //   - error: Error if chunk download fails
-func (d *Downloader) downloadSingleChunk(ctx context.Context, chunkIndex int, chunkData ChunkData, chunkFile string, resumeOffset int64, totalCompletedBytes *int64) error {
+func (d *Downloader) downloadSingleChunk(ctx context.Context, client *http.Client, chunkIndex int, chunkData ChunkData, chunkFile string, resumeOffset int64, totalCompletedBytes *int64) error {
+	// Track in-flight chunk downloads
+	d.activeChunkCount.Add(1)
+	defer d.activeChunkCount.Add(-1)
+
+	d.logEvent(EVENT_CHUNK_STARTED, "chunk %d from byte %d to %d", chunkIndex, chunkData.Start+resumeOffset, chunkData.End)
+
 	// Call chunk start callback
-	if d.Callbacks != nil && d.Callbacks.OnChunkStart != nil {
-		d.Callbacks.OnChunkStart(d, chunkIndex, chunkData.Start, chunkData.End)
-	}
-
-	// Create HTTP client with appropriate timeouts
-	client := &http.Client{
-		Transport: &http.Transport{
-			// Timeout for establishing a connection
-			DialContext: (&net.Dialer{
-				Timeout: 15 * time.Second,
-			}).DialContext,
-			// Timeout for waiting for the server's response headers
-			ResponseHeaderTimeout: 15 * time.Second,
-			// Timeout for waiting for a TLS handshake
-			TLSHandshakeTimeout: 10 * time.Second,
-		},
-		// DO NOT SET THE TOP-LEVEL TIMEOUT FIELD FOR DOWNLOADS
-		// Timeout: 30 * time.Second,
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnChunkStart != nil {
+		callbacks.OnChunkStart(d, chunkIndex, chunkData.Start, chunkData.End)
 	}
 
 	// Calculate actual range to download
@@ -323,7 +597,7 @@ func (d *Downloader) downloadSingleChunk(ctx context.Context, chunkIndex int, ch
 	endByte := chunkData.End
 
 	// Create request with range header
-	req, err := http.NewRequestWithContext(ctx, "GET", d.Url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", d.requestURL(), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %v", err)
 	}
@@ -340,55 +614,130 @@ func (d *Downloader) downloadSingleChunk(ctx context.Context, chunkIndex int, ch
 	// Set range header for this chunk
 	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", startByte, endByte))
 
-	// Make request
-	resp, err := client.Do(req)
+	// Wait while the host is rate limiting this or another chunk
+	if err := waitForRateLimit(ctx, req.URL.Hostname()); err != nil {
+		return err
+	}
+
+	// Make request, switching to the fallback URLs if it fails
+	resp, err := d.doWithFallback(req, client.Do, func(statusCode int) bool {
+		return statusCode == http.StatusPartialContent
+	})
 	if err != nil {
-		return fmt.Errorf("failed to make request: %v", err)
+		return &NetworkError{Op: "request", Host: req.URL.Host, Err: err}
 	}
 	defer resp.Body.Close()
 
 	// Check response status
 	if resp.StatusCode != http.StatusPartialContent {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		// Make the other chunks back off as well instead of each running into the rate limit
+		if resp.StatusCode == http.StatusTooManyRequests {
+			recordRateLimit(resp, 1)
+		}
+		return &ServerError{StatusCode: resp.StatusCode, URL: d.requestURL()}
 	}
 
 	// Open chunk file for writing
-	file, err := d.openChunkFile(chunkFile, resumeOffset)
+	file, err := d.openChunkFile(chunkFile, resumeOffset, chunkData.Size)
 	if err != nil {
-		return fmt.Errorf("failed to open chunk file: %v", err)
+		return &DiskError{Op: "open", Path: chunkFile, Err: err}
 	}
 	defer file.Close()
 
+	// Record the reference checksum as soon as the first megabyte is on disk
+	var writer io.Writer = file
+	if d.resumeState != nil && resumeOffset < resumeReferenceSize {
+		writer = &referenceWriter{File: file, offset: resumeOffset, onReference: func() {
+			d.recordChunkReference(chunkIndex, chunkFile)
+		}}
+	}
+
+	// Keep the hash of chunks downloaded in one pass for diagnosing a checksum mismatch
+	var hasher *hashWriter
+	if resumeOffset == 0 && d.needsStreamingHash() {
+		hasher = &hashWriter{w: writer, hash: sha256.New()}
+		writer = hasher
+	}
+
 	// Download chunk with progress tracking
-	bytesWritten, err := d.downloadChunkWithProgress(ctx, chunkIndex, resp.Body, file, chunkData.Size-resumeOffset, totalCompletedBytes)
+	// Detect stalled connections, the retry loop continues from the current offset
+	body := d.newDeadlineReader(resp.Body)
+	defer body.Close()
+
+	bytesWritten, err := d.downloadChunkWithProgress(ctx, chunkIndex, body, writer, chunkData.Size-resumeOffset, totalCompletedBytes)
 	if err != nil {
-		if d.Callbacks != nil && d.Callbacks.OnChunkError != nil {
-			d.Callbacks.OnChunkError(d, chunkIndex, chunkData.Start, chunkData.End, err)
+		d.logEvent(EVENT_CHUNK_FAILED, "chunk %d after %d bytes: %v", chunkIndex, bytesWritten, err)
+		if callbacks := d.callbacks(); callbacks != nil && callbacks.OnChunkError != nil {
+			callbacks.OnChunkError(d, chunkIndex, chunkData.Start, chunkData.End, err)
 		}
 		return err
 	}
 
-	// Mark chunk as completed
-	d.Chunks[chunkIndex].IsCompleted = true
+	if hasher != nil {
+		d.ChunkManager.setChunkHash(chunkIndex, hasher.sum())
+	}
+	d.logEvent(EVENT_CHUNK_COMPLETED, "chunk %d, %d bytes", chunkIndex, bytesWritten)
 
-	// Call chunk finish callback
-	if d.Callbacks != nil && d.Callbacks.OnChunkFinish != nil {
-		d.Callbacks.OnChunkFinish(d, chunkIndex, chunkData.Start, chunkData.End, bytesWritten)
+	// Call chunk finish callback, the chunk may have been split while downloading
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnChunkFinish != nil {
+		chunkData = d.ChunkManager.chunk(chunkIndex)
+		callbacks.OnChunkFinish(d, chunkIndex, chunkData.Start, chunkData.End, bytesWritten)
 	}
 
 	return nil
 }
 
+// chunkTailSampleSize is the number of bytes at the end of a partial chunk checked for crash corruption
+const chunkTailSampleSize = 4096
+
+// WRITING_MARKER_EXTENSION is the extension of the marker file that exists while chunk files are
+// written. Finding it before the chunks are downloaded means the previous run did not end cleanly,
+// e.g. the system crashed, so the tails of partial chunk files may never have reached the disk.
+const WRITING_MARKER_EXTENSION = ".udmwriting"
+
+// writingMarkerPath returns the path of the marker file of this download.
+//
+// Returns:
+//   - string: Path following the "{name}.udmwriting" convention in the output directory
+func (d *Downloader) writingMarkerPath() string {
+	baseName := ufs.FileNameWithoutExtension(d.fileInfo.Name)
+	return filepath.Join(d.fileInfo.Dir, baseName+WRITING_MARKER_EXTENSION)
+}
+
+// beginChunkWrites sets uncleanShutdown if the marker file of a previous run still exists and
+// creates the marker for this run. Creating it is best effort, without it a crash goes unnoticed.
+//
+// Returns:
+//   - func(): Removes the marker again once no chunk file is written anymore
+func (d *Downloader) beginChunkWrites() func() {
+	path := d.writingMarkerPath()
+	d.uncleanShutdown = ufs.FileExists(path)
+
+	if err := os.WriteFile(path, nil, d.getFileMode()); err != nil {
+		logf("Failed to create marker file %s: %v\n", path, err)
+	}
+
+	return func() {
+		os.Remove(path)
+		d.uncleanShutdown = false
+	}
+}
+
 // detectChunkResumeOffset checks if there's a partial chunk and returns the resume offset.
+// Chunk files larger than the chunk are deleted and are restarted from the beginning.
+// After an unclean shutdown partial chunks ending in zeros are restarted as well, the data
+// may not have reached the disk. Files can legitimately end in zeros (disk images, padding),
+// so this is only checked when asked for.
 //
 // Parameters:
 //   - chunkFile: Path to the chunk file
 //   - expectedSize: Expected size of the complete chunk
+//   - checkTail: Whether to restart partial chunks ending in zeros, see beginChunkWrites
 //
 // Returns:
 //   - int64: Byte offset to resume from (0 if starting fresh)
 //   - error: Error if offset detection fails
-func (d *Downloader) detectChunkResumeOffset(chunkFile string, expectedSize int64) (int64, error) {
+func (d *Downloader) detectChunkResumeOffset(chunkFile string, expectedSize int64, checkTail bool) (int64, error) {
 	if !ufs.FileExists(chunkFile) {
 		return 0, nil
 	}
@@ -399,10 +748,29 @@ func (d *Downloader) detectChunkResumeOffset(chunkFile string, expectedSize int6
 	}
 
 	currentSize := fileInfo.Size()
-	if currentSize >= expectedSize {
+	if currentSize > expectedSize {
+		// The file doesn't belong to this chunk, e.g. left over from a download with other chunk sizes
+		logf("Restarting chunk file %s: %d bytes exceed the chunk size of %d bytes\n", chunkFile, currentSize, expectedSize)
+		if err := os.Remove(chunkFile); err != nil {
+			return 0, &DiskError{Op: "remove", Path: chunkFile, Err: err}
+		}
+		return 0, nil
+	}
+	if currentSize == expectedSize {
 		return expectedSize, nil // Chunk is complete
 	}
 
+	// A crash while writing can leave zeros instead of the data at the end of the file
+	if checkTail && currentSize > 0 {
+		if valid, err := ufs.VerifyChunkTail(chunkFile, chunkTailSampleSize); err == nil && !valid {
+			logf("Restarting chunk file %s: the last bytes were not written\n", chunkFile)
+			if err := os.Truncate(chunkFile, 0); err != nil {
+				return 0, &DiskError{Op: "truncate", Path: chunkFile, Err: err}
+			}
+			return 0, nil
+		}
+	}
+
 	return currentSize, nil // Resume from current position
 }
 
@@ -411,18 +779,25 @@ func (d *Downloader) detectChunkResumeOffset(chunkFile string, expectedSize int6
 // Parameters:
 //   - chunkFile: Path to the chunk file
 //   - resumeOffset: Byte offset to resume from
+//   - size: Size of the chunk, used to reserve disk space for a new chunk file
 //
 // Returns:
 //   - *os.File: File handle for writing
 //   - error: Error if file opening fails
-func (d *Downloader) openChunkFile(chunkFile string, resumeOffset int64) (*os.File, error) {
+func (d *Downloader) openChunkFile(chunkFile string, resumeOffset int64, size int64) (*os.File, error) {
 	if resumeOffset > 0 {
 		// Open for appending
 		return os.OpenFile(chunkFile, os.O_WRONLY|os.O_APPEND, 0644)
-	} else {
-		// Create new file
-		return os.Create(chunkFile)
 	}
+
+	// Create new file
+	file, err := os.Create(chunkFile)
+	if err != nil {
+		return nil, err
+	}
+
+	d.preallocate(file, size)
+	return file, nil
 }
 
 // downloadChunkWithProgress downloads chunk data with pause support and progress tracking.
@@ -439,7 +814,7 @@ func (d *Downloader) openChunkFile(chunkFile string, resumeOffset int64) (*os.Fi
 //   - int64: Number of bytes actually written
 //   - error: Error if download fails
 func (d *Downloader) downloadChunkWithProgress(ctx context.Context, chunkIndex int, reader io.Reader, writer io.Writer, expectedBytes int64, totalCompletedBytes *int64) (int64, error) {
-	buffer := make([]byte, 32*1024) // 32KB buffer
+	buffer := make([]byte, d.getBufferSize())
 	var totalWritten int64
 
 	for totalWritten < expectedBytes {
@@ -455,27 +830,49 @@ func (d *Downloader) downloadChunkWithProgress(ctx context.Context, chunkIndex i
 
 		// Read data
 		n, err := reader.Read(buffer)
+		if n > 0 && d.ChunkManager != nil {
+			// Stop at the end of the chunk if its second half was given to another worker
+			if claimed := int(d.ChunkManager.claimBytes(chunkIndex, int64(n))); claimed < n {
+				n = claimed
+				expectedBytes = totalWritten + int64(n)
+				err = io.EOF
+			}
+		}
 		if n > 0 {
 			// Write data
 			written, writeErr := writer.Write(buffer[:n])
 			if writeErr != nil {
-				return totalWritten, fmt.Errorf("failed to write chunk data: %v", writeErr)
+				chunkPath := fmt.Sprintf("chunk %d", chunkIndex)
+				if file, ok := writer.(interface{ Name() string }); ok {
+					chunkPath = file.Name()
+				}
+				return totalWritten, &DiskError{Op: "write", Path: chunkPath, Err: writeErr}
 			}
 
 			totalWritten += int64(written)
 
 			// Update total progress atomically
 			atomic.AddInt64(totalCompletedBytes, int64(written))
+
+			// Stay under the speed limit shared with the other chunks
+			if err := d.throttle(ctx, written); err != nil {
+				return totalWritten, err
+			}
 		}
 
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return totalWritten, fmt.Errorf("failed to read chunk data: %v", err)
+			return totalWritten, &NetworkError{Op: "read", Host: d.requestHost(), Err: err}
 		}
 	}
 
+	// The connection may be closed before the whole chunk was sent, retried from the current offset
+	if totalWritten < expectedBytes {
+		return totalWritten, &IncompleteDownloadError{Expected: expectedBytes, Got: totalWritten}
+	}
+
 	return totalWritten, nil
 }
 
@@ -488,7 +885,6 @@ func (d *Downloader) monitorMultiStreamProgress(ctx context.Context, totalComple
 	ticker := time.NewTicker(500 * time.Millisecond) // Update every 500ms
 	defer ticker.Stop()
 
-	var lastReported int64
 	lastReportTime := time.Now()
 
 	for {
@@ -499,59 +895,92 @@ func (d *Downloader) monitorMultiStreamProgress(ctx context.Context, totalComple
 			current := atomic.LoadInt64(totalCompletedBytes)
 			now := time.Now()
 
-			// Calculate speed
+			// Update all values under one lock so readers never see bytes and percentage out of sync
 			elapsed := now.Sub(lastReportTime).Seconds()
-			if elapsed >= 1.0 { // Update speed every second
-				bytesDiff := current - lastReported
-				speed := float64(bytesDiff) / elapsed
-
-				// Update progress tracker
-				d.Progress.mu.Lock()
-				d.Progress.BytesCompleted = current
-				d.Progress.SpeedBps = speed
+			report := elapsed >= 1.0 // Update speed every second
+
+			d.Progress.mu.Lock()
+			d.Progress.BytesCompleted = current
+			d.Progress.recordSpeedSample(now)
+			if report {
+				d.Progress.updateSpeed(now, d.ServerHeaders.Filesize)
 				d.Progress.LastReported = now
 				if d.ServerHeaders.Filesize > 0 {
-					d.Progress.Percentage = float64(current) / float64(d.ServerHeaders.Filesize) * 100
+					d.Progress.Percentage = min(100.0, float64(current)/float64(d.ServerHeaders.Filesize)*100)
 				}
-				d.Progress.mu.Unlock()
+				d.Progress.addHistorySample(now)
+			}
+			d.Progress.mu.Unlock()
 
+			if report {
 				// Call progress callback
-				if d.Callbacks != nil && d.Callbacks.OnProgress != nil {
-					d.Callbacks.OnProgress(d)
-				}
+				d.reportProgress()
 
-				lastReported = current
 				lastReportTime = now
 			}
 		}
 	}
 }
 
+// MAX_MERGE_PARALLELISM is the number of chunk files copied into the output file at the same time
+const MAX_MERGE_PARALLELISM = 4
+
 // mergeChunksToFinalFile merges all chunk files into the final output file.
+// The chunks are copied in parallel unless the merged bytes have to be read in order,
+// for the streaming hash or the OnAssembleProgress callback.
 //
 // Parameters:
-//   - chunkFileNames: Array of chunk file paths in order
+//   - chunkFileNames: Array of chunk file paths in the order of chunkFilesInFileOrder
 //
 // Returns:
 //   - error: Error if merging fails
 func (d *Downloader) mergeChunksToFinalFile(chunkFileNames []string) error {
+	d.logEvent(EVENT_ASSEMBLE_STARTED, "merging %d chunk files", len(chunkFileNames))
+
 	// Call assemble start callback
-	if d.Callbacks != nil && d.Callbacks.OnAssembleStart != nil {
-		d.Callbacks.OnAssembleStart(d)
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnAssembleStart != nil {
+		callbacks.OnAssembleStart(d)
+	}
+
+	// Report merge progress to the caller, if requested
+	var progressFn func(merged, total int64)
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnAssembleProgress != nil {
+		progressFn = func(merged, total int64) {
+			callbacks.OnAssembleProgress(d, merged, total)
+		}
+	}
+
+	// Hash the file while merging instead of reading it again to verify it
+	var fileHash hash.Hash
+	if d.needsStreamingHash() {
+		fileHash = sha256.New()
 	}
 
-	// Use the UFS merge function
-	err := ufs.MergeChunkFiles(chunkFileNames, d.fileInfo.FullPath)
+	// Use the UFS merge functions, the merged file is moved into place by finalizeDownload
+	var err error
+	offsets := d.chunkOffsetsInFileOrder()
+	if fileHash == nil && progressFn == nil && len(offsets) == len(chunkFileNames) && len(chunkFileNames) > 1 {
+		parallelism := min(len(chunkFileNames), MAX_MERGE_PARALLELISM)
+		err = ufs.MergeChunkFilesParallel(chunkFileNames, offsets, d.incompletePath(), parallelism)
+	} else {
+		err = ufs.MergeChunkFilesWithHash(chunkFileNames, d.incompletePath(), progressFn, fileHash)
+	}
 	if err != nil {
-		if d.Callbacks != nil && d.Callbacks.OnAssembleError != nil {
-			d.Callbacks.OnAssembleError(d, err)
+		d.logEvent(EVENT_ERROR, "merging chunk files: %v", err)
+		if callbacks := d.callbacks(); callbacks != nil && callbacks.OnAssembleError != nil {
+			callbacks.OnAssembleError(d, err)
 		}
 		return err
 	}
 
+	if fileHash != nil {
+		d.streamedSHA256 = hex.EncodeToString(fileHash.Sum(nil))
+	}
+	d.logEvent(EVENT_ASSEMBLE_DONE, "merged into %s", d.incompletePath())
+
 	// Call assemble finish callback
-	if d.Callbacks != nil && d.Callbacks.OnAssembleFinish != nil {
-		d.Callbacks.OnAssembleFinish(d)
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnAssembleFinish != nil {
+		callbacks.OnAssembleFinish(d)
 	}
 
 	return nil
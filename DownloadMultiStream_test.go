@@ -0,0 +1,57 @@
+package udm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectChunkResumeOffsetZeroTail(t *testing.T) {
+	tests := []struct {
+		name      string
+		crashed   bool
+		wantStart int64
+	}{
+		{name: "clean shutdown keeps data ending in zeros", crashed: false, wantStart: 8192},
+		{name: "crash restarts a chunk ending in zeros", crashed: true, wantStart: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			d := &Downloader{}
+			d.fileInfo.Dir = dir
+			d.fileInfo.Name = "disk.img"
+
+			// A partial chunk of a disk image legitimately ends in zeros
+			chunkFile := filepath.Join(dir, "disk.img.part0")
+			data := make([]byte, 8192)
+			data[0] = 1
+			if err := os.WriteFile(chunkFile, data, 0644); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+
+			// The marker of a run that never finished writing its chunks
+			if tt.crashed {
+				if err := os.WriteFile(d.writingMarkerPath(), nil, 0644); err != nil {
+					t.Fatalf("WriteFile() error = %v", err)
+				}
+			}
+
+			endChunkWrites := d.beginChunkWrites()
+			offset, err := d.detectChunkResumeOffset(chunkFile, 16384, d.uncleanShutdown)
+			endChunkWrites()
+			if err != nil {
+				t.Fatalf("detectChunkResumeOffset() error = %v", err)
+			}
+			if offset != tt.wantStart {
+				t.Errorf("resume offset = %d, want %d", offset, tt.wantStart)
+			}
+
+			// A finished run leaves no marker behind
+			if _, err := os.Stat(d.writingMarkerPath()); !os.IsNotExist(err) {
+				t.Errorf("marker file still exists after the chunk writes ended: %v", err)
+			}
+		})
+	}
+}
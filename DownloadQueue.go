@@ -0,0 +1,367 @@
+package udm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// DEFAULT_MAX_CONCURRENT_DOWNLOADS is used when Settings.MaxConcurrentDownloads is not set
+const DEFAULT_MAX_CONCURRENT_DOWNLOADS = 3
+
+// Queue event types reported to Settings.OnQueueChange
+const (
+	QUEUE_EVENT_ADDED     = "added"     // A download was added to the queue
+	QUEUE_EVENT_REMOVED   = "removed"   // A download was removed using Remove
+	QUEUE_EVENT_STARTED   = "started"   // A queued download was promoted to a free slot
+	QUEUE_EVENT_FINISHED  = "finished"  // An active download completed, failed or was stopped
+	QUEUE_EVENT_PAUSED    = "paused"    // The queue stopped promoting downloads
+	QUEUE_EVENT_RESUMED   = "resumed"   // The queue continued promoting downloads
+	QUEUE_EVENT_CANCELLED = "cancelled" // All downloads were cancelled using CancelAll
+)
+
+// QueueEvent describes a change of a DownloadQueue
+type QueueEvent struct {
+	Type       string          // One of the QUEUE_EVENT_* constants
+	ID         string          // ID of the affected download, empty for queue wide events
+	Downloader *Downloader     // The affected download, nil for queue wide events
+	Stats      QueueStatistics // Queue statistics after the change
+}
+
+// QueueStatistics holds the counts of a DownloadQueue
+type QueueStatistics struct {
+	Queued        int  // Downloads waiting for a slot
+	Active        int  // Downloads currently running
+	Completed     int  // Downloads that finished successfully
+	Failed        int  // Downloads that finished with an error
+	Stopped       int  // Downloads that were stopped or cancelled
+	MaxConcurrent int  // Number of downloads allowed to run at the same time
+	IsPaused      bool // True if the queue does not promote new downloads
+}
+
+// DownloadQueue runs downloads in the order of UserPreferences.Priority and then the order
+// they were added, with at most Settings.MaxConcurrentDownloads running at the same time.
+// When a download finishes, the next DOWNLOAD_QUEUED download is started in its slot.
+//
+// Example:
+//
+//	queue := NewDownloadQueue(UDMSettings)
+//	queue.Add(&Downloader{Url: "https://example.com/a.zip"})
+//	queue.Add(&Downloader{Url: "https://example.com/b.zip"})
+//	queue.Start(ctx)
+//
+//	stats := queue.Statistics()
+//	fmt.Printf("%d running, %d waiting\n", stats.Active, stats.Queued)
+type DownloadQueue struct {
+	mu        sync.Mutex
+	settings  *Settings
+	waiting   *Queue
+	byID      map[string]*Downloader
+	active    map[string]*Downloader
+	slots     chan struct{}
+	wake      chan struct{}
+	isPaused  bool
+	isStarted bool
+
+	completed int
+	failed    int
+	stopped   int
+}
+
+// NewDownloadQueue creates an empty download queue.
+//
+// Parameters:
+//   - settings: Settings holding MaxConcurrentDownloads and OnQueueChange, nil for UDMSettings
+//
+// Returns:
+//   - *DownloadQueue: Queue ready to accept downloads, call Start to run them
+func NewDownloadQueue(settings *Settings) *DownloadQueue {
+	if settings == nil {
+		settings = UDMSettings
+	}
+
+	maxConcurrent := DEFAULT_MAX_CONCURRENT_DOWNLOADS
+	if settings != nil {
+		maxConcurrent = settings.GetMaxConcurrentDownloads()
+	}
+
+	return &DownloadQueue{
+		settings: settings,
+		waiting:  NewQueue(),
+		byID:     make(map[string]*Downloader),
+		active:   make(map[string]*Downloader),
+		slots:    make(chan struct{}, maxConcurrent),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Add puts a download at the end of the queue with status DOWNLOAD_QUEUED.
+// It is started by the queue once a slot is free, never by Add itself.
+// A download without an ID gets one assigned.
+//
+// Parameters:
+//   - d: The download to add
+//
+// Returns:
+//   - string: ID of the download, used with Remove
+func (q *DownloadQueue) Add(d *Downloader) string {
+	q.mu.Lock()
+	d.ensureID()
+	q.byID[d.ID] = d
+	q.mu.Unlock()
+
+	d.setStatus(DOWNLOAD_QUEUED)
+	q.waiting.Enqueue(d)
+
+	q.notify(QUEUE_EVENT_ADDED, d)
+	q.signal()
+
+	return d.ID
+}
+
+// Remove takes a download out of the queue. A running download is stopped.
+//
+// Parameters:
+//   - id: ID returned by Add
+//
+// Returns:
+//   - error: Error if no download with this ID is in the queue
+func (q *DownloadQueue) Remove(id string) error {
+	q.mu.Lock()
+	d, ok := q.byID[id]
+	if !ok {
+		q.mu.Unlock()
+		return fmt.Errorf("no download with ID %q in the queue", id)
+	}
+	delete(q.byID, id)
+	_, isActive := q.active[id]
+	q.mu.Unlock()
+
+	if isActive {
+		d.StopDownload()
+	} else if q.waiting.Remove(d) {
+		d.setStatus(DOWNLOAD_STOPPED)
+	}
+
+	q.notify(QUEUE_EVENT_REMOVED, d)
+	return nil
+}
+
+// Start begins running queued downloads in the background.
+// New downloads are promoted until ctx is done, downloads already running are not affected.
+//
+// Parameters:
+//   - ctx: Context for cancellation of the queue
+func (q *DownloadQueue) Start(ctx context.Context) {
+	q.mu.Lock()
+	if q.isStarted {
+		q.mu.Unlock()
+		return
+	}
+	q.isStarted = true
+	q.mu.Unlock()
+
+	go q.run(ctx)
+}
+
+// Pause stops starting new downloads, running downloads continue
+func (q *DownloadQueue) Pause() {
+	q.mu.Lock()
+	q.isPaused = true
+	q.mu.Unlock()
+
+	q.notify(QUEUE_EVENT_PAUSED, nil)
+}
+
+// Resume continues starting queued downloads after Pause
+func (q *DownloadQueue) Resume() {
+	q.mu.Lock()
+	q.isPaused = false
+	q.mu.Unlock()
+
+	q.notify(QUEUE_EVENT_RESUMED, nil)
+	q.signal()
+}
+
+// PauseAll pauses the queue and every running download
+func (q *DownloadQueue) PauseAll() {
+	q.Pause()
+
+	for _, d := range q.activeDownloads() {
+		if d.PauseControl != nil {
+			d.Pause()
+		}
+	}
+}
+
+// ResumeAll resumes every paused download and the queue
+func (q *DownloadQueue) ResumeAll() {
+	for _, d := range q.activeDownloads() {
+		if d.PauseControl != nil {
+			d.Resume()
+		}
+	}
+
+	q.Resume()
+}
+
+// CancelAll removes every waiting download and stops every running one.
+// The queue keeps accepting new downloads afterwards.
+func (q *DownloadQueue) CancelAll() {
+	for d := q.waiting.Dequeue(); d != nil; d = q.waiting.Dequeue() {
+		d.setStatus(DOWNLOAD_STOPPED)
+
+		q.mu.Lock()
+		delete(q.byID, d.ID)
+		q.stopped++
+		q.mu.Unlock()
+	}
+
+	for _, d := range q.activeDownloads() {
+		d.StopDownload()
+	}
+
+	q.notify(QUEUE_EVENT_CANCELLED, nil)
+}
+
+// ActiveCount returns the number of downloads currently running
+func (q *DownloadQueue) ActiveCount() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.active)
+}
+
+// Statistics returns the current counts of the queue
+func (q *DownloadQueue) Statistics() QueueStatistics {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return QueueStatistics{
+		Queued:        q.waiting.Len(),
+		Active:        len(q.active),
+		Completed:     q.completed,
+		Failed:        q.failed,
+		Stopped:       q.stopped,
+		MaxConcurrent: cap(q.slots),
+		IsPaused:      q.isPaused,
+	}
+}
+
+// run promotes queued downloads to free slots until ctx is done.
+//
+// Parameters:
+//   - ctx: Context for cancellation of the queue
+func (q *DownloadQueue) run(ctx context.Context) {
+	for {
+		// Wait for a free slot
+		select {
+		case <-ctx.Done():
+			return
+		case q.slots <- struct{}{}:
+		}
+
+		d := q.next()
+		if d == nil {
+			// Nothing to start, give the slot back and wait for a change
+			<-q.slots
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.wake:
+			}
+			continue
+		}
+
+		go q.runDownload(d)
+	}
+}
+
+// next takes the waiting download with the highest priority out of the queue.
+//
+// Returns:
+//   - *Downloader: Download marked as active, or nil if the queue is paused or empty
+func (q *DownloadQueue) next() *Downloader {
+	q.mu.Lock()
+	if q.isPaused {
+		q.mu.Unlock()
+		return nil
+	}
+	q.mu.Unlock()
+
+	d := q.waiting.DequeueHighestPriority()
+	if d == nil {
+		return nil
+	}
+
+	q.mu.Lock()
+	q.active[d.ID] = d
+	q.mu.Unlock()
+
+	return d
+}
+
+// runDownload runs an active download and frees its slot when it is done.
+//
+// Parameters:
+//   - d: The download to run
+func (q *DownloadQueue) runDownload(d *Downloader) {
+	q.notify(QUEUE_EVENT_STARTED, d)
+
+	d.StartDownload()
+
+	q.mu.Lock()
+	delete(q.active, d.ID)
+	delete(q.byID, d.ID)
+	switch d.GetStatus() {
+	case DOWNLOAD_COMPLETED:
+		q.completed++
+	case DOWNLOAD_FAILED:
+		q.failed++
+	default:
+		q.stopped++
+	}
+	q.mu.Unlock()
+
+	<-q.slots
+
+	q.notify(QUEUE_EVENT_FINISHED, d)
+	q.signal()
+}
+
+// activeDownloads returns a copy of the running downloads
+func (q *DownloadQueue) activeDownloads() []*Downloader {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	downloads := make([]*Downloader, 0, len(q.active))
+	for _, d := range q.active {
+		downloads = append(downloads, d)
+	}
+	return downloads
+}
+
+// signal wakes up the run loop if it is waiting for queued downloads
+func (q *DownloadQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// notify calls Settings.OnQueueChange, if set.
+//
+// Parameters:
+//   - eventType: One of the QUEUE_EVENT_* constants
+//   - d: The affected download, nil for queue wide events
+func (q *DownloadQueue) notify(eventType string, d *Downloader) {
+	if q.settings == nil || q.settings.OnQueueChange == nil {
+		return
+	}
+
+	event := QueueEvent{Type: eventType, Downloader: d, Stats: q.Statistics()}
+	if d != nil {
+		event.ID = d.ID
+	}
+
+	q.settings.OnQueueChange(event)
+}
@@ -0,0 +1,139 @@
+package udm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DownloadReport is a summary of a finished download
+// It is intended for automated pipelines that need to verify the result
+type DownloadReport struct {
+	ID           string        `json:"id"`
+	URL          string        `json:"url"`
+	FinalURL     string        `json:"final_url"`
+	Status       string        `json:"status"`
+	Filename     string        `json:"filename"`
+	OutputPath   string        `json:"output_path"`
+	FileSize     int64         `json:"file_size"`
+	Duration     time.Duration `json:"duration_ns"`
+	AverageSpeed float64       `json:"average_speed_bps"`
+	PeakSpeed    float64       `json:"peak_speed_bps"`
+	ThreadCount  int           `json:"thread_count"`
+	RetryCount   int           `json:"retry_count"`
+	RetryStats   RetryStats    `json:"retry_stats"`
+	ChunkCount   int           `json:"chunk_count"`
+	Checksum     string        `json:"checksum,omitempty"`
+	ChecksumAlgo string        `json:"checksum_algo,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// GenerateReport captures the current state of the download in a DownloadReport.
+// It is usually called from the OnFinish or OnError callback.
+//
+// Returns:
+//   - DownloadReport: Snapshot of the download information
+//
+// Example:
+//
+//	report := downloader.GenerateReport()
+//	data, err := report.ToJSON()
+//	if err != nil {
+//		fmt.Println("Error:", err)
+//		return
+//	}
+//	os.WriteFile("report.json", data, 0644)
+func (d *Downloader) GenerateReport() DownloadReport {
+	report := DownloadReport{
+		ID:           d.GetID(),
+		URL:          d.GetURL(),
+		FinalURL:     d.GetFinalURL(),
+		Status:       d.GetStatus(),
+		Filename:     d.GetFilename(),
+		OutputPath:   d.GetFilePath(),
+		FileSize:     d.GetFileSize(),
+		Duration:     d.GetTimeTaken(),
+		AverageSpeed: d.GetAverageSpeed(),
+		PeakSpeed:    d.GetPeakSpeed(),
+		ThreadCount:  d.GetThreadCount(),
+		RetryCount:   d.GetRetryCount(),
+		RetryStats:   d.GetRetryStats(),
+		ChunkCount:   len(d.Chunks),
+	}
+
+	// Only report the checksum once it has been verified
+	if d.verifiedChecksum != "" {
+		report.Checksum = d.verifiedChecksum
+		report.ChecksumAlgo = d.Prefs.ChecksumAlgo
+		if report.ChecksumAlgo == "" {
+			report.ChecksumAlgo = "sha256"
+		}
+	}
+
+	if d.Error != nil {
+		report.Error = d.Error.Error()
+	}
+
+	return report
+}
+
+// GetPeakSpeed returns the highest speed recorded in the progress history.
+//
+// Returns:
+//   - float64: Peak speed in bytes per second (0 if no history was recorded)
+func (d *Downloader) GetPeakSpeed() float64 {
+	var peak float64
+	for _, sample := range d.GetSpeedHistory() {
+		if sample.SpeedBps > peak {
+			peak = sample.SpeedBps
+		}
+	}
+	return peak
+}
+
+// ToJSON encodes the report as indented JSON.
+//
+// Returns:
+//   - []byte: JSON encoded report
+//   - error: Error if encoding fails
+func (r DownloadReport) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// ToString formats the report as human readable multi-line text.
+//
+// Returns:
+//   - string: Report with one field per line
+func (r DownloadReport) ToString() string {
+	var sb strings.Builder
+
+	border := strings.Repeat("=", 50)
+	sb.WriteString(border + "\n")
+	sb.WriteString("Download report\n")
+	sb.WriteString(border + "\n")
+	sb.WriteString(fmt.Sprintf("ID            :: %s\n", r.ID))
+	sb.WriteString(fmt.Sprintf("URL           :: %s\n", r.URL))
+	if r.FinalURL != "" && r.FinalURL != r.URL {
+		sb.WriteString(fmt.Sprintf("Final URL     :: %s\n", r.FinalURL))
+	}
+	sb.WriteString(fmt.Sprintf("Status        :: %s\n", r.Status))
+	sb.WriteString(fmt.Sprintf("Filename      :: %s\n", r.Filename))
+	sb.WriteString(fmt.Sprintf("Output path   :: %s\n", r.OutputPath))
+	sb.WriteString(fmt.Sprintf("File size     :: %s\n", ReadableFileSize(r.FileSize)))
+	sb.WriteString(fmt.Sprintf("Time taken    :: %s\n", ReadableTime(int64(r.Duration.Seconds()))))
+	sb.WriteString(fmt.Sprintf("Average speed :: %s\n", ReadableSpeed(r.AverageSpeed)))
+	sb.WriteString(fmt.Sprintf("Peak speed    :: %s\n", ReadableSpeed(r.PeakSpeed)))
+	sb.WriteString(fmt.Sprintf("Threads       :: %d\n", r.ThreadCount))
+	sb.WriteString(fmt.Sprintf("Retries       :: %d (max %d)\n", r.RetryStats.TotalRetries, r.RetryCount))
+	sb.WriteString(fmt.Sprintf("Chunks        :: %d\n", r.ChunkCount))
+	if r.Checksum != "" {
+		sb.WriteString(fmt.Sprintf("Checksum      :: %s (%s)\n", r.Checksum, r.ChecksumAlgo))
+	}
+	if r.Error != "" {
+		sb.WriteString(fmt.Sprintf("Error         :: %s\n", r.Error))
+	}
+	sb.WriteString(border)
+
+	return sb.String()
+}
@@ -2,13 +2,14 @@ package udm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -71,8 +72,9 @@ func (d *Downloader) DownloadSingleStream() {
 //   - error: Error if initialization fails
 func (d *Downloader) initializeSingleStreamDownload() error {
 	// Set initial status
-	d.Status = DOWNLOAD_IN_PROGRESS
+	d.setStatus(DOWNLOAD_IN_PROGRESS)
 	d.TimeStats.StartTime = time.Now()
+	d.streamedSHA256 = ""
 
 	// Initialize progress tracker if not exists
 	if d.Progress == nil {
@@ -91,12 +93,15 @@ func (d *Downloader) initializeSingleStreamDownload() error {
 
 	// Setup file paths
 	if err := d.setupDownloadPaths(); err != nil {
-		return fmt.Errorf("failed to setup download paths: %v", err)
+		return fmt.Errorf("failed to setup download paths: %w", err)
 	}
 
+	d.logEvent(EVENT_STARTED, "single-stream download of %s to %s", d.requestURL(), d.fileInfo.FullPath)
+	d.sendWebhook(WEBHOOK_EVENT_START)
+
 	// Call start callback
-	if d.Callbacks != nil && d.Callbacks.OnStart != nil {
-		d.Callbacks.OnStart(d)
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnStart != nil {
+		callbacks.OnStart(d)
 	}
 
 	return nil
@@ -108,6 +113,11 @@ func (d *Downloader) initializeSingleStreamDownload() error {
 // Returns:
 //   - error: Error if path setup fails
 func (d *Downloader) setupDownloadPaths() error {
+	// Keep the name already claimed by this download, e.g. when elevating to multi-stream
+	if d.claimedOutputPath != "" && d.claimedOutputPath == d.fileInfo.FullPath {
+		return nil
+	}
+
 	// Determine download directory
 	downloadDir := d.getDownloadDirectory()
 	if downloadDir == "" {
@@ -122,27 +132,39 @@ func (d *Downloader) setupDownloadPaths() error {
 	}
 
 	// Ensure download directory exists
-	if err := os.MkdirAll(downloadDir, 0755); err != nil {
-		return fmt.Errorf("failed to create download directory: %v", err)
+	if err := os.MkdirAll(downloadDir, d.getDirMode()); err != nil {
+		return &DiskError{Op: "mkdir", Path: downloadDir, Err: err}
 	}
 
 	// Determine filename
 	filename := d.getUserPreferredFilename()
 	if filename == "" {
-		filename = d.ServerHeaders.Filename
-		if filename == "" {
-			filename = "downloaded_file"
-			// Add extension from MIME type if available
-			if d.ServerHeaders.Filetype != "" {
-				ext := mimeExtensionFromContentType(d.ServerHeaders.Filetype)
-				filename += ext
-			}
+		if d.fileInfo.Name != "" {
+			// Keep the name resolved by CheckPreferences
+			filename = d.fileInfo.Name
+		} else if name := d.templateFilename(); name != "" {
+			filename = name
+		} else if d.ServerHeaders.Filename != "" && !d.ServerHeaders.IsFallbackName {
+			filename = d.ServerHeaders.Filename
+		} else {
+			filename = d.defaultFilename()
 		}
 	}
 
-	// Create full path and ensure uniqueness
+	// Prevent path traversal and invalid names
+	filename = ufs.SanitizeFilename(filename)
+	if filename == "" {
+		filename = d.defaultFilename()
+	}
+
+	// Create full path and ensure uniqueness, unless resuming the partial file of a saved state,
+	// continuing an interrupted download that already claimed the name or replacing a stale file
 	fullPath := filepath.Join(downloadDir, filename)
-	uniquePath := ufs.GenerateUniqueFilename(fullPath)
+	uniquePath := fullPath
+	keepPath := (d.restoredFromState || d.replaceOutput) && fullPath == d.fileInfo.FullPath
+	if !keepPath && !d.ownsOutputPath(fullPath) {
+		uniquePath = ufs.GenerateUniqueFilename(fullPath)
+	}
 
 	// Update file info
 	d.fileInfo.Dir = downloadDir
@@ -164,20 +186,62 @@ func (d *Downloader) executeSingleStreamDownload(ctx context.Context, cancel con
 	headerChan := make(chan *ServerData, 1)
 	go d.concurrentHeaderAnalysis(ctx, headerChan)
 
+	// Keep expiring URLs valid, resumed requests pick up the new URL
+	refreshCtx, stopRefresh := context.WithCancel(ctx)
+	defer stopRefresh()
+	go d.refreshURLPeriodically(refreshCtx)
+
 	// Check for existing partial download
 	resumeOffset, err := d.detectResumeOffset()
 	if err != nil {
-		d.handleDownloadError(fmt.Errorf("failed to detect resume offset: %v", err))
+		d.handleDownloadError(fmt.Errorf("failed to detect resume offset: %w", err))
 		return
 	}
 
-	// Perform the download
-	if err := d.performSingleStreamDownload(ctx, resumeOffset, headerChan); err != nil {
-		if ctx.Err() == context.Canceled {
-			d.Status = DOWNLOAD_STOPPED
-			if d.Callbacks != nil && d.Callbacks.OnStop != nil {
-				d.Callbacks.OnStop(d)
+	for attempt := 1; ; attempt++ {
+		// Perform the download, the request gets its own context so it can be
+		// cancelled when elevating to multi-stream without stopping the download
+		requestCtx, cancelRequest := context.WithCancel(ctx)
+		err = d.performSingleStreamDownload(requestCtx, resumeOffset, headerChan)
+		cancelRequest()
+
+		if !d.shouldResumeSingleStream(ctx, err, attempt) {
+			break
+		}
+		d.recordRetry(0, attempt, err)
+
+		// Give a stalled server time to recover, like a chunk that timed out
+		if isIdleTimeout(err) {
+			select {
+			case <-time.After(currentRetryPolicy().NextDelay(attempt)):
+			case <-ctx.Done():
+			}
+			if ctx.Err() != nil {
+				err = ctx.Err()
+				break
 			}
+		}
+
+		// Request only the missing tail of the file
+		if resumeOffset, err = d.detectResumeOffset(); err != nil {
+			break
+		}
+	}
+
+	if errors.Is(err, errElevateToMultiStream) {
+		d.Progress.mu.Lock()
+		offset := d.Progress.BytesCompleted
+		d.Progress.mu.Unlock()
+
+		// The multi-stream download refreshes the URL itself
+		stopRefresh()
+		d.elevateToMultiStream(offset)
+		return
+	}
+
+	if err != nil {
+		if ctx.Err() == context.Canceled {
+			d.handleDownloadStop(ctx.Err())
 		} else {
 			d.handleDownloadError(err)
 		}
@@ -188,6 +252,30 @@ func (d *Downloader) executeSingleStreamDownload(ctx context.Context, cancel con
 	d.finalizeDownload()
 }
 
+// shouldResumeSingleStream reports whether a single-stream download that ended before the whole
+// file was received should continue with a range request for the rest. This is the case when
+// the connection stalled past the idle timeout, or when it was closed early and
+// AutoRetryIncomplete is set.
+//
+// Parameters:
+//   - ctx: Context of the download, no retry once it is cancelled
+//   - err: Error of the attempt
+//   - attempt: Number of the attempt that failed, starting at 1
+//
+// Returns:
+//   - bool: True if the missing tail should be requested
+func (d *Downloader) shouldResumeSingleStream(ctx context.Context, err error, attempt int) bool {
+	if err == nil || ctx.Err() != nil || !d.ServerHeaders.AcceptsRanges || attempt > d.getRetryCount() {
+		return false
+	}
+	if isIdleTimeout(err) {
+		return true
+	}
+
+	var incompleteErr *IncompleteDownloadError
+	return d.Prefs.AutoRetryIncomplete && errors.As(err, &incompleteErr)
+}
+
 // concurrentHeaderAnalysis performs header analysis alongside the download
 // to detect if the server supports range requests during the actual download.
 //
@@ -205,11 +293,10 @@ func (d *Downloader) concurrentHeaderAnalysis(ctx context.Context, headerChan ch
 	}
 
 	// Perform GET request to get headers during download
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
+	client := d.buildHTTPClient()
+	client.Timeout = 10 * time.Second
 
-	req, err := http.NewRequestWithContext(ctx, "GET", d.Url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", d.requestURL(), nil)
 	if err != nil {
 		return
 	}
@@ -226,12 +313,20 @@ func (d *Downloader) concurrentHeaderAnalysis(ctx context.Context, headerChan ch
 	// Make a partial request to get headers
 	req.Header.Set("Range", "bytes=0-1023") // Request first 1KB
 
-	resp, err := client.Do(req)
+	// Switch to the fallback URLs like the download request if the current URL fails
+	resp, err := d.doWithFallback(req, client.Do, func(statusCode int) bool {
+		return statusCode == http.StatusOK || statusCode == http.StatusPartialContent
+	})
 	if err != nil {
 		return
 	}
 	defer resp.Body.Close()
 
+	// The mirrors may all have failed as well
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return
+	}
+
 	// Discard the body since we only want headers
 	io.Copy(io.Discard, resp.Body)
 
@@ -267,11 +362,11 @@ func (d *Downloader) concurrentHeaderAnalysis(ctx context.Context, headerChan ch
 //   - int64: Byte offset to resume from (0 if starting fresh)
 //   - error: Error if offset detection fails
 func (d *Downloader) detectResumeOffset() (int64, error) {
-	if !ufs.FileExists(d.fileInfo.FullPath) {
+	if !ufs.FileExists(d.incompletePath()) {
 		return 0, nil
 	}
 
-	fileInfo, err := os.Stat(d.fileInfo.FullPath)
+	fileInfo, err := os.Stat(d.incompletePath())
 	if err != nil {
 		return 0, nil // Start fresh if we can't get file info
 	}
@@ -295,24 +390,18 @@ func (d *Downloader) detectResumeOffset() (int64, error) {
 // Returns:
 //   - error: Error if download fails
 func (d *Downloader) performSingleStreamDownload(ctx context.Context, resumeOffset int64, headerChan <-chan *ServerData) error {
+	// Fail early instead of filling up the disk halfway through
+	if d.ServerHeaders.Filesize > 0 {
+		if err := d.checkDiskSpace(d.ServerHeaders.Filesize - resumeOffset); err != nil {
+			return err
+		}
+	}
 
 	// Create HTTP client with granular timeouts, but no total timeout
-	client := &http.Client{
-		Transport: &http.Transport{
-			// Timeout for establishing a connection
-			DialContext: (&net.Dialer{
-				Timeout: 15 * time.Second,
-			}).DialContext,
-			// Timeout for waiting for the server's response headers
-			ResponseHeaderTimeout: 15 * time.Second,
-			// Timeout for waiting for a TLS handshake
-			TLSHandshakeTimeout: 10 * time.Second,
-		},
-		// DO NOT SET THE TOP-LEVEL TIMEOUT FIELD FOR DOWNLOADS
-		// Timeout: 30 * time.Second,
-	}
+	client := d.buildHTTPClient()
+
 	// Create request
-	req, err := http.NewRequestWithContext(ctx, "GET", d.Url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", d.requestURL(), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %v", err)
 	}
@@ -331,16 +420,32 @@ func (d *Downloader) performSingleStreamDownload(ctx context.Context, resumeOffs
 		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", resumeOffset))
 	}
 
-	// Make request
-	resp, err := client.Do(req)
+	// Make request, retrying on status codes like 429 and 503 and then on the fallback URLs
+	resp, err := d.doWithFallback(req, func(r *http.Request) (*http.Response, error) {
+		return d.doWithStatusRetry(ctx, client, r)
+	}, func(statusCode int) bool {
+		return statusCode == http.StatusOK || statusCode == http.StatusPartialContent
+	})
 	if err != nil {
-		return fmt.Errorf("failed to make request: %v", err)
+		if ctx.Err() != nil {
+			return err
+		}
+		return &NetworkError{Op: "request", Host: req.URL.Host, Err: err}
 	}
 	defer resp.Body.Close()
 
 	// Check response status
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		// Server honoured the Range header, continue from resumeOffset
+	case http.StatusOK:
+		// Server ignored the Range header and is sending the whole file,
+		// so discard the partial data and restart from the beginning
+		if resumeOffset > 0 {
+			resumeOffset = 0
+		}
+	default:
+		return &ServerError{StatusCode: resp.StatusCode, URL: d.requestURL()}
 	}
 
 	// Get content length
@@ -358,15 +463,33 @@ func (d *Downloader) performSingleStreamDownload(ctx context.Context, resumeOffs
 	// Open/create output file
 	file, err := d.openOutputFile(resumeOffset)
 	if err != nil {
-		return fmt.Errorf("failed to open output file: %v", err)
+		return &DiskError{Op: "open", Path: d.incompletePath(), Err: err}
 	}
 	defer file.Close()
 
 	// Download with progress tracking
-	return d.downloadWithProgress(ctx, resp.Body, file, totalSize, headerChan)
+	// Detect stalled connections
+	body := d.newDeadlineReader(resp.Body)
+	defer body.Close()
+
+	// Hash while writing so the checksum doesn't need another read of the file
+	var writer io.Writer = file
+	var hasher *hashWriter
+	if d.needsStreamingHash() {
+		if hasher = d.newResumedHashWriter(file, resumeOffset); hasher != nil {
+			writer = hasher
+		}
+	}
+
+	err = d.downloadWithProgress(ctx, body, writer, totalSize, headerChan)
+	if err == nil && hasher != nil {
+		d.streamedSHA256 = hasher.sum()
+	}
+	return err
 }
 
 // openOutputFile opens the output file for writing, handling resume scenarios.
+// Data is written to the incomplete file until finalizeDownload moves it into place.
 //
 // Parameters:
 //   - resumeOffset: Byte offset to resume from
@@ -376,12 +499,26 @@ func (d *Downloader) performSingleStreamDownload(ctx context.Context, resumeOffs
 //   - error: Error if file opening fails
 func (d *Downloader) openOutputFile(resumeOffset int64) (*os.File, error) {
 	if resumeOffset > 0 {
+		// The name was claimed by the run that wrote the incomplete file
+		d.setClaimedOutputPath(d.fileInfo.FullPath)
+
 		// Open for appending
-		return os.OpenFile(d.fileInfo.FullPath, os.O_WRONLY|os.O_APPEND, 0644)
-	} else {
-		// Create new file
-		return os.Create(d.fileInfo.FullPath)
+		return os.OpenFile(d.incompletePath(), os.O_WRONLY|os.O_APPEND, d.getFileMode())
+	}
+
+	// Claim the output name before writing, it may have been taken since setupDownloadPaths
+	if err := d.claimOutputPath(); err != nil {
+		return nil, err
+	}
+
+	// Create new file with the configured permissions (still reduced by the umask)
+	file, err := os.OpenFile(d.incompletePath(), os.O_RDWR|os.O_CREATE|os.O_TRUNC, d.getFileMode())
+	if err != nil {
+		return nil, err
 	}
+
+	d.preallocate(file, d.ServerHeaders.Filesize)
+	return file, nil
 }
 
 // downloadWithProgress performs the download with progress tracking and pause/resume support.
@@ -396,7 +533,7 @@ func (d *Downloader) openOutputFile(resumeOffset int64) (*os.File, error) {
 // Returns:
 //   - error: Error if download fails
 func (d *Downloader) downloadWithProgress(ctx context.Context, reader io.Reader, writer io.Writer, totalSize int64, headerChan <-chan *ServerData) error {
-	buffer := make([]byte, 32*1024) // 32KB buffer
+	buffer := make([]byte, d.getBufferSize())
 	elevationChecked := false
 
 	for {
@@ -409,8 +546,9 @@ func (d *Downloader) downloadWithProgress(ctx context.Context, reader io.Reader,
 			return ctx.Err()
 		case updatedHeaders := <-headerChan:
 			// Handle updated headers from concurrent analysis
-			if updatedHeaders != nil {
-				d.handleUpdatedHeaders(updatedHeaders, &elevationChecked, totalSize)
+			if updatedHeaders != nil && d.handleUpdatedHeaders(updatedHeaders, &elevationChecked, totalSize) {
+				// Stop reading, the rest of the file is downloaded by multiple streams
+				return errElevateToMultiStream
 			}
 		default:
 		}
@@ -421,18 +559,38 @@ func (d *Downloader) downloadWithProgress(ctx context.Context, reader io.Reader,
 			// Write data
 			written, writeErr := writer.Write(buffer[:n])
 			if writeErr != nil {
-				return fmt.Errorf("failed to write data: %v", writeErr)
+				return &DiskError{Op: "write", Path: d.incompletePath(), Err: writeErr}
 			}
 
 			// Update progress
 			d.updateProgress(int64(written), totalSize)
+
+			// Stay under the speed limit
+			if err := d.throttle(ctx, written); err != nil {
+				return err
+			}
 		}
 
 		if err == io.EOF {
 			break
 		}
+		// The connection was closed before Content-Length bytes arrived, reported below
+		if errors.Is(err, io.ErrUnexpectedEOF) && totalSize > 0 {
+			break
+		}
 		if err != nil {
-			return fmt.Errorf("failed to read data: %v", err)
+			return &NetworkError{Op: "read", Host: d.requestHost(), Err: err}
+		}
+	}
+
+	// The connection may be closed before the whole body was sent
+	if totalSize > 0 {
+		d.Progress.mu.Lock()
+		completed := d.Progress.BytesCompleted
+		d.Progress.mu.Unlock()
+
+		if completed < totalSize {
+			return &IncompleteDownloadError{Expected: totalSize, Got: completed}
 		}
 	}
 
@@ -445,7 +603,10 @@ func (d *Downloader) downloadWithProgress(ctx context.Context, reader io.Reader,
 //   - headers: Updated server headers
 //   - elevationChecked: Pointer to elevation check flag
 //   - totalSize: Current total download size
-func (d *Downloader) handleUpdatedHeaders(headers *ServerData, elevationChecked *bool, totalSize int64) {
+//
+// Returns:
+//   - bool: True if the download should be elevated to multi-stream
+func (d *Downloader) handleUpdatedHeaders(headers *ServerData, elevationChecked *bool, totalSize int64) bool {
 	// Update server headers if we got better information
 	if headers.Filesize > 0 && d.ServerHeaders.Filesize == 0 {
 		d.ServerHeaders.Filesize = headers.Filesize
@@ -456,11 +617,17 @@ func (d *Downloader) handleUpdatedHeaders(headers *ServerData, elevationChecked
 	}
 
 	// Check for elevation to multi-stream if conditions are met
-	if !*elevationChecked && d.shouldElevateToMultiStream(headers, totalSize) {
+	if !*elevationChecked && !d.hasElevated && d.shouldElevateToMultiStream(headers, totalSize) {
 		*elevationChecked = true
-		// TODO: Implement elevation to multi-stream download
-		// This would pause current download and switch to multi-threaded mode
+
+		// Multi-stream needs the exact file size to divide it into chunks
+		if headers.Filesize > 0 {
+			d.ServerHeaders.Filesize = headers.Filesize
+		}
+		return d.ServerHeaders.Filesize > 0
 	}
+
+	return false
 }
 
 // shouldElevateToMultiStream determines if download should be elevated to multi-stream.
@@ -501,10 +668,11 @@ func (d *Downloader) checkPauseState() {
 
 	if d.PauseControl.isPaused {
 		// We're paused, call the pause callback once without holding the mutex
-		pauseCallback := d.Callbacks != nil && d.Callbacks.OnPause != nil
+		callbacks := d.callbacks()
+		pauseCallback := callbacks != nil && callbacks.OnPause != nil
 		var pauseFunc func(d *Downloader)
 		if pauseCallback {
-			pauseFunc = d.Callbacks.OnPause
+			pauseFunc = callbacks.OnPause
 		}
 
 		d.PauseControl.mu.Unlock()
@@ -519,10 +687,11 @@ func (d *Downloader) checkPauseState() {
 		}
 
 		// We're resumed, call the resume callback once without holding the mutex
-		resumeCallback := d.Callbacks != nil && d.Callbacks.OnResume != nil
+		callbacks = d.callbacks()
+		resumeCallback := callbacks != nil && callbacks.OnResume != nil
 		var resumeFunc func(d *Downloader)
 		if resumeCallback {
-			resumeFunc = d.Callbacks.OnResume
+			resumeFunc = callbacks.OnResume
 		}
 
 		d.PauseControl.mu.Unlock()
@@ -545,31 +714,125 @@ func (d *Downloader) updateProgress(bytesRead int64, totalSize int64) {
 	d.Progress.BytesCompleted += bytesRead
 	now := time.Now()
 
+	// Record a speed sample for the rolling average
+	d.Progress.recordSpeedSample(now)
+
 	// Calculate speed every second
 	if now.Sub(d.Progress.LastReported) >= time.Second {
-		elapsed := now.Sub(d.Progress.LastReported).Seconds()
-		d.Progress.SpeedBps = float64(bytesRead) / elapsed
+		d.Progress.updateSpeed(now, totalSize)
 		d.Progress.LastReported = now
+		d.Progress.addHistorySample(now)
 		shouldCallCallback = true
 	}
 	d.Progress.mu.Unlock()
 
 	// Call progress callback outside of mutex to prevent deadlock
-	if shouldCallCallback && d.Callbacks != nil && d.Callbacks.OnProgress != nil {
-		d.Callbacks.OnProgress(d)
+	if shouldCallCallback {
+		d.reportProgress()
 	}
 }
 
 // finalizeDownload completes the download process and updates status.
 func (d *Downloader) finalizeDownload() {
-	d.Status = DOWNLOAD_COMPLETED
+	// Verify file integrity if a checksum was provided, before the file gets its final name
+	if d.Prefs.Checksum != "" {
+		if err := d.verifyChecksum(); err != nil {
+			d.handleDownloadError(err)
+			return
+		}
+	}
+
+	// Verify the SHA-256 if one was provided
+	if d.ExpectedSHA256 != "" {
+		if err := d.verifySHA256(); err != nil {
+			d.handleDownloadError(err)
+			return
+		}
+	}
+
+	// Only now the complete and verified file appears at the final path
+	if err := d.commitOutputFile(); err != nil {
+		d.handleDownloadError(err)
+		return
+	}
+
+	// Warn if the content doesn't match the Content-Type, e.g. an error page instead of the file
+	d.checkFileType()
+
+	// The download cannot be resumed anymore
+	d.removeStateFile()
+
+	// Remember the validators to skip the next download of an unchanged file
+	if d.Prefs.SkipIfUnchanged {
+		d.writeMetadataFile()
+	}
+
+	d.setStatus(DOWNLOAD_COMPLETED)
 	d.TimeStats.EndTime = time.Now()
 	d.TimeStats.Elapsed = d.TimeStats.EndTime.Sub(d.TimeStats.StartTime)
+	d.recordHistory()
+	d.endDownloadSpan(nil)
+	d.logEvent(EVENT_COMPLETED, "saved to %s in %s", d.OutputPath, d.TimeStats.Elapsed)
+	d.sendWebhook(WEBHOOK_EVENT_FINISH)
 
 	// Call completion callback
-	if d.Callbacks != nil && d.Callbacks.OnFinish != nil {
-		d.Callbacks.OnFinish(d)
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnFinish != nil {
+		callbacks.OnFinish(d)
 	}
+
+	d.markDone()
+}
+
+// verifyChecksum compares the checksum of the downloaded file with the expected one.
+//
+// Returns:
+//   - error: Error if the checksum cannot be computed or does not match
+func (d *Downloader) verifyChecksum() error {
+	algo := d.Prefs.ChecksumAlgo
+	if algo == "" {
+		algo = "sha256"
+	}
+
+	var ok bool
+	var err error
+	if d.streamedSHA256 != "" && strings.EqualFold(algo, "sha256") {
+		// Computed while downloading
+		ok = d.streamedSHA256 == strings.ToLower(strings.TrimSpace(d.Prefs.Checksum))
+	} else {
+		ok, err = ufs.VerifyFileChecksum(d.verificationPath(), algo, d.Prefs.Checksum)
+	}
+	if err != nil {
+		return &DiskError{Op: "checksum", Path: d.verificationPath(), Err: err}
+	}
+	if !ok {
+		return &ChecksumError{Algo: algo, Expected: d.Prefs.Checksum, Path: d.verificationPath()}
+	}
+
+	d.verifiedChecksum = strings.ToLower(strings.TrimSpace(d.Prefs.Checksum))
+	return nil
+}
+
+// verifySHA256 compares the SHA-256 of the downloaded file with ExpectedSHA256
+// and fires OnHashMismatch when they differ.
+//
+// Returns:
+//   - error: HashMismatchError on mismatch, or an error if the file cannot be hashed
+func (d *Downloader) verifySHA256() error {
+	actual, err := d.fileSHA256()
+	if err != nil {
+		return &DiskError{Op: "hash", Path: d.verificationPath(), Err: err}
+	}
+
+	expected := strings.ToLower(strings.TrimSpace(d.ExpectedSHA256))
+	if actual == expected {
+		return nil
+	}
+
+	d.logEvent(EVENT_HASH_MISMATCH, "expected SHA-256 %s, got %s", expected, actual)
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnHashMismatch != nil {
+		callbacks.OnHashMismatch(d, expected, actual)
+	}
+	return &HashMismatchError{Expected: expected, Got: actual}
 }
 
 // handleDownloadError handles download errors and updates status.
@@ -577,14 +840,49 @@ func (d *Downloader) finalizeDownload() {
 // Parameters:
 //   - err: The error that occurred
 func (d *Downloader) handleDownloadError(err error) {
-	d.Status = DOWNLOAD_FAILED
-	d.Error = err
+	// Wrap the error so callers can inspect the cause
+	var downloadErr *DownloadError
+	if !errors.As(err, &downloadErr) {
+		downloadErr = &DownloadError{Err: err}
+	}
+
+	// Don't leave partial files or the claimed name behind
+	d.removeIncompleteOutput()
+
+	d.setStatus(DOWNLOAD_FAILED)
+	d.Error = downloadErr
+	d.ErrorCode = errorCode(downloadErr)
+	d.endDownloadSpan(downloadErr)
 	d.TimeStats.EndTime = time.Now()
 	d.TimeStats.Elapsed = d.TimeStats.EndTime.Sub(d.TimeStats.StartTime)
+	d.recordHistory()
+	d.logEvent(EVENT_ERROR, "%v", downloadErr)
+	d.sendWebhook(WEBHOOK_EVENT_ERROR)
 
 	// Call error callback
-	if d.Callbacks != nil && d.Callbacks.OnError != nil {
-		d.Callbacks.OnError(d, err)
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnError != nil {
+		callbacks.OnError(d, downloadErr)
+	}
+
+	d.markDone()
+}
+
+// handleDownloadStop handles a download stopped using StopDownload and updates status.
+//
+// Parameters:
+//   - err: The cancellation error of the download context
+func (d *Downloader) handleDownloadStop(err error) {
+	// Don't leave partial files or the claimed name behind
+	d.removeIncompleteOutput()
+
+	d.endDownloadSpan(err)
+	d.setStatus(DOWNLOAD_STOPPED)
+	d.logEvent(EVENT_STOPPED, "download stopped")
+	d.sendWebhook(WEBHOOK_EVENT_STOP)
+
+	// Call stop callback
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnStop != nil {
+		callbacks.OnStop(d)
 	}
 }
 
@@ -0,0 +1,250 @@
+package udm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"udl/udm/ufs"
+)
+
+// STATE_FILE_EXTENSION is the extension of the file written by SaveState next to the download
+const STATE_FILE_EXTENSION = ".udmstate"
+
+// downloaderState is the JSON content of a saved download state file
+type downloaderState struct {
+	URL           string           `json:"url"`
+	FileInfo      FileInfo         `json:"file_info"`
+	ServerHeaders ServerData       `json:"server_headers"`
+	Chunks        []ChunkData      `json:"chunks"`
+	Progress      ProgressSnapshot `json:"progress"`
+
+	// Reference checksums of the chunk files, see UserPreferences.ValidateResumedChunks
+	ChunkReferences map[string]chunkReference `json:"chunk_references,omitempty"`
+}
+
+// SaveState writes the state of the download to a JSON file, so it can be resumed
+// after the process restarts using LoadDownloaderState.
+// StartDownload picks up the state automatically when it is saved to StateFilePath.
+//
+// Parameters:
+//   - path: Path of the state file to write
+//
+// Returns:
+//   - error: Error if the state cannot be encoded or written
+//
+// Example:
+//
+//	// Before exiting
+//	if err := downloader.SaveState(downloader.StateFilePath()); err != nil {
+//	    log.Println("Failed to save download state:", err)
+//	}
+func (d *Downloader) SaveState(path string) error {
+	state := d.currentState()
+	if d.resumeState != nil {
+		state.ChunkReferences = d.resumeState.references()
+	}
+	return writeDownloaderState(path, state)
+}
+
+// currentState collects the state of the download, without the chunk references.
+//
+// Returns:
+//   - *downloaderState: State to write to a state file
+func (d *Downloader) currentState() *downloaderState {
+	state := &downloaderState{
+		URL:           d.requestURL(),
+		FileInfo:      d.fileInfo,
+		ServerHeaders: d.ServerHeaders,
+		Chunks:        d.Chunks,
+	}
+	if d.Progress != nil {
+		state.Progress = d.Progress.Snapshot()
+	}
+	return state
+}
+
+// writeDownloaderState encodes and writes a state file.
+//
+// Parameters:
+//   - path: Path of the state file
+//   - state: State to write
+//
+// Returns:
+//   - error: Error if the state cannot be encoded or written
+func writeDownloaderState(path string, state *downloaderState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode download state: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write download state: %w", err)
+	}
+
+	return nil
+}
+
+// LoadDownloaderState creates a downloader from a state file written by SaveState.
+// The returned downloader keeps the saved output path, call StartDownload to resume it.
+//
+// Parameters:
+//   - path: Path of the state file to read
+//
+// Returns:
+//   - *Downloader: Downloader with the saved URL, file info, server headers, chunks and progress
+//   - error: Error if the file cannot be read or decoded
+//
+// Example:
+//
+//	downloader, err := LoadDownloaderState("/home/user/Downloads/video.udmstate")
+//	if err != nil {
+//	    log.Fatal("Failed to load download state:", err)
+//	}
+//	downloader.StartDownload()
+func LoadDownloaderState(path string) (*Downloader, error) {
+	state, err := readDownloaderState(path)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Downloader{
+		Url:      state.URL,
+		Progress: &ProgressTracker{},
+	}
+	d.applyState(state)
+
+	// Download to the same file again
+	d.Prefs.FileName = state.FileInfo.Name
+	d.Prefs.DownloadDir = state.FileInfo.Dir
+
+	return d, nil
+}
+
+// StateFilePath returns the path StartDownload looks at for a saved state.
+//
+// Returns:
+//   - string: Path following the "{name}.udmstate" convention in the output directory
+func (d *Downloader) StateFilePath() string {
+	baseName := ufs.FileNameWithoutExtension(d.fileInfo.Name)
+	return filepath.Join(d.fileInfo.Dir, baseName+STATE_FILE_EXTENSION)
+}
+
+// readDownloaderState reads and decodes a state file.
+//
+// Parameters:
+//   - path: Path of the state file
+//
+// Returns:
+//   - *downloaderState: Decoded state
+//   - error: Error if the file cannot be read or decoded
+func readDownloaderState(path string) (*downloaderState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read download state: %v", err)
+	}
+
+	state := &downloaderState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to decode download state: %v", err)
+	}
+
+	return state, nil
+}
+
+// applyState copies a saved state into the downloader.
+//
+// Parameters:
+//   - state: State read from a state file
+func (d *Downloader) applyState(state *downloaderState) {
+	d.fileInfo = state.FileInfo
+	d.setServerHeaders(state.ServerHeaders)
+	d.Chunks = state.Chunks
+	if d.Progress != nil {
+		d.Progress.RestoreSnapshot(state.Progress)
+	}
+	d.restoredFromState = true
+}
+
+// restoreSavedState resumes from the state file in the download directory, if there is one
+// for the same URL and the file on the server is unchanged. The saved progress is then
+// reconciled against the partial files actually on disk.
+func (d *Downloader) restoreSavedState() {
+	state, err := readDownloaderState(d.StateFilePath())
+	if err != nil {
+		return
+	}
+
+	// Ignore state of another download or of an older version of the file
+	if state.URL != d.requestURL() && state.URL != d.finalURL() {
+		return
+	}
+	if !sameFileVersion(state.ServerHeaders, d.ServerHeaders) {
+		return
+	}
+
+	// Keep the fresh server headers, they are at least as accurate as the saved ones
+	headers := d.ServerHeaders
+	d.applyState(state)
+	d.setServerHeaders(headers)
+
+	d.reconcileRestoredProgress()
+}
+
+// sameFileVersion reports whether saved and fresh server data describe the same version of a file.
+// The validators are compared directly instead of using ServerData.Changed, which is always
+// true in a new process because no conditional request could be sent.
+//
+// Parameters:
+//   - saved: Server data stored in the state file
+//   - fresh: Server data of the current request
+//
+// Returns:
+//   - bool: True if the sizes match and no ETag or Last-Modified present on both sides differs
+func sameFileVersion(saved, fresh ServerData) bool {
+	if saved.Filesize != fresh.Filesize {
+		return false
+	}
+	if saved.ETag != "" && fresh.ETag != "" && saved.ETag != fresh.ETag {
+		return false
+	}
+	if !saved.LastModified.IsZero() && !fresh.LastModified.IsZero() && !saved.LastModified.Equal(fresh.LastModified) {
+		return false
+	}
+	return true
+}
+
+// reconcileRestoredProgress updates the restored progress and chunk completion
+// to match the partial files on disk, which may differ from the saved state.
+func (d *Downloader) reconcileRestoredProgress() {
+	var completed int64
+
+	if len(d.Chunks) == 0 {
+		// Single-stream download, the output file holds everything downloaded so far
+		offset, err := d.detectResumeOffset()
+		if err == nil {
+			completed = offset
+		}
+	} else {
+		chunkFileNames := ufs.GenerateChunkFileNames(d.fileInfo.Name, len(d.Chunks), d.fileInfo.Dir)
+		for i, chunkFileName := range chunkFileNames {
+			var size int64
+			if info, err := os.Stat(chunkFileName); err == nil {
+				size = min(info.Size(), d.Chunks[i].Size)
+			}
+			d.Chunks[i].IsCompleted = size == d.Chunks[i].Size
+			completed += size
+		}
+	}
+
+	d.Progress.mu.Lock()
+	d.Progress.BytesCompleted = completed
+	d.Progress.mu.Unlock()
+}
+
+// removeStateFile deletes the saved state once the download has completed.
+func (d *Downloader) removeStateFile() {
+	os.Remove(d.StateFilePath())
+	d.restoredFromState = false
+}
@@ -0,0 +1,69 @@
+package udm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// DownloadToMemory downloads the file into memory instead of saving it to disk.
+// It is meant for small files like checksums, manifests and config files.
+//
+// Progress tracking, callbacks and the speed limit still apply, like for DownloadToWriter.
+//
+// Parameters:
+//   - ctx: Context for cancellation of the download
+//   - maxBytes: Largest allowed file size, the download fails with ErrResponseTooLarge beyond it
+//
+// Returns:
+//   - []byte: Content of the file
+//   - error: Error if the download fails, ctx.Err() if it was cancelled
+//
+// Example:
+//
+//	downloader := &Downloader{Url: "https://example.com/release/SHA256SUMS"}
+//	data, err := downloader.DownloadToMemory(ctx, 64*1024)
+//	if errors.Is(err, ErrResponseTooLarge) {
+//	    log.Fatal("Checksum file is suspiciously large")
+//	}
+//
+// Notes:
+//   - Always downloads using a single stream, see DownloadToWriter
+//   - Fails before downloading anything if the server reports a size above maxBytes
+func (d *Downloader) DownloadToMemory(ctx context.Context, maxBytes int64) ([]byte, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("maxBytes must be positive, got %d", maxBytes)
+	}
+
+	buffer := &boundedBuffer{max: maxBytes}
+	if err := d.downloadToWriter(ctx, buffer, maxBytes); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// boundedBuffer is a bytes.Buffer that refuses to grow beyond max bytes
+type boundedBuffer struct {
+	bytes.Buffer
+	max int64
+}
+
+// Write appends p to the buffer, or fails with ErrResponseTooLarge if it would exceed max
+func (b *boundedBuffer) Write(p []byte) (int, error) {
+	if int64(b.Len()+len(p)) > b.max {
+		return 0, responseTooLargeError(int64(b.Len()+len(p)), b.max)
+	}
+	return b.Buffer.Write(p)
+}
+
+// responseTooLargeError describes a file larger than the limit of DownloadToMemory.
+//
+// Parameters:
+//   - size: Size of the file, or the bytes received so far if it is unknown
+//   - maxBytes: The limit
+//
+// Returns:
+//   - error: Error wrapping ErrResponseTooLarge
+func responseTooLargeError(size, maxBytes int64) error {
+	return fmt.Errorf("%w: %d bytes, the limit is %d", ErrResponseTooLarge, size, maxBytes)
+}
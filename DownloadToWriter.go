@@ -0,0 +1,214 @@
+package udm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"udl/udm/ratelimit"
+)
+
+// DownloadToWriter downloads the file and writes its bytes to w instead of saving it to disk.
+// This allows piping a download into os.Stdout, a gzip.Writer, a tar.Writer or a network connection.
+//
+// Progress tracking, callbacks, pause/resume using the PauseController and the speed limit
+// still apply. ServerHeaders.Filesize is used for the percentage when the server reports it.
+//
+// Parameters:
+//   - ctx: Context for cancellation of the download
+//   - w: Destination of the downloaded bytes
+//
+// Returns:
+//   - error: Error if the download or a write to w fails, ctx.Err() if it was cancelled
+//
+// Example:
+//
+//	downloader := &Downloader{Url: "https://example.com/data.csv"}
+//	gz := gzip.NewWriter(file)
+//	if err := downloader.DownloadToWriter(ctx, gz); err != nil {
+//	    log.Fatal("Download failed:", err)
+//	}
+//	gz.Close()
+//
+// Notes:
+//   - Always downloads using a single stream, chunks cannot be merged into a stream
+//   - Resuming is not possible, a cancelled download has to start over
+//   - No file or directory is created, so checksum verification and the state file are skipped
+func (d *Downloader) DownloadToWriter(ctx context.Context, w io.Writer) error {
+	return d.downloadToWriter(ctx, w, 0)
+}
+
+// downloadToWriter runs DownloadToWriter, optionally failing downloads larger than maxBytes.
+//
+// Parameters:
+//   - ctx: Context for cancellation of the download
+//   - w: Destination of the downloaded bytes
+//   - maxBytes: Largest allowed file size, 0 for no limit
+//
+// Returns:
+//   - error: Error if the download or a write to w fails, ctx.Err() if it was cancelled
+func (d *Downloader) downloadToWriter(ctx context.Context, w io.Writer, maxBytes int64) error {
+	if err := ValidateURL(d.requestURL()); err != nil {
+		return err
+	}
+
+	d.prepareDone()
+	defer d.markDone()
+
+	d.Error = nil
+	d.ErrorCode = ""
+
+	// Cancel with ctx or StopDownload
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	d.ctx = ctx
+	d.cancelFunc = cancel
+	d.isStopped = false
+
+	d.applyURLCredentials()
+
+	d.resetRetryStats()
+	d.speedLimiter = ratelimit.NewTokenBucketLimiter(d.Prefs.MaxSpeedBps)
+
+	if err := d.initializeDownload(); err != nil {
+		d.handleDownloadError(err)
+		return d.Error
+	}
+	d.startDownloadSpan()
+
+	// Only the file size is needed, no output path is resolved and no directory created
+	if err := d.fetchServerData(); err != nil {
+		d.handleDownloadError(err)
+		return d.Error
+	}
+
+	// Custom headers, cookies, retries and size limits from the config, if loaded
+	if UDMSettings != nil {
+		UDMSettings.ApplySettingsToDownloader(d)
+	}
+
+	if err := d.checkFileSizeLimit(); err != nil {
+		d.handleDownloadError(err)
+		return d.Error
+	}
+
+	// Don't start downloads that are known to exceed the limit
+	if maxBytes > 0 && d.ServerHeaders.Filesize > maxBytes {
+		d.handleDownloadError(responseTooLargeError(d.ServerHeaders.Filesize, maxBytes))
+		return d.Error
+	}
+
+	d.setStatus(DOWNLOAD_IN_PROGRESS)
+	d.TimeStats.StartTime = time.Now()
+	d.logEvent(EVENT_STARTED, "download of %s to a writer", d.requestURL())
+	d.sendWebhook(WEBHOOK_EVENT_START)
+
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnStart != nil {
+		callbacks.OnStart(d)
+	}
+
+	err := d.streamToWriter(ctx, w, maxBytes)
+	if err != nil {
+		if ctx.Err() == context.Canceled {
+			d.handleDownloadStop(ctx.Err())
+			return ctx.Err()
+		}
+
+		d.handleDownloadError(err)
+		return d.Error
+	}
+
+	d.setStatus(DOWNLOAD_COMPLETED)
+	d.TimeStats.EndTime = time.Now()
+	d.TimeStats.Elapsed = d.TimeStats.EndTime.Sub(d.TimeStats.StartTime)
+	d.recordHistory()
+	d.endDownloadSpan(nil)
+	d.logEvent(EVENT_COMPLETED, "written in %s", d.TimeStats.Elapsed)
+	d.sendWebhook(WEBHOOK_EVENT_FINISH)
+
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnFinish != nil {
+		callbacks.OnFinish(d)
+	}
+
+	return nil
+}
+
+// streamToWriter requests the whole file and copies the response body to w.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - w: Destination of the downloaded bytes
+//   - maxBytes: Largest allowed response body, 0 for no limit
+//
+// Returns:
+//   - error: Error if the request, reading or writing fails
+func (d *Downloader) streamToWriter(ctx context.Context, w io.Writer, maxBytes int64) error {
+	client := d.buildHTTPClient()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", d.requestURL(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+
+	for key, value := range d.Headers.Headers {
+		req.Header.Set(key, value)
+	}
+
+	if d.Headers.Cookies != "" {
+		req.Header.Set("Cookie", d.Headers.Cookies)
+	}
+
+	// Make request, retrying on status codes like 429 and 503 and then on the fallback URLs
+	resp, err := d.doWithFallback(req, func(r *http.Request) (*http.Response, error) {
+		return d.doWithStatusRetry(ctx, client, r)
+	}, func(statusCode int) bool {
+		return statusCode == http.StatusOK
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return &NetworkError{Op: "request", Host: req.URL.Host, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &ServerError{StatusCode: resp.StatusCode, URL: d.requestURL()}
+	}
+
+	if maxBytes > 0 && resp.ContentLength > maxBytes {
+		return responseTooLargeError(resp.ContentLength, maxBytes)
+	}
+
+	totalSize := resp.ContentLength
+	if totalSize <= 0 {
+		totalSize = d.ServerHeaders.Filesize
+	}
+
+	d.Progress.mu.Lock()
+	d.Progress.BytesCompleted = 0
+	d.Progress.mu.Unlock()
+
+	// Detect stalled connections
+	body := d.newDeadlineReader(resp.Body)
+	defer body.Close()
+
+	// Read one byte past the limit so a body without Content-Length is detected as too large
+	var reader io.Reader = body
+	if maxBytes > 0 {
+		reader = io.LimitReader(body, maxBytes+1)
+	}
+
+	// No header analysis, elevating to multi-stream is not possible in this mode
+	err = d.downloadWithProgress(ctx, reader, w, totalSize, nil)
+
+	// downloadWithProgress reports write failures as disk errors of the output file
+	var diskErr *DiskError
+	if errors.As(err, &diskErr) && diskErr.Op == "write" {
+		return fmt.Errorf("failed to write to stream: %w", diskErr.Err)
+	}
+	return err
+}
@@ -0,0 +1,40 @@
+package udm
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDownloadToWriterCreatesNoFiles(t *testing.T) {
+	previous := UDMSettings
+	UDMSettings = &Settings{}
+	defer func() { UDMSettings = previous }()
+
+	content := bytes.Repeat([]byte("stream "), 1000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "data.txt", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	downloadDir := filepath.Join(t.TempDir(), "downloads")
+	d := &Downloader{Url: server.URL + "/data.txt"}
+	d.Prefs.DownloadDir = downloadDir
+
+	var buffer bytes.Buffer
+	if err := d.DownloadToWriter(context.Background(), &buffer); err != nil {
+		t.Fatalf("DownloadToWriter failed: %v", err)
+	}
+
+	if !bytes.Equal(buffer.Bytes(), content) {
+		t.Errorf("wrote %d bytes that differ from the %d bytes of the file", buffer.Len(), len(content))
+	}
+	if _, err := os.Stat(downloadDir); !os.IsNotExist(err) {
+		t.Errorf("download directory %s was created", downloadDir)
+	}
+}
@@ -1,6 +1,12 @@
 package udm
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
 	"time"
 )
 
@@ -14,12 +20,12 @@ func (d *Downloader) GetID() string {
 
 // GetURL returns the download URL
 func (d *Downloader) GetURL() string {
-	return d.Url
+	return d.requestURL()
 }
 
 // GetStatus returns the current download status
 func (d *Downloader) GetStatus() string {
-	return d.Status
+	return d.status()
 }
 
 // GetProgressPercent returns the download completion percentage (0-100)
@@ -34,6 +40,24 @@ func (d *Downloader) GetProgressPercent() float64 {
 	return d.Progress.Percentage
 }
 
+// GetProgressSnapshot returns all progress values read at the same moment.
+// Use it instead of calling several getters when the values have to match each other.
+//
+// Returns:
+//   - ProgressSnapshot: Copy of the progress, zero if the download has not started
+//
+// Example:
+//
+//	progress := downloader.GetProgressSnapshot()
+//	fmt.Printf("%d / %d bytes at %.0f B/s\n", progress.BytesCompleted, progress.TotalBytes, progress.SpeedBps)
+func (d *Downloader) GetProgressSnapshot() ProgressSnapshot {
+	if d.Progress == nil {
+		return ProgressSnapshot{}
+	}
+
+	return d.Progress.Snapshot()
+}
+
 // GetDownloadedBytes returns the number of bytes downloaded so far
 func (d *Downloader) GetDownloadedBytes() int64 {
 	if d.Progress == nil {
@@ -46,6 +70,11 @@ func (d *Downloader) GetDownloadedBytes() int64 {
 	return d.Progress.BytesCompleted
 }
 
+// GetDownloadedBytesFormatted returns the downloaded size in a readable format, e.g. "635.20 MB"
+func (d *Downloader) GetDownloadedBytesFormatted() string {
+	return ReadableFileSize(d.GetDownloadedBytes())
+}
+
 // GetFileSize returns the total file size in bytes
 func (d *Downloader) GetFileSize() int64 {
 	if d.ServerHeaders.Filesize > 0 {
@@ -61,6 +90,11 @@ func (d *Downloader) GetFileSize() int64 {
 	return 0
 }
 
+// GetFileSizeFormatted returns the total file size in a readable format, e.g. "1.50 GB"
+func (d *Downloader) GetFileSizeFormatted() string {
+	return ReadableFileSize(d.GetFileSize())
+}
+
 // GetCurrentSpeed returns the current download speed in bytes per second
 func (d *Downloader) GetCurrentSpeed() float64 {
 	if d.Progress == nil {
@@ -82,7 +116,7 @@ func (d *Downloader) GetAverageSpeed() float64 {
 	d.Progress.mu.Lock()
 	defer d.Progress.mu.Unlock()
 
-	return float64(d.Progress.BytesPerSecond)
+	return float64(d.Progress.AverageSpeedBps)
 }
 
 // GetETA returns the estimated time remaining for the download
@@ -188,38 +222,75 @@ func (d *Downloader) GetEndTime() time.Time {
 }
 
 // GetError returns the last error that occurred during download
-func (d *Downloader) GetError() error {
-	return d.Error
+// wrapped in a DownloadError, or nil if the download didn't fail
+func (d *Downloader) GetError() *DownloadError {
+	if d.Error == nil {
+		return nil
+	}
+
+	var downloadErr *DownloadError
+	if errors.As(d.Error, &downloadErr) {
+		return downloadErr
+	}
+	return &DownloadError{Err: d.Error}
 }
 
 // IsCompleted returns true if the download is completed
 func (d *Downloader) IsCompleted() bool {
-	return d.Status == DOWNLOAD_COMPLETED
+	return d.status() == DOWNLOAD_COMPLETED
 }
 
 // IsPaused returns true if the download is paused
 func (d *Downloader) IsPaused() bool {
-	return d.Status == DOWNLOAD_PAUSED
+	return d.status() == DOWNLOAD_PAUSED
 }
 
 // IsInProgress returns true if the download is in progress
 func (d *Downloader) IsInProgress() bool {
-	return d.Status == DOWNLOAD_IN_PROGRESS
+	return d.status() == DOWNLOAD_IN_PROGRESS
 }
 
 // IsFailed returns true if the download has failed
 func (d *Downloader) IsFailed() bool {
-	return d.Status == DOWNLOAD_FAILED
+	return d.status() == DOWNLOAD_FAILED
 }
 
 // IsStopped returns true if the download was stopped/cancelled
 func (d *Downloader) IsStopped() bool {
-	return d.Status == DOWNLOAD_STOPPED
+	return d.status() == DOWNLOAD_STOPPED
 }
 
 // GetThreadCount returns the number of threads used for multi-stream downloads
 func (d *Downloader) GetThreadCount() int {
-	return d.getThreadCount()
+	return d.getOptimalThreadCount()
+}
+
+// GetChunkCount returns the total number of chunks of a multi-stream download
+func (d *Downloader) GetChunkCount() int {
+	unlock := d.lockChunks()
+	defer unlock()
+
+	return len(d.Chunks)
+}
+
+// GetCompletedChunkCount returns the number of chunks that finished downloading
+func (d *Downloader) GetCompletedChunkCount() int {
+	unlock := d.lockChunks()
+	defer unlock()
+
+	completed := 0
+	for _, chunk := range d.Chunks {
+		if chunk.IsCompleted {
+			completed++
+		}
+	}
+	return completed
+}
+
+// GetActiveChunkCount returns the number of chunks currently being downloaded
+// Chunks that are waiting, completed or failed are not counted
+func (d *Downloader) GetActiveChunkCount() int {
+	return int(d.activeChunkCount.Load())
 }
 
 // GetRetryCount returns the maximum number of retries configured
@@ -232,6 +303,42 @@ func (d *Downloader) GetFileType() string {
 	return d.ServerHeaders.Filetype
 }
 
+// GetMimeType returns the MIME type of the file.
+// The type reported by the server is used if available, otherwise it is inferred
+// from the file extension. As a last resort the first 512 bytes of the downloaded
+// file are sniffed, this is only done once the download is completed.
+//
+// Returns:
+//   - string: The MIME type, or an empty string if it cannot be determined
+func (d *Downloader) GetMimeType() string {
+	if d.ServerHeaders.Filetype != "" {
+		return d.ServerHeaders.Filetype
+	}
+
+	if mimeType := mime.TypeByExtension(d.GetFileExtension()); mimeType != "" {
+		return mimeType
+	}
+
+	// Don't read partial files
+	if d.status() != DOWNLOAD_COMPLETED {
+		return ""
+	}
+
+	file, err := os.Open(d.GetFilePath())
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	header := make([]byte, 512)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return ""
+	}
+
+	return http.DetectContentType(header[:n])
+}
+
 // GetFileExtension returns the file extension
 func (d *Downloader) GetFileExtension() string {
 	// Extract extension from filename
@@ -257,8 +364,51 @@ func (d *Downloader) SupportsRangeRequests() bool {
 
 // GetFinalURL returns the final URL after all redirects
 func (d *Downloader) GetFinalURL() string {
-	if d.ServerHeaders.FinalURL != "" {
-		return d.ServerHeaders.FinalURL
+	return d.finalURL()
+}
+
+// GetProgressBar returns a plain-text progress bar of the download for embedding in custom CLIs.
+// See MakeProgressBar for the format.
+//
+// Parameters:
+//   - width: Number of block characters in the bar
+//
+// Returns:
+//   - string: The rendered progress bar, e.g. [████████░░░░░░░░░░░░] 42.3%
+func (d *Downloader) GetProgressBar(width int) string {
+	return MakeProgressBar(d.GetProgressPercent(), width)
+}
+
+// GetProgressDisplay returns a single-line progress summary for scripts and logs, e.g.
+// "ubuntu.iso [████████░░░░░░░░░░░░] 42.3% | 5.82 MB/s | ETA: 02:14 | 635.20 MB / 1.50 GB"
+//
+// Example:
+//
+//	downloader.Callbacks = &Callbacks{
+//		OnProgress: func(d *Downloader) {
+//			fmt.Printf("\r%s", d.GetProgressDisplay())
+//		},
+//	}
+func (d *Downloader) GetProgressDisplay() string {
+	return fmt.Sprintf("%s %s | %s/s | ETA: %s | %s / %s",
+		d.GetFilename(),
+		d.GetProgressBar(20),
+		ReadableFileSize(int64(d.GetCurrentSpeed())),
+		formatETA(d.GetETA()),
+		d.GetDownloadedBytesFormatted(),
+		d.GetFileSizeFormatted(),
+	)
+}
+
+// formatETA formats a duration as mm:ss, or hh:mm:ss when it is an hour or longer
+func formatETA(eta time.Duration) string {
+	seconds := int64(eta.Seconds())
+	if seconds < 0 {
+		seconds = 0
+	}
+
+	if seconds >= 3600 {
+		return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, (seconds%3600)/60, seconds%60)
 	}
-	return d.Url
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
 }
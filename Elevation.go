@@ -0,0 +1,94 @@
+package udm
+
+import (
+	"errors"
+	"os"
+)
+
+// errElevateToMultiStream stops a single-stream download so it can continue as a multi-stream download
+var errElevateToMultiStream = errors.New("elevating to multi-stream download")
+
+// elevateToMultiStream continues a single-stream download as a multi-stream download.
+// The bytes already downloaded are kept as the first chunk, so only the rest
+// of the file is divided between the chunk workers.
+//
+// Parameters:
+//   - offset: Number of bytes already written to the output file
+func (d *Downloader) elevateToMultiStream(offset int64) {
+	d.hasElevated = true
+
+	// Move the partial file out of the way, it becomes the first chunk once the chunk files exist
+	partialPath := d.fileInfo.FullPath + ".udtemp"
+	if offset > 0 {
+		if err := os.Rename(d.incompletePath(), partialPath); err != nil {
+			logf("Failed to keep downloaded bytes, restarting as multi-stream: %v\n", err)
+			offset = 0
+		}
+	}
+	if offset == 0 {
+		os.Remove(d.incompletePath())
+	}
+
+	d.elevationOffset = offset
+	d.elevationPartialPath = partialPath
+
+	d.logEvent(EVENT_ELEVATED, "continuing as multi-stream from byte %d", offset)
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnElevated != nil {
+		callbacks.OnElevated(d, offset)
+	}
+
+	d.DownloadMultiStream()
+}
+
+// divideChunksFromOffset divides the file into chunks, keeping the bytes downloaded
+// before an elevation to multi-stream as the first chunk.
+//
+// Parameters:
+//   - chunkCount: The number of chunks to divide the file into
+//
+// Returns:
+//   - []int64: Chunk sizes in bytes
+func (d *Downloader) divideChunksFromOffset(chunkCount int) []int64 {
+	if d.elevationOffset <= 0 {
+		return DivideChunks(d.ServerHeaders.Filesize, chunkCount)
+	}
+
+	remaining := DivideChunks(d.ServerHeaders.Filesize-d.elevationOffset, max(chunkCount-1, 1))
+	return append([]int64{d.elevationOffset}, remaining...)
+}
+
+// placeElevatedChunk moves the bytes downloaded before an elevation into the first chunk file.
+//
+// Parameters:
+//   - chunkFile: Path of the first chunk file
+//
+// Returns:
+//   - error: Error if the file could not be moved
+func (d *Downloader) placeElevatedChunk(chunkFile string) error {
+	if d.elevationOffset <= 0 {
+		return nil
+	}
+
+	if err := os.Rename(d.elevationPartialPath, chunkFile); err != nil {
+		return &DiskError{Op: "rename", Path: d.elevationPartialPath, Err: err}
+	}
+
+	d.elevationOffset = 0
+	d.elevationPartialPath = ""
+	return nil
+}
+
+// restoreElevatedChunk moves the bytes downloaded before an elevation back to the
+// output file, so a single-stream download can resume from them.
+func (d *Downloader) restoreElevatedChunk() {
+	if d.elevationOffset <= 0 {
+		return
+	}
+
+	if err := os.Rename(d.elevationPartialPath, d.incompletePath()); err != nil {
+		os.Remove(d.elevationPartialPath)
+	}
+
+	d.elevationOffset = 0
+	d.elevationPartialPath = ""
+}
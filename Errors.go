@@ -0,0 +1,306 @@
+package udm
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// NetworkError is returned when a request fails before a response is received
+// like DNS failures, refused connections or timeouts
+type NetworkError struct {
+	Op   string // Operation that failed, e.g. "request" or "read"
+	Host string // Host that was being contacted
+	Err  error  // Underlying error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("network error during %s to %s: %v", e.Op, e.Host, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// DiskError is returned when reading or writing a local file fails
+type DiskError struct {
+	Op   string // Operation that failed, e.g. "write" or "open"
+	Path string // Path of the file involved
+	Err  error  // Underlying error
+}
+
+func (e *DiskError) Error() string {
+	return fmt.Sprintf("disk error during %s of %s: %v", e.Op, e.Path, e.Err)
+}
+
+func (e *DiskError) Unwrap() error {
+	return e.Err
+}
+
+// ServerError is returned when the server responds with an unexpected status code
+type ServerError struct {
+	StatusCode int    // HTTP status code returned by the server
+	URL        string // URL that was requested
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// StatusText returns the standard text for the status code, e.g. "Not Found"
+func (e *ServerError) StatusText() string {
+	return http.StatusText(e.StatusCode)
+}
+
+// ChecksumError is returned when the downloaded file doesn't match the expected checksum
+type ChecksumError struct {
+	Algo     string // Checksum algorithm used
+	Expected string // Expected hex digest
+	Path     string // Path of the verified file
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("%s checksum mismatch for %s", e.Algo, e.Path)
+}
+
+// FileSizeLimitError is returned when the file is larger than the configured limit
+type FileSizeLimitError struct {
+	Size  int64 // File size reported by the server
+	Limit int64 // Effective file size limit
+}
+
+func (e *FileSizeLimitError) Error() string {
+	return fmt.Sprintf("file size %d bytes exceeds the limit of %d bytes", e.Size, e.Limit)
+}
+
+// TLSVersionError is returned when the server doesn't support the minimum TLS version
+// configured with UserPreferences.TLSMinVersion
+type TLSVersionError struct {
+	Host       string // Host that was being contacted
+	MinVersion uint16 // Minimum TLS version required
+	Err        error  // Underlying TLS error
+}
+
+func (e *TLSVersionError) Error() string {
+	return fmt.Sprintf("server %s does not support %s or newer: %v", e.Host, tls.VersionName(e.MinVersion), e.Err)
+}
+
+func (e *TLSVersionError) Unwrap() error {
+	return e.Err
+}
+
+// ContentChangedError is returned when a URL serves different content across requests
+type ContentChangedError struct {
+	URL    string // URL that was checked
+	Header string // Header that differed, e.g. "ETag"
+	First  string // Value of the header in the first response
+	Second string // Value of the header in the second response
+}
+
+func (e *ContentChangedError) Error() string {
+	return fmt.Sprintf("content of %s changed between requests: %s %q != %q", e.URL, e.Header, e.First, e.Second)
+}
+
+// HashMismatchError is returned when the SHA-256 of the downloaded file differs from Downloader.ExpectedSHA256
+type HashMismatchError struct {
+	Expected string // Expected hex digest
+	Got      string // Hex digest of the downloaded file
+}
+
+func (e *HashMismatchError) Error() string {
+	return fmt.Sprintf("sha256 mismatch: expected %s, got %s", e.Expected, e.Got)
+}
+
+// InsufficientDiskSpaceError is returned before downloading when the target filesystem is too full for the file
+type InsufficientDiskSpaceError struct {
+	Required  int64 // Bytes needed for the download
+	Available int64 // Bytes free on the filesystem
+}
+
+func (e *InsufficientDiskSpaceError) Error() string {
+	return fmt.Sprintf("insufficient disk space: %d bytes required, %d bytes available", e.Required, e.Available)
+}
+
+// ErrInsufficientDiskSpace is the previous name of InsufficientDiskSpaceError
+//
+// Deprecated: Use InsufficientDiskSpaceError instead.
+type ErrInsufficientDiskSpace = InsufficientDiskSpaceError
+
+// IncompleteDownloadError is returned when the server closes the connection before all bytes were received
+type IncompleteDownloadError struct {
+	Expected int64 // Bytes the response should have contained
+	Got      int64 // Bytes actually received
+}
+
+func (e *IncompleteDownloadError) Error() string {
+	return fmt.Sprintf("incomplete download: expected %d bytes, got %d", e.Expected, e.Got)
+}
+
+// URL validation errors returned by ValidateURL, use errors.Is to check for them
+var (
+	ErrEmptyURL      = errors.New("no download URL provided")
+	ErrInvalidScheme = errors.New("URL scheme must be http or https")
+	ErrMissingHost   = errors.New("URL has no host")
+	ErrInvalidHost   = errors.New("URL host contains invalid characters")
+	ErrURLTooLong    = errors.New("URL path is too long")
+)
+
+// ErrTooManyRedirects is returned when a request is redirected more than UserPreferences.MaxRedirects times
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// ErrResponseTooLarge is returned by DownloadToMemory when the file is larger than maxBytes
+var ErrResponseTooLarge = errors.New("response is larger than the memory limit")
+
+// InvalidTransitionError is returned by SetStatus when the status change is not allowed
+type InvalidTransitionError struct {
+	From string // Current status
+	To   string // Requested status
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("invalid status transition from %q to %q", e.From, e.To)
+}
+
+// ErrInvalidStatusTransition is another name of InvalidTransitionError, both can be used with errors.As
+type ErrInvalidStatusTransition = InvalidTransitionError
+
+// DownloadError wraps the error that made a download fail so callers can decide how to react.
+// It is stored in Downloader.Error and returned by GetError.
+//
+// Example:
+//
+//	if err := downloader.GetError(); err != nil {
+//		if err.IsTransient() {
+//			go downloader.Clone().StartDownload() // Try again later
+//		} else {
+//			fmt.Println("Giving up:", ReadableError(err))
+//		}
+//	}
+type DownloadError struct {
+	Err error // The original error
+}
+
+func (e *DownloadError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DownloadError) Unwrap() error {
+	return e.Err
+}
+
+// IsTransient reports whether the failure is likely temporary and the download can be retried,
+// like network failures, timeouts, rate limiting and 5xx server errors
+func (e *DownloadError) IsTransient() bool {
+	var networkErr *NetworkError
+	if errors.As(e.Err, &networkErr) || errors.Is(e.Err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var serverErr *ServerError
+	if errors.As(e.Err, &serverErr) {
+		return serverErr.StatusCode == http.StatusTooManyRequests || serverErr.StatusCode >= 500
+	}
+	return false
+}
+
+// IsServerError reports whether the server responded with a 5xx status code
+func (e *DownloadError) IsServerError() bool {
+	var serverErr *ServerError
+	return errors.As(e.Err, &serverErr) && serverErr.StatusCode >= 500
+}
+
+// IsClientError reports whether the server responded with a 4xx status code,
+// e.g. the URL is wrong or access is denied
+func (e *DownloadError) IsClientError() bool {
+	var serverErr *ServerError
+	return errors.As(e.Err, &serverErr) && serverErr.StatusCode >= 400 && serverErr.StatusCode < 500
+}
+
+// IsDiskError reports whether the failure happened while reading or writing local files
+func (e *DownloadError) IsDiskError() bool {
+	var diskErr *DiskError
+	return errors.As(e.Err, &diskErr)
+}
+
+// Error codes stored in Downloader.ErrorCode when a download fails
+const (
+	ERROR_CODE_NETWORK                 = "network"
+	ERROR_CODE_SERVER                  = "server"
+	ERROR_CODE_DISK                    = "disk"
+	ERROR_CODE_INSUFFICIENT_DISK_SPACE = "insufficient_disk_space"
+	ERROR_CODE_INCOMPLETE              = "incomplete"
+	ERROR_CODE_CHECKSUM_MISMATCH       = "checksum_mismatch"
+	ERROR_CODE_HASH_MISMATCH           = "hash_mismatch"
+	ERROR_CODE_FILE_SIZE_LIMIT         = "file_size_limit"
+	ERROR_CODE_TLS_VERSION             = "tls_version"
+	ERROR_CODE_CONTENT_CHANGED         = "content_changed"
+	ERROR_CODE_INVALID_URL             = "invalid_url"
+	ERROR_CODE_UNKNOWN                 = "unknown"
+)
+
+// errorCode returns the error code for the most specific typed error in the wrap chain.
+//
+// Parameters:
+//   - err: The error that made the download fail
+//
+// Returns:
+//   - string: One of the ERROR_CODE_* constants
+func errorCode(err error) string {
+	var (
+		tlsErr        *TLSVersionError
+		spaceErr      *InsufficientDiskSpaceError
+		hashErr       *HashMismatchError
+		checksumErr   *ChecksumError
+		incompleteErr *IncompleteDownloadError
+		sizeErr       *FileSizeLimitError
+		changedErr    *ContentChangedError
+		serverErr     *ServerError
+		diskErr       *DiskError
+		networkErr    *NetworkError
+	)
+
+	// TLS and incomplete download errors are usually wrapped in a NetworkError, so check them first
+	switch {
+	case errors.As(err, &tlsErr):
+		return ERROR_CODE_TLS_VERSION
+	case errors.As(err, &spaceErr):
+		return ERROR_CODE_INSUFFICIENT_DISK_SPACE
+	case errors.As(err, &hashErr):
+		return ERROR_CODE_HASH_MISMATCH
+	case errors.As(err, &checksumErr):
+		return ERROR_CODE_CHECKSUM_MISMATCH
+	case errors.As(err, &incompleteErr):
+		return ERROR_CODE_INCOMPLETE
+	case errors.As(err, &sizeErr):
+		return ERROR_CODE_FILE_SIZE_LIMIT
+	case errors.As(err, &changedErr):
+		return ERROR_CODE_CONTENT_CHANGED
+	case errors.Is(err, ErrEmptyURL), errors.Is(err, ErrInvalidScheme), errors.Is(err, ErrMissingHost),
+		errors.Is(err, ErrInvalidHost), errors.Is(err, ErrURLTooLong):
+		return ERROR_CODE_INVALID_URL
+	case errors.As(err, &serverErr):
+		return ERROR_CODE_SERVER
+	case errors.As(err, &diskErr):
+		return ERROR_CODE_DISK
+	case errors.As(err, &networkErr):
+		return ERROR_CODE_NETWORK
+	default:
+		return ERROR_CODE_UNKNOWN
+	}
+}
+
+// requestHost returns the host of the download URL for error reporting.
+//
+// Returns:
+//   - string: Host name, or the raw URL if it cannot be parsed
+func (d *Downloader) requestHost() string {
+	requestURL := d.requestURL()
+	parsed, err := url.Parse(requestURL)
+	if err != nil || parsed.Host == "" {
+		return requestURL
+	}
+	return parsed.Host
+}
@@ -0,0 +1,87 @@
+package udm
+
+import (
+	"fmt"
+	"time"
+)
+
+// MAX_EVENT_LOG_SIZE is the number of events kept per download, older events are dropped first
+const MAX_EVENT_LOG_SIZE = 1000
+
+// Types of DownloadEvent
+const (
+	EVENT_STARTED          = "started"
+	EVENT_PAUSED           = "paused"
+	EVENT_RESUMED          = "resumed"
+	EVENT_STOPPED          = "stopped"
+	EVENT_COMPLETED        = "completed"
+	EVENT_ERROR            = "error"
+	EVENT_RETRY            = "retry"
+	EVENT_CHUNK_STARTED    = "chunk_started"
+	EVENT_CHUNK_COMPLETED  = "chunk_completed"
+	EVENT_CHUNK_FAILED     = "chunk_failed"
+	EVENT_ASSEMBLE_STARTED = "assemble_started"
+	EVENT_ASSEMBLE_DONE    = "assemble_done"
+	EVENT_ELEVATED         = "elevated"
+	EVENT_URL_FALLBACK     = "url_fallback"
+	EVENT_HASH_MISMATCH    = "hash_mismatch"
+	EVENT_TYPE_MISMATCH    = "type_mismatch"
+)
+
+// DownloadEvent is an entry of the event log of a download, see Downloader.EventLog
+type DownloadEvent struct {
+	Time   time.Time `json:"time"`
+	Type   string    `json:"type"`   // One of the EVENT_* constants
+	Detail string    `json:"detail"` // Human readable details, e.g. the chunk range or error
+}
+
+// logEvent appends an event to the event log of the download.
+//
+// Parameters:
+//   - eventType: One of the EVENT_* constants
+//   - format: fmt format of the event details
+//   - args: Arguments for format
+func (d *Downloader) logEvent(eventType string, format string, args ...any) {
+	event := DownloadEvent{
+		Time:   time.Now(),
+		Type:   eventType,
+		Detail: fmt.Sprintf(format, args...),
+	}
+
+	d.eventMu.Lock()
+	defer d.eventMu.Unlock()
+
+	if len(d.events) >= MAX_EVENT_LOG_SIZE {
+		d.events = append(d.events[:0], d.events[len(d.events)-MAX_EVENT_LOG_SIZE+1:]...)
+	}
+	d.events = append(d.events, event)
+}
+
+// EventLog returns the events of the download in the order they happened.
+// It records the lifecycle of the download without any callbacks, to find out
+// what happened when a download misbehaves.
+//
+// Returns:
+//   - []DownloadEvent: Copy of the event log, at most MAX_EVENT_LOG_SIZE of the latest events
+//
+// Example:
+//
+//	for _, event := range d.EventLog() {
+//	    fmt.Printf("%s %-16s %s\n", event.Time.Format("15:04:05.000"), event.Type, event.Detail)
+//	}
+func (d *Downloader) EventLog() []DownloadEvent {
+	d.eventMu.Lock()
+	defer d.eventMu.Unlock()
+
+	events := make([]DownloadEvent, len(d.events))
+	copy(events, d.events)
+	return events
+}
+
+// ClearEventLog removes all events from the event log of the download.
+func (d *Downloader) ClearEventLog() {
+	d.eventMu.Lock()
+	defer d.eventMu.Unlock()
+
+	d.events = nil
+}
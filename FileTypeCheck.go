@@ -0,0 +1,92 @@
+package udm
+
+import (
+	"mime"
+	"strings"
+
+	"udl/udm/ufs"
+)
+
+// checkFileType compares the format of the downloaded file with the Content-Type sent by
+// the server and fires OnTypeMismatch if they disagree, e.g. a captive portal serving an
+// HTML page or a ZIP served as video/mp4. The download is not failed.
+func (d *Downloader) checkFileType() {
+	serverType := normalizeMimeType(d.ServerHeaders.Filetype)
+	if serverType == "" || serverType == ufs.UNKNOWN_FILE_TYPE || serverType == "binary/octet-stream" {
+		return
+	}
+
+	detectedType, err := ufs.DetectFileType(d.fileInfo.FullPath)
+	if err != nil || detectedType == ufs.UNKNOWN_FILE_TYPE {
+		return
+	}
+
+	if fileTypesCompatible(serverType, detectedType) {
+		return
+	}
+
+	logf("Warning: server reported %s but the file looks like %s\n", serverType, detectedType)
+	d.logEvent(EVENT_TYPE_MISMATCH, "server reported %s, detected %s", serverType, detectedType)
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnTypeMismatch != nil {
+		callbacks.OnTypeMismatch(d, serverType, detectedType)
+	}
+}
+
+// normalizeMimeType removes parameters like charset from a Content-Type and lowercases it.
+//
+// Parameters:
+//   - contentType: Value of a Content-Type header
+//
+// Returns:
+//   - string: The bare MIME type, e.g. "text/html"
+func normalizeMimeType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, _, _ = strings.Cut(contentType, ";")
+	}
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// fileTypesCompatible reports whether the detected format can be what the server announced.
+// Aliases like audio/mp3 and audio/mpeg, and container formats like ZIP based
+// office documents, are not a meaningful mismatch.
+//
+// Parameters:
+//   - serverType: Normalized Content-Type sent by the server
+//   - detectedType: MIME type returned by ufs.DetectFileType
+//
+// Returns:
+//   - bool: True if the types agree
+func fileTypesCompatible(serverType, detectedType string) bool {
+	if serverType == detectedType {
+		return true
+	}
+
+	serverTop, serverSub, _ := strings.Cut(serverType, "/")
+	detectedTop, _, _ := strings.Cut(detectedType, "/")
+
+	// Different subtypes of media, e.g. video/webm detected as video/x-matroska
+	if serverTop == detectedTop && serverTop != "application" {
+		return true
+	}
+
+	switch detectedType {
+	case "application/zip":
+		// DOCX, XLSX, JAR, APK, EPUB and other ZIP containers
+		return strings.Contains(serverSub, "zip") || strings.HasPrefix(serverSub, "vnd.") || strings.Contains(serverSub, "java-archive")
+	case "application/gzip":
+		return strings.Contains(serverSub, "gzip") || strings.Contains(serverSub, "tar") || strings.Contains(serverSub, "compressed")
+	case "application/x-ole-storage":
+		// DOC, XLS, PPT and MSI files
+		return strings.HasPrefix(serverSub, "msword") || strings.HasPrefix(serverSub, "vnd.ms-") || strings.Contains(serverSub, "msi")
+	case "video/mp4", "video/quicktime":
+		// M4A audio uses the same container
+		return serverTop == "audio"
+	case "audio/ogg":
+		return strings.Contains(serverSub, "ogg")
+	case "application/vnd.microsoft.portable-executable":
+		return strings.Contains(serverSub, "msdownload") || strings.Contains(serverSub, "dosexec") || strings.Contains(serverSub, "executable")
+	}
+
+	return false
+}
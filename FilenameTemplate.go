@@ -0,0 +1,102 @@
+package udm
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DEFAULT_FILENAME_TEMPLATE is used when Settings.DefaultFilenameTemplate is empty
+const DEFAULT_FILENAME_TEMPLATE = "download_{date}_{id}"
+
+// DEFAULT_DATE_FORMAT is used for {date} when Settings.DateFormat is empty
+const DEFAULT_DATE_FORMAT = "2006-01-02"
+
+// renderFilenameTemplate substitutes template variables with values of the download.
+//
+// Supported variables:
+//   - {title}: Server filename without extension (empty if the server provided none)
+//   - {ext}:   Extension of the server filename with the dot, e.g. ".zip"
+//   - {date}:  Current date formatted with Settings.DateFormat
+//   - {time}:  Current time as HHMMSS
+//   - {size}:  Readable file size, e.g. "1.50 MB" (empty if unknown)
+//   - {id}:    Downloader ID
+//
+// Parameters:
+//   - template: Filename template, e.g. "download_{date}_{id}"
+//   - d: Downloader providing the variable values
+//
+// Returns:
+//   - string: Rendered filename, separators left dangling by empty variables are trimmed
+//
+// Example:
+//
+//	name := renderFilenameTemplate("download_{date}_{id}", d)
+//	// Result: "download_2025-07-31_abc123"
+func renderFilenameTemplate(template string, d *Downloader) string {
+	dateFormat := DEFAULT_DATE_FORMAT
+	if UDMSettings != nil && UDMSettings.DateFormat != "" {
+		dateFormat = UDMSettings.DateFormat
+	}
+
+	ext := filepath.Ext(d.ServerHeaders.Filename)
+	title := ""
+	if !d.ServerHeaders.IsFallbackName {
+		title = strings.TrimSuffix(d.ServerHeaders.Filename, ext)
+	}
+
+	size := ""
+	if d.ServerHeaders.Filesize > 0 {
+		size = ReadableFileSize(d.ServerHeaders.Filesize)
+	}
+
+	now := time.Now()
+	replacer := strings.NewReplacer(
+		"{title}", title,
+		"{ext}", ext,
+		"{date}", now.Format(dateFormat),
+		"{time}", now.Format("150405"),
+		"{size}", size,
+		"{id}", d.ID,
+	)
+	rendered := replacer.Replace(template)
+
+	// Remove separators left behind by empty variables, e.g. "download_2025-07-31_"
+	rendered = strings.ReplaceAll(rendered, "__", "_")
+	return strings.Trim(rendered, "_- ")
+}
+
+// defaultFilename returns the filename used when neither the user nor the server provide one.
+// The configured filename template is rendered first, with the MIME type extension appended.
+//
+// Returns:
+//   - string: Generated filename, "downloaded_file" plus extension as the last fallback
+func (d *Downloader) defaultFilename() string {
+	template := DEFAULT_FILENAME_TEMPLATE
+	if UDMSettings != nil {
+		template = UDMSettings.GetDefaultFilenameTemplate()
+	}
+
+	filename := renderFilenameTemplate(template, d)
+	if filename == "" {
+		filename = "downloaded_file"
+	}
+
+	// Add extension from MIME type if available
+	if d.ServerHeaders.Filetype != "" {
+		filename += mimeExtensionFromContentType(d.ServerHeaders.Filetype)
+	}
+
+	return filename
+}
+
+// templateFilename returns the filename rendered from Prefs.FileNameTemplate.
+//
+// Returns:
+//   - string: Rendered filename, empty if no template is set or it rendered to nothing
+func (d *Downloader) templateFilename() string {
+	if d.Prefs.FileNameTemplate == "" {
+		return ""
+	}
+	return renderFilenameTemplate(d.Prefs.FileNameTemplate, d)
+}
@@ -0,0 +1,136 @@
+package udm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// contentValidators holds the response headers that identify a version of a file
+type contentValidators struct {
+	ETag          string
+	LastModified  string
+	ContentLength string
+}
+
+// FingerprintURL checks that a URL serves the same content across requests and
+// returns a fingerprint of it. Round-robin CDNs and A/B testing systems can serve
+// different versions of a file for the same URL, which corrupts multi-stream
+// downloads as chunks may come from different versions.
+//
+// Working:
+//   - Two HEAD requests are made one second apart
+//   - The ETag, Last-Modified and Content-Length headers of both responses are compared
+//   - If any of them differ a *ContentChangedError is returned
+//
+// Parameters:
+//   - url: The URL to check
+//
+// Returns:
+//   - string: Hex encoded SHA-256 fingerprint of the content validators
+//   - error: *ContentChangedError if the content differs, or the request error
+//
+// Example:
+//
+//	fingerprint, err := FingerprintURL("https://example.com/file.zip")
+//	var changedErr *ContentChangedError
+//	if errors.As(err, &changedErr) {
+//		fmt.Println("Server is serving different content:", changedErr.Header)
+//		return
+//	}
+//	fmt.Println("Fingerprint:", fingerprint)
+func FingerprintURL(url string) (fingerprint string, err error) {
+	return fingerprintWithClient(makeHTTPClient(nil), url, CustomHeaders{})
+}
+
+// fingerprintURL runs FingerprintURL for the download URL using the downloader's client,
+// so its proxy, TLS, authentication and custom header settings apply to the requests.
+//
+// Returns:
+//   - string: Hex encoded SHA-256 fingerprint of the content validators
+//   - error: *ContentChangedError if the content differs, or the request error
+func (d *Downloader) fingerprintURL() (string, error) {
+	return fingerprintWithClient(d.buildHTTPClient(), d.GetFinalURL(), d.Headers)
+}
+
+// fingerprintWithClient makes the two HEAD requests of FingerprintURL and compares them.
+//
+// Parameters:
+//   - client: HTTP client used for the requests
+//   - url: The URL to check
+//   - headers: Custom headers and cookies sent with the requests
+//
+// Returns:
+//   - string: Hex encoded SHA-256 fingerprint of the content validators
+//   - error: *ContentChangedError if the content differs, or the request error
+func fingerprintWithClient(client *http.Client, url string, headers CustomHeaders) (string, error) {
+	client.Timeout = 15 * time.Second
+
+	first, err := fetchContentValidators(client, url, headers)
+	if err != nil {
+		return "", err
+	}
+
+	time.Sleep(1 * time.Second)
+
+	second, err := fetchContentValidators(client, url, headers)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case first.ETag != second.ETag:
+		return "", &ContentChangedError{URL: url, Header: "ETag", First: first.ETag, Second: second.ETag}
+	case first.LastModified != second.LastModified:
+		return "", &ContentChangedError{URL: url, Header: "Last-Modified", First: first.LastModified, Second: second.LastModified}
+	case first.ContentLength != second.ContentLength:
+		return "", &ContentChangedError{URL: url, Header: "Content-Length", First: first.ContentLength, Second: second.ContentLength}
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{first.ETag, first.LastModified, first.ContentLength}, "\n")))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// fetchContentValidators makes a HEAD request and returns the content validators of the response.
+//
+// Parameters:
+//   - client: HTTP client used for the request
+//   - url: The URL to request
+//   - headers: Custom headers and cookies sent with the request
+//
+// Returns:
+//   - contentValidators: The validator headers of the response
+//   - error: Error if the request failed or the server returned an error status
+func fetchContentValidators(client *http.Client, url string, headers CustomHeaders) (contentValidators, error) {
+	req, err := http.NewRequest("HEAD", url, nil)
+	if err != nil {
+		return contentValidators{}, err
+	}
+
+	// Add custom headers
+	for key, value := range headers.Headers {
+		req.Header.Set(key, value)
+	}
+
+	if headers.Cookies != "" {
+		req.Header.Set("Cookie", headers.Cookies)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return contentValidators{}, &NetworkError{Op: "fingerprint", Host: req.URL.Host, Err: err}
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return contentValidators{}, &ServerError{StatusCode: resp.StatusCode, URL: url}
+	}
+
+	return contentValidators{
+		ETag:          resp.Header.Get("ETag"),
+		LastModified:  resp.Header.Get("Last-Modified"),
+		ContentLength: resp.Header.Get("Content-Length"),
+	}, nil
+}
@@ -0,0 +1,30 @@
+package udm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFingerprintURLUsesDownloaderSettings(t *testing.T) {
+	if testing.Short() {
+		t.Skip("fingerprinting waits a second between requests")
+	}
+
+	// The server only answers requests carrying the downloader's custom header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+	}))
+	defer server.Close()
+
+	d := &Downloader{Url: server.URL}
+	d.Headers.Headers = map[string]string{"X-Token": "secret"}
+
+	if _, err := d.fingerprintURL(); err != nil {
+		t.Fatalf("fingerprintURL() error = %v", err)
+	}
+}
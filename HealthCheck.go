@@ -0,0 +1,150 @@
+package udm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// keepAlivePing periodically checks that the server is still reachable during a download.
+// Long downloads can lose their TCP connections silently while no error is reported,
+// when a ping cannot reach the server all in-flight chunk requests are cancelled so they
+// are retried with fresh connections. Error statuses don't reset the connections, the
+// server answered after all. Nothing is done if UserPreferences.PingIntervalSeconds is not set.
+//
+// Parameters:
+//   - ctx: Context for cancellation, the ping stops when it is done
+func (d *Downloader) keepAlivePing(ctx context.Context) {
+	if d.Prefs.PingIntervalSeconds <= 0 {
+		return
+	}
+
+	// Reuse one client for all pings
+	client := d.buildHTTPClient()
+	defer client.CloseIdleConnections()
+
+	ticker := time.NewTicker(time.Duration(d.Prefs.PingIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := d.pingServer(ctx, client)
+			if err == nil || ctx.Err() != nil {
+				continue
+			}
+
+			// The server answered with an error status, so the connections still work
+			var networkErr *NetworkError
+			if !errors.As(err, &networkErr) {
+				continue
+			}
+
+			logf("Health check failed, reconnecting chunks: %v\n", err)
+			d.resetConnections()
+		}
+	}
+}
+
+// pingServer makes a zero-byte HEAD request to the download URL. Servers that reject
+// HEAD requests with a 4xx status, like S3 presigned GET URLs, are pinged with a GET
+// request for the first byte instead, as done by getServerData.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - client: HTTP client shared by all pings of the download
+//
+// Returns:
+//   - error: *NetworkError if the server could not be reached, *ServerError if it responded with an error status
+func (d *Downloader) pingServer(ctx context.Context, client *http.Client) error {
+	pingCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	statusCode, err := d.sendPing(pingCtx, client, "HEAD")
+	if err == nil && statusCode >= 400 && statusCode < 500 {
+		statusCode, err = d.sendPing(pingCtx, client, "GET")
+	}
+	if err != nil {
+		return err
+	}
+
+	if statusCode >= 400 {
+		return &ServerError{StatusCode: statusCode, URL: d.requestURL()}
+	}
+
+	return nil
+}
+
+// sendPing sends a request for the first byte of the download URL.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - client: HTTP client used for the request
+//   - method: HEAD or GET
+//
+// Returns:
+//   - int: Status code of the response
+//   - error: *NetworkError if no response was received
+func (d *Downloader) sendPing(ctx context.Context, client *http.Client, method string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, d.requestURL(), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	// Add custom headers
+	for key, value := range d.Headers.Headers {
+		req.Header.Set(key, value)
+	}
+
+	if d.Headers.Cookies != "" {
+		req.Header.Set("Cookie", d.Headers.Cookies)
+	}
+
+	req.Header.Set("Range", "bytes=0-0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, &NetworkError{Op: "ping", Host: req.URL.Host, Err: err}
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+// connectionContext returns the context for the current connection generation.
+// Chunk requests use it so a failed health check can cancel them all at once.
+//
+// Parameters:
+//   - parent: Download context the connection context is derived from
+//
+// Returns:
+//   - context.Context: Context cancelled by resetConnections or when parent is done
+func (d *Downloader) connectionContext(parent context.Context) context.Context {
+	d.connMu.Lock()
+	defer d.connMu.Unlock()
+
+	if d.connCtx == nil || d.connCtx.Err() != nil {
+		d.connCtx, d.connCancel = context.WithCancel(parent)
+	}
+
+	return d.connCtx
+}
+
+// resetConnections cancels all requests of the current connection generation.
+// The next call to connectionContext starts a new generation.
+func (d *Downloader) resetConnections() {
+	d.connMu.Lock()
+	defer d.connMu.Unlock()
+
+	if d.connCancel != nil {
+		d.connCancel()
+	}
+
+	d.connCtx = nil
+	d.connCancel = nil
+}
@@ -0,0 +1,69 @@
+package udm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPingServer(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     http.HandlerFunc
+		wantNetwork bool
+		wantErr     bool
+	}{
+		{
+			name:    "head answered",
+			handler: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusPartialContent) },
+		},
+		{
+			name: "head rejected like a presigned URL",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodHead {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				w.WriteHeader(http.StatusPartialContent)
+			},
+		},
+		{
+			name:    "error status",
+			handler: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusMethodNotAllowed) },
+			wantErr: true,
+		},
+		{
+			name: "connection dropped",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+			},
+			wantErr:     true,
+			wantNetwork: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			d := &Downloader{Url: server.URL}
+			client := d.buildHTTPClient()
+			defer client.CloseIdleConnections()
+
+			err := d.pingServer(context.Background(), client)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("pingServer() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			// Only unreachable servers make the chunks reconnect
+			var networkErr *NetworkError
+			if got := errors.As(err, &networkErr); got != tt.wantNetwork {
+				t.Errorf("pingServer() error %v is a NetworkError: %v, want %v", err, got, tt.wantNetwork)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package udm
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// DEFAULT_IDLE_TIMEOUT_SEC is the idle timeout used when UserPreferences.IdleTimeoutSec is not set
+const DEFAULT_IDLE_TIMEOUT_SEC = 60
+
+// idleTimeoutError is returned by deadlineReader when no bytes arrived in time.
+// It implements net.Error so it is reported as a timeout.
+type idleTimeoutError struct {
+	timeout time.Duration
+}
+
+func (e *idleTimeoutError) Error() string {
+	return "no data received for " + e.timeout.String()
+}
+
+func (e *idleTimeoutError) Timeout() bool   { return true }
+func (e *idleTimeoutError) Temporary() bool { return true }
+
+// deadlineReader fails a Read that receives no bytes within the timeout, so a stalled
+// connection is detected and the request can be retried from the current offset.
+// Bodies supporting SetReadDeadline use it, others are closed by a timer.
+type deadlineReader struct {
+	body     io.ReadCloser
+	timeout  time.Duration
+	timer    *time.Timer
+	timedOut atomic.Bool
+}
+
+// newDeadlineReader wraps a response body with the idle timeout from user preferences.
+//
+// Parameters:
+//   - body: Response body to read from
+//
+// Returns:
+//   - *deadlineReader: Reader that must be closed to release its timer
+func (d *Downloader) newDeadlineReader(body io.ReadCloser) *deadlineReader {
+	return &deadlineReader{body: body, timeout: d.getIdleTimeout()}
+}
+
+// Read reads from the body, failing with idleTimeoutError if it stalls.
+func (r *deadlineReader) Read(p []byte) (int, error) {
+	if setter, ok := r.body.(interface{ SetReadDeadline(time.Time) error }); ok {
+		if err := setter.SetReadDeadline(time.Now().Add(r.timeout)); err == nil {
+			n, err := r.body.Read(p)
+			if isTimeoutError(err) {
+				err = &idleTimeoutError{timeout: r.timeout}
+			}
+			return n, err
+		}
+	}
+
+	// Interrupt the read by closing the body if it takes too long
+	if r.timer == nil {
+		r.timer = time.AfterFunc(r.timeout, r.expire)
+	} else {
+		r.timer.Reset(r.timeout)
+	}
+
+	n, err := r.body.Read(p)
+	r.timer.Stop()
+
+	if err != nil && r.timedOut.Load() {
+		err = &idleTimeoutError{timeout: r.timeout}
+	}
+	return n, err
+}
+
+// expire closes the body of a stalled read.
+func (r *deadlineReader) expire() {
+	r.timedOut.Store(true)
+	r.body.Close()
+}
+
+// Close stops the timer and closes the body.
+func (r *deadlineReader) Close() error {
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	return r.body.Close()
+}
+
+// isIdleTimeout reports whether err, or an error it wraps, is an idleTimeoutError.
+func isIdleTimeout(err error) bool {
+	var idleErr *idleTimeoutError
+	return errors.As(err, &idleErr)
+}
+
+// isTimeoutError reports whether err is a timeout error.
+func isTimeoutError(err error) bool {
+	timeoutErr, ok := err.(interface{ Timeout() bool })
+	return ok && timeoutErr.Timeout()
+}
+
+// getIdleTimeout returns the idle read timeout from user preferences.
+//
+// Returns:
+//   - time.Duration: Configured timeout or DEFAULT_IDLE_TIMEOUT_SEC seconds if not set
+func (d *Downloader) getIdleTimeout() time.Duration {
+	if d.Prefs.IdleTimeoutSec > 0 {
+		return time.Duration(d.Prefs.IdleTimeoutSec) * time.Second
+	}
+	return DEFAULT_IDLE_TIMEOUT_SEC * time.Second
+}
@@ -0,0 +1,59 @@
+package udm
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSingleStreamResumesAfterIdleTimeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits for the one second idle timeout")
+	}
+
+	previous := UDMSettings
+	UDMSettings = &Settings{RetryPolicy: RetryPolicy{InitialBackoffMs: 10, BackoffMultiplier: 1, MaxBackoffMs: 10}}
+	defer func() { UDMSettings = previous }()
+
+	content := bytes.Repeat([]byte("0123456789"), 10*1024)
+	var stalled atomic.Bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first download request sends half of the file and then stalls
+		if r.Method == http.MethodGet && r.Header.Get("Range") == "" && stalled.CompareAndSwap(false, true) {
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Header().Set("Content-Length", "102400")
+			w.Write(content[:len(content)/2])
+			w.(http.Flusher).Flush()
+
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		http.ServeContent(w, r, "data.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	d := &Downloader{Url: server.URL + "/data.bin"}
+	d.Prefs.DownloadDir = t.TempDir()
+	d.Prefs.IdleTimeoutSec = 1
+	d.Prefs.threadCount = 1
+
+	d.StartDownload()
+
+	if d.Error != nil {
+		t.Fatalf("download failed: %v", d.Error)
+	}
+	if !stalled.Load() {
+		t.Fatal("the stalling response was never sent")
+	}
+	if got, _ := os.ReadFile(d.OutputPath); !bytes.Equal(got, content) {
+		t.Errorf("downloaded %d bytes, want the %d bytes of the file", len(got), len(content))
+	}
+}
@@ -0,0 +1,175 @@
+package udm
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"udl/udm/ufs"
+)
+
+// IncompleteFileSuffix is appended to the output path while the file is being downloaded.
+// The file is renamed to its final name once it is complete, so media players and
+// antivirus scanners never read a partially downloaded file.
+const IncompleteFileSuffix = ".udtmp"
+
+// claimedOutputPaths maps the output paths claimed by running downloads to their Downloader,
+// so a download in this process never continues the partial files of another one
+var claimedOutputPaths sync.Map
+
+// incompletePath returns the path the output file is written to until it is complete.
+//
+// Returns:
+//   - string: Output path followed by IncompleteFileSuffix
+func (d *Downloader) incompletePath() string {
+	return d.fileInfo.FullPath + IncompleteFileSuffix
+}
+
+// verificationPath returns the path of the downloaded data to verify before it is committed.
+//
+// Returns:
+//   - string: The incomplete file, or the output path if it was already moved
+func (d *Downloader) verificationPath() string {
+	if incomplete := d.incompletePath(); ufs.FileExists(incomplete) {
+		return incomplete
+	}
+	return d.fileInfo.FullPath
+}
+
+// commitOutputFile moves the completed incomplete file to the final output path.
+// Nothing is done if there is no incomplete file, e.g. it was already moved.
+//
+// Returns:
+//   - error: DiskError if the file could not be moved or its permissions not set
+func (d *Downloader) commitOutputFile() error {
+	incomplete := d.incompletePath()
+	if !ufs.FileExists(incomplete) {
+		return nil
+	}
+
+	if err := ufs.AtomicWriteFile(incomplete, d.fileInfo.FullPath); err != nil {
+		return &DiskError{Op: "rename", Path: incomplete, Err: err}
+	}
+	d.releaseOutputPath()
+
+	// Apply the configured permissions exactly, creating the file was subject to the umask
+	if err := ufs.SetFilePermissions(d.fileInfo.FullPath, d.getFileMode()); err != nil {
+		return &DiskError{Op: "chmod", Path: d.fileInfo.FullPath, Err: err}
+	}
+	return nil
+}
+
+// claimOutputPath atomically creates an empty file at the output path, so no other download
+// or process can take the name while the incomplete file is written. The empty file is
+// replaced by commitOutputFile. If the name was taken since setupDownloadPaths, the next
+// free "filename (N).ext" is claimed and the output path is updated.
+//
+// Returns:
+//   - error: Error if the file could not be created
+func (d *Downloader) claimOutputPath() error {
+	// Already claimed by this download or by an earlier, interrupted run of it
+	if d.ownsOutputPath(d.fileInfo.FullPath) {
+		d.setClaimedOutputPath(d.fileInfo.FullPath)
+		return nil
+	}
+
+	// A stale file set by skipIfUnchanged stays in place until commitOutputFile replaces it,
+	// unless another download of this process claimed the name
+	if d.replaceOutput {
+		if owner, loaded := claimedOutputPaths.LoadOrStore(d.fileInfo.FullPath, d); !loaded || owner == d {
+			d.setClaimedOutputPath(d.fileInfo.FullPath)
+			return nil
+		}
+	}
+
+	placeholder, path, err := ufs.CreateUniqueFile(d.fileInfo.FullPath)
+	if err != nil {
+		return err
+	}
+	placeholder.Close()
+
+	d.fileInfo.Name = filepath.Base(path)
+	d.fileInfo.FullPath = path
+	d.OutputPath = path
+	d.setClaimedOutputPath(path)
+	return nil
+}
+
+// setClaimedOutputPath records path as the output path claimed by this download.
+//
+// Parameters:
+//   - path: Output path created or reused by claimOutputPath
+func (d *Downloader) setClaimedOutputPath(path string) {
+	if d.claimedOutputPath != path {
+		d.releaseOutputPath()
+	}
+	d.claimedOutputPath = path
+	claimedOutputPaths.Store(path, d)
+}
+
+// releaseOutputPath forgets the output path claimed by this download, once it was
+// committed or cleaned up.
+func (d *Downloader) releaseOutputPath() {
+	if d.claimedOutputPath == "" {
+		return
+	}
+	claimedOutputPaths.CompareAndDelete(d.claimedOutputPath, d)
+	d.claimedOutputPath = ""
+}
+
+// ownsOutputPath reports whether path was claimed by this download, either in this
+// run or by an interrupted run whose partial files still exist.
+//
+// Parameters:
+//   - path: Output path of the download
+//
+// Returns:
+//   - bool: True if the download can keep writing to path
+func (d *Downloader) ownsOutputPath(path string) bool {
+	if owner, claimed := claimedOutputPaths.Load(path); claimed && owner != d {
+		return false
+	}
+	if path == d.claimedOutputPath && ufs.FileExists(path) {
+		return true
+	}
+	return isOutputPlaceholder(path)
+}
+
+// removeIncompleteOutput deletes the incomplete file and the placeholder created by
+// claimOutputPath after a download failed or was stopped, so neither blocks the name.
+// Nothing is done if the download never claimed its output path.
+func (d *Downloader) removeIncompleteOutput() {
+	path := d.claimedOutputPath
+	if path == "" {
+		return
+	}
+	d.releaseOutputPath()
+
+	os.Remove(path + IncompleteFileSuffix)
+
+	// Only remove the placeholder, never a file that was written in the meantime
+	if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() && info.Size() == 0 {
+		os.Remove(path)
+	}
+}
+
+// isOutputPlaceholder reports whether path is the empty file created by claimOutputPath
+// for a download whose incomplete file or chunk files still exist, e.g. after it was interrupted.
+//
+// Parameters:
+//   - path: Output path of a download
+//
+// Returns:
+//   - bool: True if the download at path can be continued
+func isOutputPlaceholder(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() != 0 {
+		return false
+	}
+	if ufs.FileExists(path + IncompleteFileSuffix) {
+		return true
+	}
+
+	// Multi-stream downloads write chunk files until they are merged
+	firstChunk := ufs.ChunkFileName(ufs.FileNameWithoutExtension(filepath.Base(path)), 0, filepath.Dir(path), ".udtemp")
+	return ufs.FileExists(firstChunk)
+}
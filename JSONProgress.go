@@ -0,0 +1,37 @@
+package udm
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// jsonProgressMu keeps the lines of concurrent downloads from interleaving on stdout
+var jsonProgressMu sync.Mutex
+
+// reportProgress prints the progress as a JSON line when JSONProgressMode is set
+// and calls the OnProgress callback.
+func (d *Downloader) reportProgress() {
+	if d.Prefs.JSONProgressMode {
+		d.printJSONProgress()
+	}
+
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnProgress != nil {
+		callbacks.OnProgress(d)
+	}
+}
+
+// printJSONProgress writes GetProgressMap to stdout as a single JSON line, e.g.
+//
+//	{"downloaded":4194304,"eta":30,"filesize":10485760,"id":"...","percentage":42.5,...}
+func (d *Downloader) printJSONProgress() {
+	line, err := json.Marshal(d.GetProgressMap())
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	jsonProgressMu.Lock()
+	defer jsonProgressMu.Unlock()
+	os.Stdout.Write(line)
+}
@@ -7,7 +7,8 @@ func (d *Downloader) Pause() {
 
 	if !d.PauseControl.isPaused {
 		d.PauseControl.isPaused = true
-		d.Status = DOWNLOAD_PAUSED
+		d.setStatus(DOWNLOAD_PAUSED)
+		d.logEvent(EVENT_PAUSED, "paused by the user")
 	}
 }
 
@@ -18,7 +19,8 @@ func (d *Downloader) Resume() {
 
 	if d.PauseControl.isPaused {
 		d.PauseControl.isPaused = false
-		d.Status = DOWNLOAD_IN_PROGRESS
+		d.setStatus(DOWNLOAD_IN_PROGRESS)
+		d.logEvent(EVENT_RESUMED, "resumed by the user")
 		d.PauseControl.cond.Broadcast()
 	}
 }
@@ -29,6 +31,6 @@ func (d *Downloader) Cancel() {
 	defer d.PauseControl.mu.Unlock()
 
 	d.PauseControl.isPaused = false
-	d.Status = DOWNLOAD_STOPPED
+	d.setStatus(DOWNLOAD_STOPPED)
 	d.PauseControl.cond.Broadcast()
 }
@@ -17,10 +17,12 @@ type ProgressManager struct {
 	ctx        context.Context
 	cancel     context.CancelFunc
 	isRunning  bool
+	isDetached bool
 }
 
-// NewProgressManager creates a new progress manager for the downloader
-func NewProgressManager(downloader *Downloader) *ProgressManager {
+// NewProgressManager creates a new progress manager for the downloader.
+// An optional ProgressTheme sets the colors of the progress bar, e.g. ThemeMonokai.
+func NewProgressManager(downloader *Downloader, theme ...ProgressTheme) *ProgressManager {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	tracker := &UDMProgressTracker{
@@ -38,7 +40,7 @@ func NewProgressManager(downloader *Downloader) *ProgressManager {
 		ChunkProgress:  []ChunkProgress{},
 	}
 
-	model := NewUDMProgress(tracker)
+	model := NewUDMProgress(tracker, theme...)
 
 	return &ProgressManager{
 		downloader: downloader,
@@ -70,7 +72,7 @@ func (pm *ProgressManager) StartProgressDisplay() error {
 		defer func() { pm.isRunning = false }()
 
 		if err := pm.program.Start(); err != nil {
-			fmt.Printf("Error starting progress display: %v\n", err)
+			logf("Error starting progress display: %v\n", err)
 		}
 	}()
 
@@ -91,6 +93,51 @@ func (pm *ProgressManager) StopProgressDisplay() {
 	}
 }
 
+// Detach stops the progress display without affecting the download.
+// The Bubble Tea program and the update loop are stopped, but the download
+// keeps running in the background and can be displayed again with Reattach.
+func (pm *ProgressManager) Detach() {
+	if pm.isDetached {
+		return
+	}
+
+	// Stop the update loop, this context is owned by the progress manager only
+	if pm.cancel != nil {
+		pm.cancel()
+	}
+
+	if pm.program != nil {
+		pm.program.Quit()
+	}
+
+	pm.isDetached = true
+}
+
+// Reattach starts a new progress display for a detached progress manager.
+//
+// Returns:
+//   - error: Error if the progress manager is not detached or the display is still running
+func (pm *ProgressManager) Reattach() error {
+	if !pm.isDetached {
+		return fmt.Errorf("progress display is not detached")
+	}
+
+	if pm.isRunning {
+		return fmt.Errorf("progress display is still shutting down")
+	}
+
+	// Create a fresh context for the new update loop
+	pm.ctx, pm.cancel = context.WithCancel(context.Background())
+	pm.isDetached = false
+
+	return pm.StartProgressDisplay()
+}
+
+// IsDetached returns true if the progress display was detached from the download
+func (pm *ProgressManager) IsDetached() bool {
+	return pm.isDetached
+}
+
 // updateLoop continuously updates the progress display
 func (pm *ProgressManager) updateLoop() {
 	ticker := time.NewTicker(100 * time.Millisecond)
@@ -113,16 +160,17 @@ func (pm *ProgressManager) updateProgress() {
 	}
 
 	// Get current progress data
-	bytesCompleted, totalBytes, percentage, speedBps, eta := pm.downloader.Progress.GetProgressInfo()
+	progress := pm.downloader.Progress.Snapshot()
 
 	// Update tracker
-	pm.tracker.BytesCompleted = bytesCompleted
-	pm.tracker.TotalBytes = totalBytes
-	pm.tracker.Percentage = percentage
-	pm.tracker.SpeedBps = speedBps
-	pm.tracker.ETA = eta
-	pm.tracker.IsPaused = (pm.downloader.Status == DOWNLOAD_PAUSED)
-	pm.tracker.IsCompleted = (pm.downloader.Status == DOWNLOAD_COMPLETED)
+	pm.tracker.BytesCompleted = progress.BytesCompleted
+	pm.tracker.TotalBytes = progress.TotalBytes
+	pm.tracker.Percentage = progress.Percentage
+	pm.tracker.SpeedBps = progress.SpeedBps
+	pm.tracker.ETA = progress.ETA
+	status := pm.downloader.status()
+	pm.tracker.IsPaused = (status == DOWNLOAD_PAUSED)
+	pm.tracker.IsCompleted = (status == DOWNLOAD_COMPLETED)
 
 	// Update chunk progress for multi-stream downloads
 	if pm.downloader.IsMultiStreamDownload() {
@@ -165,6 +213,22 @@ func (pm *ProgressManager) updateChunkProgress() {
 	}
 }
 
+// setAssembleProgress updates the assembly phase shown below the download progress.
+//
+// Parameters:
+//   - assembling: True while the chunk files are being merged
+//   - merged: Bytes merged so far
+//   - total: Total bytes to merge
+func (pm *ProgressManager) setAssembleProgress(assembling bool, merged, total int64) {
+	pm.tracker.IsAssembling = assembling
+	pm.tracker.AssembledBytes = merged
+	pm.tracker.AssembleTotal = total
+
+	if pm.program != nil && pm.isRunning {
+		pm.program.Send(progressUpdateMsg(*pm.tracker))
+	}
+}
+
 // MarkCompleted marks the download as completed and shows final message
 func (pm *ProgressManager) MarkCompleted() {
 	pm.tracker.IsCompleted = true
@@ -194,6 +258,10 @@ func (pm *ProgressManager) MarkError(err error) {
 
 // SetupProgressCallbacks configures the downloader callbacks to work with progress bar
 func SetupProgressCallbacks(downloader *Downloader, pm *ProgressManager) {
+	// The download may already be running, so the callbacks are swapped under the lock
+	downloader.callbacksMu.Lock()
+	defer downloader.callbacksMu.Unlock()
+
 	// Store original callbacks
 	originalCallbacks := downloader.Callbacks
 	if originalCallbacks == nil {
@@ -301,23 +369,45 @@ func SetupProgressCallbacks(downloader *Downloader, pm *ProgressManager) {
 		},
 
 		OnAssembleStart: func(d *Downloader) {
+			if d.UseProgressBar && pm != nil {
+				pm.setAssembleProgress(true, 0, 0)
+			}
+
 			if originalCallbacks.OnAssembleStart != nil && !d.UseProgressBar {
 				originalCallbacks.OnAssembleStart(d)
 			}
 		},
 
 		OnAssembleFinish: func(d *Downloader) {
+			if d.UseProgressBar && pm != nil {
+				pm.setAssembleProgress(false, 0, 0)
+			}
+
 			if originalCallbacks.OnAssembleFinish != nil && !d.UseProgressBar {
 				originalCallbacks.OnAssembleFinish(d)
 			}
 		},
 
 		OnAssembleError: func(d *Downloader, err error) {
+			if d.UseProgressBar && pm != nil {
+				pm.setAssembleProgress(false, 0, 0)
+			}
+
 			if originalCallbacks.OnAssembleError != nil && !d.UseProgressBar {
 				originalCallbacks.OnAssembleError(d, err)
 			}
 		},
 
+		OnAssembleProgress: func(d *Downloader, merged, total int64) {
+			if d.UseProgressBar && pm != nil {
+				pm.setAssembleProgress(true, merged, total)
+			}
+
+			if originalCallbacks.OnAssembleProgress != nil && !d.UseProgressBar {
+				originalCallbacks.OnAssembleProgress(d, merged, total)
+			}
+		},
+
 		OnDispose: func(d *Downloader) {
 			if d.UseProgressBar && pm != nil {
 				pm.StopProgressDisplay()
@@ -327,5 +417,58 @@ func SetupProgressCallbacks(downloader *Downloader, pm *ProgressManager) {
 				originalCallbacks.OnDispose(d)
 			}
 		},
+
+		// These callbacks don't print anything, so they are passed through
+		OnRetry:        originalCallbacks.OnRetry,
+		OnElevated:     originalCallbacks.OnElevated,
+		OnQueued:       originalCallbacks.OnQueued,
+		OnDequeued:     originalCallbacks.OnDequeued,
+		OnHashMismatch: originalCallbacks.OnHashMismatch,
+		OnURLFallback:  originalCallbacks.OnURLFallback,
+		OnTypeMismatch: originalCallbacks.OnTypeMismatch,
+	}
+}
+
+// AttachProgressBar shows a progress bar for a download that was started without one.
+// It can be called while the download is in progress, e.g. one started with DownloadAsync.
+// The progress callbacks are wired using SetupProgressCallbacks and the display is started right away.
+//
+// Parameters:
+//   - pm: The progress manager to display the download with
+//
+// Returns:
+//   - error: Error if the progress display could not be started
+//
+// Example:
+//
+//	go downloader.StartDownload()
+//	// ... later, when the user opens the TUI
+//	if err := downloader.AttachProgressBar(NewProgressManager(downloader)); err != nil {
+//		fmt.Println("Error:", err)
+//	}
+func (d *Downloader) AttachProgressBar(pm *ProgressManager) error {
+	pm.downloader = d
+
+	// The download may have progressed since the progress manager was created
+	pm.tracker.Filename = d.fileInfo.Name
+	pm.tracker.TotalBytes = d.ServerHeaders.Filesize
+	pm.tracker.OutputDir = d.fileInfo.Dir
+	if d.Progress != nil && !d.Progress.StartTime.IsZero() {
+		pm.tracker.StartTime = d.Progress.StartTime
 	}
+
+	// Chunk progress is only tracked when the progress bar is enabled
+	if chunkCount := d.GetChunkCount(); len(d.GetChunkProgressData()) == 0 && chunkCount > 1 {
+		d.InitializeChunkProgress(chunkCount)
+	}
+	pm.tracker.IsMultiStream = d.IsMultiStreamDownload()
+
+	d.UseProgressBar = true
+	if d.Progress != nil {
+		d.Progress.ShowProgress = true
+	}
+
+	SetupProgressCallbacks(d, pm)
+
+	return pm.StartProgressDisplay()
 }
@@ -0,0 +1,45 @@
+package udm
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSetupProgressCallbacksWhileRunning(t *testing.T) {
+	var progressCalls atomic.Int32
+	d := &Downloader{Callbacks: &Callbacks{
+		OnProgress: func(d *Downloader) { progressCalls.Add(1) },
+	}}
+
+	// Progress is reported the way a running download does while the callbacks are replaced
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if callbacks := d.callbacks(); callbacks != nil && callbacks.OnProgress != nil {
+				callbacks.OnProgress(d)
+			}
+		}
+	}()
+
+	for range 10 {
+		SetupProgressCallbacks(d, nil)
+	}
+	close(stop)
+	wg.Wait()
+
+	// The original callback is still reached through the wrappers
+	before := progressCalls.Load()
+	d.callbacks().OnProgress(d)
+	if got := progressCalls.Load(); got != before+1 {
+		t.Errorf("original OnProgress calls = %d, want %d", got, before+1)
+	}
+}
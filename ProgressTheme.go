@@ -0,0 +1,76 @@
+package udm
+
+// ProgressTheme holds the colors of the progress bar, so applications embedding UDM
+// can match their own branding. Colors are hex strings like "#00d7af".
+//
+// Example:
+//
+//	pm := NewProgressManager(downloader, ThemeSolarized)
+//
+//	// Or only change some colors, empty fields use ThemeDefault
+//	pm = NewProgressManager(downloader, ProgressTheme{
+//	    ActiveGradientStart: "#ff0000",
+//	    ActiveGradientEnd:   "#0000ff",
+//	})
+type ProgressTheme struct {
+	ActiveGradientStart string // Start color of the bar while downloading
+	ActiveGradientEnd   string // End color of the bar while downloading
+	PausedGradientStart string // Start color of the bar while paused
+	PausedGradientEnd   string // End color of the bar while paused
+	CompletedColor      string // Color of the completion message
+}
+
+// Built-in progress bar themes
+var (
+	ThemeDefault = ProgressTheme{
+		ActiveGradientStart: "#00d7af",
+		ActiveGradientEnd:   "#5fafff",
+		PausedGradientStart: "#ffff00",
+		PausedGradientEnd:   "#ffa500",
+		CompletedColor:      "#00d7af",
+	}
+
+	ThemeMonokai = ProgressTheme{
+		ActiveGradientStart: "#a6e22e",
+		ActiveGradientEnd:   "#66d9ef",
+		PausedGradientStart: "#e6db74",
+		PausedGradientEnd:   "#fd971f",
+		CompletedColor:      "#a6e22e",
+	}
+
+	ThemeSolarized = ProgressTheme{
+		ActiveGradientStart: "#2aa198",
+		ActiveGradientEnd:   "#268bd2",
+		PausedGradientStart: "#b58900",
+		PausedGradientEnd:   "#cb4b16",
+		CompletedColor:      "#859900",
+	}
+)
+
+// withDefaults returns the theme with empty colors taken from ThemeDefault
+func (t ProgressTheme) withDefaults() ProgressTheme {
+	if t.ActiveGradientStart == "" {
+		t.ActiveGradientStart = ThemeDefault.ActiveGradientStart
+	}
+	if t.ActiveGradientEnd == "" {
+		t.ActiveGradientEnd = ThemeDefault.ActiveGradientEnd
+	}
+	if t.PausedGradientStart == "" {
+		t.PausedGradientStart = ThemeDefault.PausedGradientStart
+	}
+	if t.PausedGradientEnd == "" {
+		t.PausedGradientEnd = ThemeDefault.PausedGradientEnd
+	}
+	if t.CompletedColor == "" {
+		t.CompletedColor = ThemeDefault.CompletedColor
+	}
+	return t
+}
+
+// themeOrDefault returns the first theme passed to a variadic constructor, or ThemeDefault
+func themeOrDefault(themes []ProgressTheme) ProgressTheme {
+	if len(themes) == 0 {
+		return ThemeDefault
+	}
+	return themes[0].withDefaults()
+}
@@ -0,0 +1,155 @@
+package udm
+
+import "sync"
+
+// Queue holds downloaders waiting to be started, in first-in first-out order.
+// It fires Callbacks.OnQueued when a downloader is added and
+// Callbacks.OnDequeued when it is removed, either to be started or cancelled.
+// The downloaders themselves are not modified, DownloadQueue uses it as its waiting list
+// and manages their status.
+//
+// Example:
+//
+//	queue := NewQueue()
+//	queue.Enqueue(&Downloader{Url: "https://example.com/a.zip", Callbacks: callbacks})
+//	queue.Enqueue(&Downloader{Url: "https://example.com/b.zip", Callbacks: callbacks})
+//
+//	for d := queue.Dequeue(); d != nil; d = queue.Dequeue() {
+//		d.StartDownload()
+//	}
+type Queue struct {
+	mu    sync.Mutex
+	items []*Downloader
+}
+
+// NewQueue creates an empty download queue
+func NewQueue() *Queue {
+	return &Queue{}
+}
+
+// Enqueue adds a downloader to the end of the queue and fires its OnQueued callback.
+//
+// Parameters:
+//   - d: The downloader to add
+//
+// Returns:
+//   - int: Position of the downloader in the queue (0 is the next to start)
+func (q *Queue) Enqueue(d *Downloader) int {
+	q.mu.Lock()
+	q.items = append(q.items, d)
+	position := len(q.items) - 1
+	q.mu.Unlock()
+
+	// Fire callbacks outside the lock so they can use the queue
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnQueued != nil {
+		callbacks.OnQueued(d, position)
+	}
+
+	return position
+}
+
+// Dequeue removes the next downloader from the queue so it can be started
+// and fires its OnDequeued callback.
+//
+// Returns:
+//   - *Downloader: The next downloader, or nil if the queue is empty
+func (q *Queue) Dequeue() *Downloader {
+	q.mu.Lock()
+	if len(q.items) == 0 {
+		q.mu.Unlock()
+		return nil
+	}
+	d := q.items[0]
+	q.items[0] = nil
+	q.items = q.items[1:]
+	q.mu.Unlock()
+
+	fireDequeued(d)
+	return d
+}
+
+// DequeueHighestPriority removes the downloader with the highest UserPreferences.Priority
+// so it can be started and fires its OnDequeued callback. Among equal priorities the
+// downloader added first is returned.
+//
+// Returns:
+//   - *Downloader: The next downloader, or nil if the queue is empty
+func (q *Queue) DequeueHighestPriority() *Downloader {
+	q.mu.Lock()
+	if len(q.items) == 0 {
+		q.mu.Unlock()
+		return nil
+	}
+
+	index := 0
+	for i, item := range q.items {
+		if item.Prefs.Priority > q.items[index].Prefs.Priority {
+			index = i
+		}
+	}
+	d := q.items[index]
+	q.items = append(q.items[:index], q.items[index+1:]...)
+	q.mu.Unlock()
+
+	fireDequeued(d)
+	return d
+}
+
+// Remove cancels a queued downloader by taking it out of the queue
+// and fires its OnDequeued callback.
+//
+// Parameters:
+//   - d: The downloader to remove
+//
+// Returns:
+//   - bool: True if the downloader was in the queue
+func (q *Queue) Remove(d *Downloader) bool {
+	q.mu.Lock()
+	index := q.indexOf(d)
+	if index < 0 {
+		q.mu.Unlock()
+		return false
+	}
+	q.items = append(q.items[:index], q.items[index+1:]...)
+	q.mu.Unlock()
+
+	fireDequeued(d)
+	return true
+}
+
+// Position returns the position of a downloader in the queue.
+//
+// Parameters:
+//   - d: The downloader to look for
+//
+// Returns:
+//   - int: Position in the queue (0 is the next to start), or -1 if not queued
+func (q *Queue) Position(d *Downloader) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.indexOf(d)
+}
+
+// Len returns the number of downloaders waiting in the queue
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// indexOf returns the index of d in the queue, the lock must be held
+func (q *Queue) indexOf(d *Downloader) int {
+	for i, item := range q.items {
+		if item == d {
+			return i
+		}
+	}
+	return -1
+}
+
+// fireDequeued calls the OnDequeued callback of d if set
+func fireDequeued(d *Downloader) {
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnDequeued != nil {
+		callbacks.OnDequeued(d)
+	}
+}
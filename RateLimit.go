@@ -0,0 +1,19 @@
+package udm
+
+import "context"
+
+// throttle waits until n more bytes may be downloaded under Prefs.MaxSpeedBps.
+// All streams of a download share the same limiter, so their combined speed stays under the cap.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - n: Number of bytes just written
+//
+// Returns:
+//   - error: ctx error if the download was cancelled while waiting, nil otherwise
+func (d *Downloader) throttle(ctx context.Context, n int) error {
+	if d.speedLimiter == nil {
+		return nil
+	}
+	return d.speedLimiter.WaitN(ctx, n)
+}
@@ -0,0 +1,68 @@
+package udm
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rateLimitTracker holds, by host name, the time until which requests to the host are paused
+// after it responded with 429 Too Many Requests. It is shared by all downloads and chunks,
+// so they back off together instead of each running into the rate limit on its own.
+var rateLimitTracker sync.Map
+
+// rateLimitMu serializes updates of rateLimitTracker entries
+var rateLimitMu sync.Mutex
+
+// recordRateLimit pauses requests to the host of a 429 Too Many Requests response.
+// The pause lasts as long as the Retry-After header asks for, or the retry policy's delay without it.
+//
+// Parameters:
+//   - resp: The 429 response
+//   - attempt: Number of the retry about to be made, used when Retry-After is missing
+func recordRateLimit(resp *http.Response, attempt int) {
+	delay, ok := retryAfterDelay(resp)
+	if !ok {
+		delay = currentRetryPolicy().NextDelay(attempt)
+	}
+	until := time.Now().Add(delay)
+
+	rateLimitMu.Lock()
+	defer rateLimitMu.Unlock()
+
+	// Keep the longer pause if another chunk was already told to wait
+	host := resp.Request.URL.Hostname()
+	if current, ok := rateLimitTracker.Load(host); ok && current.(time.Time).After(until) {
+		return
+	}
+	rateLimitTracker.Store(host, until)
+}
+
+// waitForRateLimit blocks while requests to a host are paused by recordRateLimit.
+//
+// Parameters:
+//   - ctx: Context for cancellation while waiting
+//   - host: Host name the next request is sent to
+//
+// Returns:
+//   - error: ctx.Err() if the context was cancelled while waiting
+func waitForRateLimit(ctx context.Context, host string) error {
+	value, ok := rateLimitTracker.Load(host)
+	if !ok {
+		return nil
+	}
+
+	delay := time.Until(value.(time.Time))
+	if delay <= 0 {
+		rateLimitTracker.CompareAndDelete(host, value)
+		return nil
+	}
+
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
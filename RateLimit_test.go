@@ -0,0 +1,53 @@
+package udm
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMaxSpeedBpsLimitsMultiStreamDownload(t *testing.T) {
+	if testing.Short() {
+		t.Skip("downloads 3 MB at 1 MB/s")
+	}
+
+	previous := UDMSettings
+	UDMSettings = &Settings{MinimumFileSize: 1}
+	defer func() { UDMSettings = previous }()
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), 3*1024*1024/16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "data.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	const maxSpeed = 1024 * 1024
+	d := &Downloader{Url: server.URL + "/data.bin"}
+	d.Prefs.DownloadDir = t.TempDir()
+	d.Prefs.threadCount = 4
+	d.Prefs.MaxSpeedBps = maxSpeed
+
+	start := time.Now()
+	d.StartDownload()
+	elapsed := time.Since(start)
+
+	if d.Error != nil {
+		t.Fatalf("download failed: %v", d.Error)
+	}
+	if len(d.Chunks) < 2 {
+		t.Fatalf("download used %d chunks, want a multi-stream download", len(d.Chunks))
+	}
+	if got, _ := os.ReadFile(d.OutputPath); !bytes.Equal(got, content) {
+		t.Errorf("downloaded %d bytes that differ from the %d bytes of the file", len(got), len(content))
+	}
+
+	// The limiter allows one second of data at once, the rest arrives at maxSpeed
+	// summed over all streams, each stream limited on its own would finish 4 times faster
+	minElapsed := time.Duration(len(content)-maxSpeed) * time.Second / maxSpeed
+	if elapsed < minElapsed*9/10 {
+		t.Errorf("download took %v, want at least %v at %d bytes per second", elapsed, minElapsed, maxSpeed)
+	}
+}
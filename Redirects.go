@@ -0,0 +1,50 @@
+package udm
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// DEFAULT_MAX_REDIRECTS is used when UserPreferences.MaxRedirects is not set
+const DEFAULT_MAX_REDIRECTS = 10
+
+// getMaxRedirects returns the number of redirects to follow from user preferences.
+//
+// Returns:
+//   - int: Configured limit or DEFAULT_MAX_REDIRECTS if not set
+func (p *UserPreferences) getMaxRedirects() int {
+	if p.MaxRedirects > 0 {
+		return p.MaxRedirects
+	}
+	return DEFAULT_MAX_REDIRECTS
+}
+
+// redirectPolicy returns the CheckRedirect function of the HTTP clients.
+//
+// Parameters:
+//   - prefs: Preferences holding MaxRedirects
+//   - chain: Receives the URL of each redirect in order, nil to not record them
+//
+// Returns:
+//   - func(*http.Request, []*http.Request) error: Function failing with ErrTooManyRedirects beyond the limit
+func redirectPolicy(prefs *UserPreferences, chain *[]string) func(req *http.Request, via []*http.Request) error {
+	maxRedirects := prefs.getMaxRedirects()
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return fmt.Errorf("%w: stopped after %d redirects", ErrTooManyRedirects, maxRedirects)
+		}
+		if chain != nil {
+			*chain = append(*chain, req.URL.String())
+		}
+		return nil
+	}
+}
+
+// GetRedirectChain returns the URLs the server redirected to while fetching the metadata,
+// in the order they were followed, e.g. to debug CDN redirects.
+//
+// Returns:
+//   - []string: Redirect URLs, the last one is GetFinalURL, empty if there were no redirects
+func (d *Downloader) GetRedirectChain() []string {
+	return append([]string(nil), d.ServerHeaders.RedirectChain...)
+}
@@ -0,0 +1,193 @@
+package udm
+
+import (
+	"fmt"
+	"hash/adler32"
+	"io"
+	"maps"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/utsav-56/ulog"
+)
+
+// resumeReferenceSize is the number of bytes at the start of each chunk
+// covered by the reference checksum used to validate resumed chunks
+const resumeReferenceSize = 1024 * 1024
+
+// chunkReference is the checksum of the first bytes of a chunk file
+type chunkReference struct {
+	Length   int64  `json:"length"`   // Number of bytes covered by the checksum
+	Checksum uint32 `json:"checksum"` // Adler-32 checksum of those bytes
+}
+
+// resumeState holds the chunk references of a download, stored in its state file
+type resumeState struct {
+	mu     sync.Mutex
+	Chunks map[string]chunkReference // References by chunk index
+}
+
+// references returns a copy of the stored references.
+func (s *resumeState) references() map[string]chunkReference {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.Chunks)
+}
+
+// loadResumeState reads the chunk references from the state file of this download.
+// A missing or unreadable file results in an empty state.
+func (d *Downloader) loadResumeState() {
+	state := &resumeState{Chunks: make(map[string]chunkReference)}
+
+	if saved, err := readDownloaderState(d.StateFilePath()); err == nil && saved.ChunkReferences != nil {
+		state.Chunks = saved.ChunkReferences
+	}
+
+	d.resumeState = state
+}
+
+// resetResumeState clears the stored references, used when the chunks are created from scratch.
+// The state file is deleted, it describes chunk files that no longer exist.
+func (d *Downloader) resetResumeState() {
+	d.resumeState = &resumeState{Chunks: make(map[string]chunkReference)}
+	os.Remove(d.StateFilePath())
+}
+
+// removeResumeState deletes the state file holding the references once the chunk files
+// were merged or cleaned up. Nothing is done if the references were never used.
+func (d *Downloader) removeResumeState() {
+	if d.resumeState == nil {
+		return
+	}
+	d.resumeState = nil
+	os.Remove(d.StateFilePath())
+}
+
+// saveChunkReferences writes the references into the state file of this download. An existing
+// state file only gets its references updated, otherwise the current state is saved with them.
+// The lock of the resume state must be held.
+func (d *Downloader) saveChunkReferences() {
+	path := d.StateFilePath()
+
+	state, err := readDownloaderState(path)
+	if err != nil {
+		state = d.currentState()
+	}
+	state.ChunkReferences = d.resumeState.Chunks
+
+	// Saving is best effort, without a reference the chunk is resumed unvalidated
+	if err := writeDownloaderState(path, state); err != nil {
+		ulog.Error(fmt.Sprintf("Failed to save chunk references: %v", err), "UDM_RESUME_STATE_ERROR")
+	}
+}
+
+// recordChunkReference stores the checksum of the first megabyte of a chunk file,
+// so a later resume can detect if the file was corrupted in between.
+// Nothing is done if a reference covering the whole first megabyte already exists.
+//
+// Parameters:
+//   - chunkIndex: Index of the chunk
+//   - chunkFile: Path to the chunk file
+func (d *Downloader) recordChunkReference(chunkIndex int, chunkFile string) {
+	state := d.resumeState
+	if state == nil {
+		return
+	}
+
+	key := strconv.Itoa(chunkIndex)
+
+	state.mu.Lock()
+	existing, exists := state.Chunks[key]
+	state.mu.Unlock()
+	if exists && existing.Length >= resumeReferenceSize {
+		return
+	}
+
+	reference, err := computeChunkReference(chunkFile, resumeReferenceSize)
+	if err != nil || reference.Length == 0 || reference.Length <= existing.Length {
+		return
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.Chunks[key] = reference
+	d.saveChunkReferences()
+}
+
+// validateResumedChunk checks that a partial chunk file still matches its stored reference.
+//
+// Parameters:
+//   - chunkIndex: Index of the chunk
+//   - chunkFile: Path to the chunk file
+//
+// Returns:
+//   - error: Error if the file doesn't match the reference, nil if it matches or no reference exists
+func (d *Downloader) validateResumedChunk(chunkIndex int, chunkFile string) error {
+	state := d.resumeState
+	if state == nil {
+		return nil
+	}
+
+	state.mu.Lock()
+	reference, exists := state.Chunks[strconv.Itoa(chunkIndex)]
+	state.mu.Unlock()
+	if !exists {
+		return nil
+	}
+
+	current, err := computeChunkReference(chunkFile, reference.Length)
+	if err != nil {
+		return err
+	}
+	if current.Length != reference.Length || current.Checksum != reference.Checksum {
+		return fmt.Errorf("chunk %d does not match its stored checksum", chunkIndex)
+	}
+
+	return nil
+}
+
+// computeChunkReference calculates the Adler-32 checksum of the first bytes of a file.
+//
+// Parameters:
+//   - path: Path to the file
+//   - limit: Maximum number of bytes to include
+//
+// Returns:
+//   - chunkReference: Checksum and number of bytes covered (less than limit for short files)
+//   - error: Error if the file cannot be read
+func computeChunkReference(path string, limit int64) (chunkReference, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return chunkReference{}, err
+	}
+	defer file.Close()
+
+	hash := adler32.New()
+	length, err := io.Copy(hash, io.LimitReader(file, limit))
+	if err != nil {
+		return chunkReference{}, err
+	}
+
+	return chunkReference{Length: length, Checksum: hash.Sum32()}, nil
+}
+
+// referenceWriter writes to a chunk file and calls onReference once
+// the file reaches the size covered by the reference checksum
+type referenceWriter struct {
+	*os.File
+	offset      int64  // Current size of the chunk file
+	onReference func() // Called once when offset reaches resumeReferenceSize
+}
+
+func (w *referenceWriter) Write(p []byte) (int, error) {
+	n, err := w.File.Write(p)
+
+	before := w.offset
+	w.offset += int64(n)
+	if before < resumeReferenceSize && w.offset >= resumeReferenceSize {
+		w.onReference()
+	}
+
+	return n, err
+}
@@ -0,0 +1,48 @@
+package udm
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChunkReferencesStoredInStateFile(t *testing.T) {
+	dir := t.TempDir()
+	chunkFile := filepath.Join(dir, "chunk0.udtemp")
+	if err := os.WriteFile(chunkFile, bytes.Repeat([]byte("a"), 4096), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &Downloader{Url: "https://example.com/video.mp4"}
+	d.fileInfo = FileInfo{Name: "video.mp4", Dir: dir, FullPath: filepath.Join(dir, "video.mp4")}
+	d.resetResumeState()
+	d.recordChunkReference(0, chunkFile)
+
+	state, err := readDownloaderState(d.StateFilePath())
+	if err != nil {
+		t.Fatalf("state file not written: %v", err)
+	}
+	if state.URL != d.Url || len(state.ChunkReferences) != 1 {
+		t.Fatalf("state file holds URL %q and %d references, want %q and 1", state.URL, len(state.ChunkReferences), d.Url)
+	}
+
+	// A new process reads the references back from the state file
+	resumed := &Downloader{fileInfo: d.fileInfo}
+	resumed.loadResumeState()
+	if err := resumed.validateResumedChunk(0, chunkFile); err != nil {
+		t.Errorf("unchanged chunk rejected: %v", err)
+	}
+
+	if err := os.WriteFile(chunkFile, bytes.Repeat([]byte("b"), 4096), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := resumed.validateResumedChunk(0, chunkFile); err == nil {
+		t.Error("corrupt chunk accepted")
+	}
+
+	resumed.removeResumeState()
+	if _, err := os.Stat(d.StateFilePath()); !os.IsNotExist(err) {
+		t.Errorf("state file still exists after removeResumeState: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package udm
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RETRY_JITTER_FRACTION is the random variance added to retry delays when RetryPolicy.Jitter is set
+const RETRY_JITTER_FRACTION = 0.25
+
+// DEFAULT_RETRY_POLICY is used when Settings.RetryPolicy is not configured
+var DEFAULT_RETRY_POLICY = RetryPolicy{
+	InitialBackoffMs:  2000,
+	BackoffMultiplier: 2,
+	MaxBackoffMs:      30000,
+	Jitter:            true,
+}
+
+// RetryPolicy controls how long to wait between retries of metadata requests,
+// chunk downloads and responses with a retryable status code.
+// Zero fields fall back to the values of DEFAULT_RETRY_POLICY.
+//
+// Example:
+//
+//	// 500ms, 1s, 2s, 4s, ... up to 10s, each ±25%
+//	UDMSettings.RetryPolicy = RetryPolicy{
+//		InitialBackoffMs:  500,
+//		BackoffMultiplier: 2,
+//		MaxBackoffMs:      10000,
+//		Jitter:            true,
+//	}
+type RetryPolicy struct {
+	InitialBackoffMs  int     `json:"InitialBackoffMs"`  // Delay before the first retry
+	BackoffMultiplier float64 `json:"BackoffMultiplier"` // Factor applied to the delay after each retry, 1 for a constant delay
+	MaxBackoffMs      int     `json:"MaxBackoffMs"`      // Upper bound of the delay before jitter
+	Jitter            bool    `json:"Jitter"`            // Add ±25% random variance to avoid retrying in lockstep
+}
+
+// NextDelay returns how long to wait before the given retry.
+//
+// Parameters:
+//   - attempt: Number of the retry, starting at 1
+//
+// Returns:
+//   - time.Duration: InitialBackoffMs * BackoffMultiplier^(attempt-1), capped at MaxBackoffMs, with jitter if enabled
+//
+// Example:
+//
+//	policy := RetryPolicy{InitialBackoffMs: 1000, BackoffMultiplier: 2, MaxBackoffMs: 5000}
+//	policy.NextDelay(1) // 1s
+//	policy.NextDelay(3) // 4s
+//	policy.NextDelay(4) // 5s
+func (rp RetryPolicy) NextDelay(attempt int) time.Duration {
+	return rp.nextDelay(attempt, nil)
+}
+
+// nextDelay implements NextDelay using the given random source for the jitter.
+//
+// Parameters:
+//   - attempt: Number of the retry, starting at 1
+//   - rng: Random source for the jitter, nil uses the global source
+//
+// Returns:
+//   - time.Duration: Delay before the retry
+func (rp RetryPolicy) nextDelay(attempt int, rng *rand.Rand) time.Duration {
+	initial := rp.InitialBackoffMs
+	if initial <= 0 {
+		initial = DEFAULT_RETRY_POLICY.InitialBackoffMs
+	}
+	multiplier := rp.BackoffMultiplier
+	if multiplier <= 0 {
+		multiplier = DEFAULT_RETRY_POLICY.BackoffMultiplier
+	}
+	maxBackoff := rp.MaxBackoffMs
+	if maxBackoff <= 0 {
+		maxBackoff = DEFAULT_RETRY_POLICY.MaxBackoffMs
+	}
+
+	delayMs := float64(initial)
+	for i := 1; i < attempt && delayMs < float64(maxBackoff); i++ {
+		delayMs *= multiplier
+	}
+	delayMs = min(delayMs, float64(maxBackoff))
+
+	if rp.Jitter {
+		var random float64
+		if rng != nil {
+			random = rng.Float64()
+		} else {
+			random = rand.Float64()
+		}
+		// Scale by a factor between 0.75 and 1.25
+		delayMs *= 1 - RETRY_JITTER_FRACTION + 2*RETRY_JITTER_FRACTION*random
+	}
+
+	return time.Duration(delayMs * float64(time.Millisecond))
+}
+
+// currentRetryPolicy returns the retry policy from the loaded settings.
+//
+// Returns:
+//   - RetryPolicy: Settings.RetryPolicy, or DEFAULT_RETRY_POLICY if settings are not loaded
+func currentRetryPolicy() RetryPolicy {
+	if UDMSettings != nil {
+		return UDMSettings.GetRetryPolicy()
+	}
+	return DEFAULT_RETRY_POLICY
+}
+
+// retryAfterDelay returns the delay requested by the server in the Retry-After header
+// of a 429 or 503 response.
+//
+// Parameters:
+//   - resp: The response to read the header from
+//
+// Returns:
+//   - time.Duration: Requested delay
+//   - bool: False if the header is missing or invalid
+func retryAfterDelay(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	// Delay in seconds
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	// HTTP date
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+
+	return 0, false
+}
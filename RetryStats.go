@@ -0,0 +1,64 @@
+package udm
+
+// RetryStats counts the retries made during a download
+type RetryStats struct {
+	TotalRetries  int         `json:"total_retries"`  // Retries of all kinds
+	ChunkRetries  map[int]int `json:"chunk_retries"`  // Retries by chunk index, single-stream downloads use index 0
+	ServerRetries int         `json:"server_retries"` // Retries of the metadata request during prefetch
+}
+
+// serverRetryIndex is passed to recordRetry for metadata request retries
+const serverRetryIndex = -1
+
+// recordRetry counts a retry in RetryStats and fires the OnRetry callback.
+//
+// Parameters:
+//   - chunkIndex: Index of the retried chunk, or serverRetryIndex for metadata requests
+//   - attempt: Number of the attempt that failed, starting at 1
+//   - err: The error that caused the retry
+func (d *Downloader) recordRetry(chunkIndex int, attempt int, err error) {
+	d.retryMu.Lock()
+	d.RetryStats.TotalRetries++
+	if chunkIndex == serverRetryIndex {
+		d.RetryStats.ServerRetries++
+	} else {
+		if d.RetryStats.ChunkRetries == nil {
+			d.RetryStats.ChunkRetries = make(map[int]int)
+		}
+		d.RetryStats.ChunkRetries[chunkIndex]++
+	}
+	d.retryMu.Unlock()
+
+	if chunkIndex == serverRetryIndex {
+		d.logEvent(EVENT_RETRY, "metadata request attempt %d failed: %v", attempt, err)
+	} else {
+		d.logEvent(EVENT_RETRY, "chunk %d attempt %d failed: %v", chunkIndex, attempt, err)
+	}
+
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnRetry != nil {
+		callbacks.OnRetry(d, chunkIndex, attempt, err)
+	}
+}
+
+// GetRetryStats returns a copy of the retry counters of the download.
+//
+// Returns:
+//   - RetryStats: Retry counters, ChunkRetries is never nil
+func (d *Downloader) GetRetryStats() RetryStats {
+	d.retryMu.Lock()
+	defer d.retryMu.Unlock()
+
+	stats := d.RetryStats
+	stats.ChunkRetries = make(map[int]int, len(d.RetryStats.ChunkRetries))
+	for index, count := range d.RetryStats.ChunkRetries {
+		stats.ChunkRetries[index] = count
+	}
+	return stats
+}
+
+// resetRetryStats clears the retry counters before a new download attempt
+func (d *Downloader) resetRetryStats() {
+	d.retryMu.Lock()
+	defer d.retryMu.Unlock()
+	d.RetryStats = RetryStats{}
+}
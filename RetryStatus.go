@@ -0,0 +1,117 @@
+package udm
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// DEFAULT_RETRY_STATUS_CODES are the HTTP status codes retried when
+// Settings.RetryStatusCodes is not configured
+var DEFAULT_RETRY_STATUS_CODES = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// isRetryableStatus reports whether a response with the given status code should be retried.
+//
+// Parameters:
+//   - statusCode: HTTP status code of the response
+//
+// Returns:
+//   - bool: True if the status code is in the configured retry list
+func (d *Downloader) isRetryableStatus(statusCode int) bool {
+	retryCodes := DEFAULT_RETRY_STATUS_CODES
+	if UDMSettings != nil {
+		retryCodes = UDMSettings.GetRetryStatusCodes()
+	}
+	return slices.Contains(retryCodes, statusCode)
+}
+
+// isRetryableError reports whether a failed request should be retried.
+// Only transient failures are retried: network errors like dropped or stalled connections,
+// responses the server cut short and server errors with one of the configured retry status
+// codes (5xx and 429 by default). Disk errors and any other failure are not retried.
+//
+// Parameters:
+//   - err: The error returned by the failed attempt
+//
+// Returns:
+//   - bool: True if the request should be retried
+func (d *Downloader) isRetryableError(err error) bool {
+	var diskErr *DiskError
+	if errors.As(err, &diskErr) {
+		return false
+	}
+
+	var serverErr *ServerError
+	if errors.As(err, &serverErr) {
+		return d.isRetryableStatus(serverErr.StatusCode)
+	}
+
+	var networkErr *NetworkError
+	var incompleteErr *IncompleteDownloadError
+	var netErr net.Error
+	return errors.As(err, &networkErr) || errors.As(err, &incompleteErr) || errors.As(err, &netErr)
+}
+
+// doWithStatusRetry sends a request and retries it while the server responds with a
+// retryable status code, up to the configured retry count. The request must not have a body.
+// The wait between attempts honors the Retry-After header and otherwise follows Settings.RetryPolicy.
+// A 429 Too Many Requests response pauses all requests to the same host, see recordRateLimit.
+//
+// Parameters:
+//   - ctx: Context for cancellation while waiting between attempts
+//   - client: HTTP client used to send the request
+//   - req: The request to send
+//
+// Returns:
+//   - *http.Response: The last response received, the caller must close its body
+//   - error: Error if the request failed or the context was cancelled
+func (d *Downloader) doWithStatusRetry(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error) {
+	maxRetries := d.getRetryCount()
+
+	for attempt := 0; ; attempt++ {
+		// Wait while the host is rate limiting this or another download
+		if err := waitForRateLimit(ctx, req.URL.Hostname()); err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if attempt >= maxRetries || !d.isRetryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		// Single-stream downloads are counted as chunk 0
+		d.recordRetry(0, attempt+1, &ServerError{StatusCode: resp.StatusCode, URL: req.URL.String()})
+
+		// Pause all requests to the host, the next attempt waits in waitForRateLimit
+		if resp.StatusCode == http.StatusTooManyRequests {
+			recordRateLimit(resp, attempt+1)
+			continue
+		}
+
+		// Give the server some time to recover before retrying, as long as it asked for
+		delay, ok := retryAfterDelay(resp)
+		if !ok {
+			delay = currentRetryPolicy().NextDelay(attempt + 1)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
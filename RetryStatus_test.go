@@ -0,0 +1,38 @@
+package udm
+
+import (
+	"errors"
+	"io"
+	"syscall"
+	"testing"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "dropped connection", err: &NetworkError{Op: "read", Host: "example.com", Err: io.ErrUnexpectedEOF}, want: true},
+		{name: "stalled connection", err: &idleTimeoutError{}, want: true},
+		{name: "incomplete response", err: &IncompleteDownloadError{Expected: 10, Got: 5}, want: true},
+		{name: "service unavailable", err: &ServerError{StatusCode: 503}, want: true},
+		{name: "too many requests", err: &ServerError{StatusCode: 429}, want: true},
+		{name: "not found", err: &ServerError{StatusCode: 404}, want: false},
+		{name: "disk full", err: &DiskError{Op: "write", Path: "chunk_0", Err: syscall.ENOSPC}, want: false},
+		{name: "other error", err: errors.New("unexpected"), want: false},
+	}
+
+	previous := UDMSettings
+	defer func() { UDMSettings = previous }()
+	UDMSettings = &Settings{}
+
+	d := &Downloader{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := d.isRetryableError(tt.err); got != tt.want {
+				t.Errorf("isRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package udm
+
+import "time"
+
+// ROLLING_SPEED_WINDOW is the period covered by the rolling average download speed
+const ROLLING_SPEED_WINDOW = 5 * time.Second
+
+// speedRingSize is the capacity of the speed sample ring buffer,
+// enough for the whole window at the minimum sample interval
+const speedRingSize = 64
+
+// minSpeedSampleInterval is the minimum time between two speed samples
+const minSpeedSampleInterval = 100 * time.Millisecond
+
+// speedSample is the number of bytes downloaded at a point in time
+type speedSample struct {
+	at    time.Time
+	bytes int64 // Cumulative bytes downloaded at that time
+}
+
+// speedRing is a fixed size ring buffer of speed samples, oldest first
+type speedRing struct {
+	samples [speedRingSize]speedSample
+	start   int // Index of the oldest sample
+	count   int // Number of samples stored
+}
+
+// at returns the i-th oldest sample.
+func (r *speedRing) at(i int) speedSample {
+	return r.samples[(r.start+i)%speedRingSize]
+}
+
+// push appends a sample, overwriting the oldest one when the buffer is full.
+func (r *speedRing) push(sample speedSample) {
+	if r.count == speedRingSize {
+		r.start = (r.start + 1) % speedRingSize
+		r.count--
+	}
+	r.samples[(r.start+r.count)%speedRingSize] = sample
+	r.count++
+}
+
+// dropOldest removes the oldest sample.
+func (r *speedRing) dropOldest() {
+	r.start = (r.start + 1) % speedRingSize
+	r.count--
+}
+
+// recordSpeedSample adds the current byte count to the rolling window and
+// drops samples older than ROLLING_SPEED_WINDOW. The caller must hold pt.mu.
+//
+// Parameters:
+//   - now: Time of the sample
+func (pt *ProgressTracker) recordSpeedSample(now time.Time) {
+	ring := &pt.speedSamples
+
+	// Samples closer together than the interval add noise without information
+	if ring.count > 0 && now.Sub(ring.at(ring.count-1).at) < minSpeedSampleInterval {
+		return
+	}
+
+	ring.push(speedSample{at: now, bytes: pt.BytesCompleted})
+
+	for ring.count > 2 && now.Sub(ring.at(0).at) > ROLLING_SPEED_WINDOW {
+		ring.dropOldest()
+	}
+}
+
+// rollingSpeed returns the average speed over the samples in the window.
+// The caller must hold pt.mu.
+//
+// Returns:
+//   - float64: Speed in bytes per second, 0 until two samples are recorded
+func (pt *ProgressTracker) rollingSpeed() float64 {
+	ring := &pt.speedSamples
+	if ring.count < 2 {
+		return 0
+	}
+
+	oldest, newest := ring.at(0), ring.at(ring.count-1)
+	elapsed := newest.at.Sub(oldest.at).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(newest.bytes-oldest.bytes) / elapsed
+}
+
+// updateSpeed records a sample and recalculates SpeedBps, SmoothedSpeedBps,
+// AverageSpeedBps and ETA from BytesCompleted. The caller must hold pt.mu.
+//
+// Parameters:
+//   - now: Time of the update
+//   - totalSize: Total file size (0 if unknown)
+func (pt *ProgressTracker) updateSpeed(now time.Time, totalSize int64) {
+	pt.recordSpeedSample(now)
+	pt.SpeedBps = pt.rollingSpeed()
+	if pt.SpeedBps > 0 {
+		pt.updateSmoothedSpeed(pt.SpeedBps)
+	}
+
+	// Calculate average speed since start
+	if !pt.StartTime.IsZero() {
+		if totalElapsed := now.Sub(pt.StartTime).Seconds(); totalElapsed > 0 {
+			pt.AverageSpeedBps = int64(float64(pt.BytesCompleted) / totalElapsed)
+		}
+	}
+
+	// Calculate ETA if we have speed and total size
+	if pt.SpeedBps > 0 && totalSize > 0 && pt.BytesCompleted < totalSize {
+		remainingBytes := totalSize - pt.BytesCompleted
+		etaSeconds := float64(remainingBytes) / pt.SpeedBps
+		pt.ETA = time.Duration(etaSeconds) * time.Second
+	}
+}
+
+// RollingSpeedBps returns the average download speed over the last ROLLING_SPEED_WINDOW.
+// Unlike a speed measured over a single interval it doesn't swing wildly at startup,
+// it is the value used for SpeedBps and the ETA.
+//
+// Returns:
+//   - float64: Speed in bytes per second, 0 until enough samples are recorded
+//
+// Example:
+//
+//	speed := downloader.Progress.RollingSpeedBps()
+//	fmt.Printf("Speed: %s/s\n", ReadableFileSize(int64(speed)))
+func (pt *ProgressTracker) RollingSpeedBps() float64 {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	return pt.rollingSpeed()
+}
@@ -0,0 +1,129 @@
+package udm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SERVER_DATA_CACHE_TTL is how long GetServerDataBatch reuses metadata fetched for a URL
+const SERVER_DATA_CACHE_TTL = 60 * time.Second
+
+// DEFAULT_BATCH_CONCURRENCY is the number of parallel requests used when no concurrency is given
+const DEFAULT_BATCH_CONCURRENCY = 4
+
+// ServerDataResult is the outcome of fetching the metadata of one URL
+type ServerDataResult struct {
+	Data *ServerData // Metadata of the file, nil if the request failed
+	Err  error       // Error of the request, nil on success
+}
+
+// cachedServerData is an entry of serverDataCache
+type cachedServerData struct {
+	data    ServerData
+	expires time.Time
+}
+
+// serverDataCache holds successfully fetched metadata by URL for SERVER_DATA_CACHE_TTL
+var serverDataCache sync.Map
+
+// GetServerDataBatch fetches the metadata of many URLs in parallel, e.g. to prefetch a queue.
+// Each distinct URL is requested only once, and metadata fetched in the last 60 seconds is reused.
+//
+// Parameters:
+//   - ctx: Context for cancellation of all requests
+//   - urls: URLs to fetch, duplicates are fetched once
+//   - concurrency: Maximum number of requests in flight, 0 or less for DEFAULT_BATCH_CONCURRENCY
+//
+// Returns:
+//   - map[string]*ServerDataResult: Result for every distinct URL
+//
+// Example:
+//
+//	results := GetServerDataBatch(ctx, []string{
+//		"https://example.com/a.zip",
+//		"https://example.com/b.zip",
+//	}, 4)
+//	for url, result := range results {
+//		if result.Err != nil {
+//			fmt.Println(url, "failed:", result.Err)
+//			continue
+//		}
+//		fmt.Println(url, result.Data.Filesize)
+//	}
+//
+// Notes:
+//   - Failed requests are not cached, they are retried by the next call
+//   - Every result holds its own copy of the metadata, changing it doesn't affect the cache
+func GetServerDataBatch(ctx context.Context, urls []string, concurrency int) map[string]*ServerDataResult {
+	if concurrency <= 0 {
+		concurrency = DEFAULT_BATCH_CONCURRENCY
+	}
+
+	results := make(map[string]*ServerDataResult, len(urls))
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, concurrency)
+
+	for _, rawURL := range urls {
+		// Deduplicate, each goroutine only fills in its own result
+		if _, seen := results[rawURL]; seen {
+			continue
+		}
+		result := &ServerDataResult{}
+		results[rawURL] = result
+
+		if data, ok := cachedServerDataFor(rawURL); ok {
+			result.Data = data
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				result.Err = ctx.Err()
+				return
+			}
+			defer func() { <-semaphore }()
+
+			data, err := GetServerDataWithContext(ctx, rawURL)
+			if err != nil {
+				result.Err = err
+				return
+			}
+
+			serverDataCache.Store(rawURL, cachedServerData{data: *data, expires: time.Now().Add(SERVER_DATA_CACHE_TTL)})
+			result.Data = data
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
+
+// cachedServerDataFor returns the cached metadata of a URL if it has not expired.
+//
+// Parameters:
+//   - rawURL: The URL to look up
+//
+// Returns:
+//   - *ServerData: Copy of the cached metadata
+//   - bool: False if there is no valid cache entry
+func cachedServerDataFor(rawURL string) (*ServerData, bool) {
+	value, ok := serverDataCache.Load(rawURL)
+	if !ok {
+		return nil, false
+	}
+
+	entry := value.(cachedServerData)
+	if time.Now().After(entry.expires) {
+		serverDataCache.Delete(rawURL)
+		return nil, false
+	}
+
+	data := entry.data
+	return &data, true
+}
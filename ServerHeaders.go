@@ -1,12 +1,16 @@
 package udm
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"mime"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -25,12 +29,22 @@ import (
 //   - Filetype: The type of the file
 //   - AcceptsRanges: Boolean indicating if the server accepts range requests
 //   - FinalURL: The final URL of the file after following redirects
+//   - IsFallbackName: True if Filename was generated because the server provided none
+//   - ETag: The ETag validator of the file (empty if not provided)
+//   - LastModified: The Last-Modified validator of the file (zero if not provided or invalid)
+//   - Changed: False if the server confirmed the file is unchanged since the previous request (304)
+//   - RedirectChain: The URLs redirected to in order, ending with FinalURL (empty without redirects)
 type ServerData struct {
-	Filename      string
-	Filesize      int64
-	Filetype      string
-	AcceptsRanges bool
-	FinalURL      string
+	Filename       string
+	Filesize       int64
+	Filetype       string
+	AcceptsRanges  bool
+	FinalURL       string
+	IsFallbackName bool
+	ETag           string
+	LastModified   time.Time
+	Changed        bool
+	RedirectChain  []string
 }
 
 /*
@@ -82,6 +96,8 @@ type ServerData struct {
 //
 // Parameters:
 //   - downloadURL: The URL of the file to download
+//   - existingData: Optional data from a previous request, its ETag and Last-Modified
+//     are sent as validators and it is returned with Changed set to false on 304 Not Modified
 //
 // Returns:
 //   - *ServerData: A struct containing the filename, filesize, file type, accepts range requests, and final URL of the server
@@ -91,7 +107,7 @@ type ServerData struct {
 //
 //	func main(){
 //		url := "https://example.com/sample.pdf"
-//		info, err := getServerData(url)
+//		info, err := GetServerData(url)
 //
 //		if err != nil {
 //			fmt.Println("Error:", err)
@@ -104,23 +120,101 @@ type ServerData struct {
 //		fmt.Printf("Accepts Range Requests: %v\n", info.AcceptsRanges)
 //		fmt.Printf("Final URL after redirect: %s\n", info.FinalURL)
 //	}
-func GetServerData(downloadURL string) (*ServerData, error) {
+func GetServerData(downloadURL string, existingData ...*ServerData) (*ServerData, error) {
+	return GetServerDataWithContext(context.Background(), downloadURL, existingData...)
+}
+
+// GetServerDataWithContext works like GetServerData but the requests are bound to ctx,
+// so a slow or hanging metadata request can be cancelled, e.g. when a queued download is cancelled.
+//
+// Parameters:
+//   - ctx: Context for cancellation, no further attempts are made once it is done
+//   - downloadURL: The URL of the file to download
+//   - existingData: Optional data from a previous request, see GetServerData
+//
+// Returns:
+//   - *ServerData: A struct containing the server data
+//   - error: An error message if the function fails or the context is done
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//
+//	info, err := GetServerDataWithContext(ctx, "https://example.com/sample.pdf")
+//	if err != nil {
+//		fmt.Println("Error:", err)
+//		return
+//	}
+func GetServerDataWithContext(ctx context.Context, downloadURL string, existingData ...*ServerData) (*ServerData, error) {
+	var existing *ServerData
+	if len(existingData) > 0 {
+		existing = existingData[0]
+	}
+
+	// Send credentials in the URL as an Authorization header
+	var auth *url.Userinfo
+	downloadURL, user, pass := extractCredentialsFromURL(downloadURL)
+	if user != "" || pass != "" {
+		auth = url.UserPassword(user, pass)
+	}
+
+	return getServerData(ctx, downloadURL, nil, existing, auth, nil, nil, nil)
+}
+
+// getServerData implements GetServerData using the given random source for retry jitter.
+// Passing a per-downloader source ensures simultaneous downloads don't retry in lockstep.
+//
+// Parameters:
+//   - ctx: Context for cancellation of the requests and the wait between attempts
+//   - downloadURL: The URL of the file to download
+//   - rng: Random source for the retry jitter, nil uses the global source
+//   - existing: Data from a previous request for a conditional request, nil to always fetch fresh data
+//   - auth: Basic Auth credentials to send, nil for none
+//   - prefs: Preferences for the redirect limit and the default HTTP client, nil for the defaults
+//   - base: Client whose transport sends the requests, nil for a client created from prefs
+//   - onRetry: Called with the failed attempt number and error before each retry, may be nil
+//
+// Returns:
+//   - *ServerData: A struct containing the server data
+//   - error: An error message if all attempts fail
+func getServerData(ctx context.Context, downloadURL string, rng *rand.Rand, existing *ServerData, auth *url.Userinfo, prefs *UserPreferences, base *http.Client, onRetry func(attempt int, err error)) (*ServerData, error) {
 	const maxRetries = 3
 	var lastErr error
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		data, err := tryGetServerData(downloadURL)
+		data, err := tryGetServerData(ctx, downloadURL, existing, auth, prefs, base)
 		if err == nil {
 			return data, nil
 		}
 		lastErr = err
-		fmt.Printf("Error on attempt %d: %v\n", attempt, err)
+
+		// A redirect loop doesn't go away by retrying
+		if errors.Is(err, ErrTooManyRedirects) {
+			return nil, err
+		}
+
+		// Don't retry once the caller has given up
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		logf("Error on attempt %d: %v\n", attempt, err)
 		if attempt < maxRetries {
-			time.Sleep(2 * time.Second) // short wait before retry
+			if onRetry != nil {
+				onRetry(attempt, err)
+			}
+
+			// Back off before retrying, as configured by Settings.RetryPolicy
+			select {
+			case <-time.After(currentRetryPolicy().nextDelay(attempt, rng)):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
 		}
 	}
 
-	return nil, fmt.Errorf("failed after %d attempts: %v", maxRetries, lastErr)
+	return nil, fmt.Errorf("failed after %d attempts: %w", maxRetries, lastErr)
 }
 
 // tryGetServerData attempts to retrieve server data using a HEAD request, falling back to a GET request if necessary
@@ -128,12 +222,18 @@ func GetServerData(downloadURL string) (*ServerData, error) {
 // Working:
 //   - The function takes a downloadURL as input
 //   - The function makes a HEAD request to the provided downloadURL
-//   - If the HEAD request fails, it makes a GET request to the provided downloadURL
+//   - If the HEAD request fails or returns a status >= 400, it makes a GET request for only the
+//     first byte (Range: bytes=0-0), the size is then taken from the Content-Range header
 //   - If the request is successful, it returns the server data
 //   - If the request fails, it returns an error message
 //
 // Parameters:
+//   - ctx: Context for cancellation of the request
 //   - downloadURL: The URL of the file to download
+//   - existing: Data from a previous request for a conditional request, nil to always fetch fresh data
+//   - auth: Basic Auth credentials to send, nil for none
+//   - prefs: Preferences for the redirect limit and the default HTTP client, nil for the defaults
+//   - base: Client whose transport sends the requests, nil for a client created from prefs
 //
 // Returns:
 //   - *ServerData: A struct containing the filename, filesize, file type, accepts range requests, and final URL of the server
@@ -143,7 +243,7 @@ func GetServerData(downloadURL string) (*ServerData, error) {
 //
 //	func main(){
 //		url := "https://example.com/sample.pdf"
-//		data, err := tryGetServerData(url)
+//		data, err := tryGetServerData(context.Background(), url, nil, nil, nil, nil)
 //
 //		if err != nil {
 //			fmt.Println("Error:", err)
@@ -156,47 +256,86 @@ func GetServerData(downloadURL string) (*ServerData, error) {
 //		fmt.Printf("Accepts Range Requests: %v\n", data.AcceptsRanges)
 //		fmt.Printf("Final URL after redirect: %s\n", data.FinalURL)
 //	}
-func tryGetServerData(downloadURL string) (*ServerData, error) {
+func tryGetServerData(ctx context.Context, downloadURL string, existing *ServerData, auth *url.Userinfo, prefs *UserPreferences, base *http.Client) (*ServerData, error) {
+	if prefs == nil {
+		prefs = &UserPreferences{}
+	}
+	if base == nil {
+		base = makeHTTPClient(prefs)
+	}
+
+	// Share the transport of the download, so a custom transport and the TLS policy apply to metadata requests too
+	var redirectChain []string
 	client := &http.Client{
-		Timeout: 15 * time.Second,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return nil
-		},
+		Transport:     base.Transport,
+		Timeout:       15 * time.Second,
+		CheckRedirect: redirectPolicy(prefs, &redirectChain),
 	}
 
 	// 1. Try HEAD request
-	req, err := http.NewRequest("HEAD", downloadURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "HEAD", downloadURL, nil)
 	if err != nil {
 		return nil, err
 	}
+	setBasicAuth(req, auth)
+
+	// Send validators of the previous response for a conditional request
+	if existing != nil {
+		if existing.ETag != "" {
+			req.Header.Set("If-None-Match", existing.ETag)
+		}
+		if !existing.LastModified.IsZero() {
+			req.Header.Set("If-Modified-Since", existing.LastModified.UTC().Format(http.TimeFormat))
+		}
+	}
+
 	resp, err := client.Do(req)
-	if err == nil && resp.StatusCode >= 400 {
+	if err != nil {
+		err = &NetworkError{Op: "request", Host: req.URL.Host, Err: err}
+	}
+	if err == nil && resp.StatusCode == http.StatusNotModified && existing != nil {
+		resp.Body.Close()
 
-		// Dont use the GET fallback if the server is returning a 400
-		return nil, fmt.Errorf("invalid response code after HEAD: %d", resp.StatusCode)
-		
-		//// 2. Fallback to GET request
-		//reqGet, err := http.NewRequest("GET", downloadURL, nil)
-		//if err != nil {
-		//	return nil, err
-		//}
-		//resp, err = client.Do(reqGet)
-		//if err != nil {
-		//	return nil, err
-		//}
-		//if resp.StatusCode >= 400 {
-		//	return nil, fmt.Errorf("invalid response code after GET fallback: %d", resp.StatusCode)
-		//}
-	} else if err != nil {
+		// File is unchanged, reuse the previous data
+		unchanged := *existing
+		unchanged.Changed = false
+		return &unchanged, nil
+	}
+	if err == nil && resp.StatusCode >= 400 {
+		resp.Body.Close()
+	}
+	if errors.Is(err, ErrTooManyRedirects) {
 		return nil, err
 	}
+	if err != nil || resp.StatusCode >= 400 {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		// Record only the redirects of the GET request
+		redirectChain = nil
+
+		// 2. Fallback to GET for servers that reject or don't implement HEAD
+		headErr := err
+		if headErr == nil {
+			headErr = &ServerError{StatusCode: resp.StatusCode, URL: downloadURL}
+		}
+		resp, err = getFirstByte(ctx, client, downloadURL, auth)
+		if err != nil {
+			return nil, fmt.Errorf("HEAD failed (%v) and GET fallback failed: %w", headErr, err)
+		}
+	}
 
 	defer resp.Body.Close()
 
 	finalURL := resp.Request.URL.String()
 
 	data := &ServerData{
-		FinalURL: finalURL,
+		FinalURL:      finalURL,
+		ETag:          resp.Header.Get("ETag"),
+		LastModified:  parseLastModified(resp.Header.Get("Last-Modified")),
+		Changed:       true,
+		RedirectChain: redirectChain,
 	}
 
 	// 3. Content-Disposition based filename
@@ -226,9 +365,13 @@ func tryGetServerData(downloadURL string) (*ServerData, error) {
 		}
 	}
 
-	// 5. Content-Length
+	// 5. Content-Length, or the total size in Content-Range for the 206 response of the GET fallback
 	cl := resp.Header.Get("Content-Length")
-	if cl != "" {
+	if resp.StatusCode == http.StatusPartialContent {
+		if size, ok := contentRangeSize(resp.Header.Get("Content-Range")); ok {
+			data.Filesize = size
+		}
+	} else if cl != "" {
 		var size int64
 		fmt.Sscanf(cl, "%d", &size)
 		data.Filesize = size
@@ -237,8 +380,8 @@ func tryGetServerData(downloadURL string) (*ServerData, error) {
 	// 6. Content-Type
 	data.Filetype = resp.Header.Get("Content-Type")
 
-	// 7. Accept-Ranges
-	if strings.Contains(resp.Header.Get("Accept-Ranges"), "bytes") {
+	// 7. Accept-Ranges, a 206 response to the GET fallback proves range support
+	if strings.Contains(resp.Header.Get("Accept-Ranges"), "bytes") || resp.StatusCode == http.StatusPartialContent {
 		data.AcceptsRanges = true
 	}
 
@@ -246,16 +389,67 @@ func tryGetServerData(downloadURL string) (*ServerData, error) {
 	if data.Filename == "" {
 		ext := mimeExtensionFromContentType(data.Filetype)
 		data.Filename = "downloaded_file" + ext
+		data.IsFallbackName = true
 	}
 
-	// If GET was used, discard the partial body
+	// If GET was used, read the requested byte, the rest of a 200 response is not downloaded
 	if resp.Request.Method == "GET" {
-		io.Copy(io.Discard, resp.Body)
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 1))
 	}
 
 	return data, nil
 }
 
+// getFirstByte requests only the first byte of a file, used when the server rejects HEAD requests.
+//
+// Parameters:
+//   - ctx: Context for cancellation of the request
+//   - client: HTTP client used for the HEAD request
+//   - downloadURL: The URL of the file to download
+//   - auth: Basic Auth credentials to send, nil for none
+//
+// Returns:
+//   - *http.Response: The response, 206 if the server supports ranges or 200 with the whole file
+//   - error: Error if the request fails or the server responds with a status >= 400
+func getFirstByte(ctx context.Context, client *http.Client, downloadURL string, auth *url.Userinfo) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	setBasicAuth(req, auth)
+	req.Header.Set("Range", "bytes=0-0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &NetworkError{Op: "request", Host: req.URL.Host, Err: err}
+	}
+	if resp.StatusCode >= 400 {
+		resp.Body.Close()
+		return nil, &ServerError{StatusCode: resp.StatusCode, URL: downloadURL}
+	}
+	return resp, nil
+}
+
+// contentRangeSize returns the total size from a Content-Range header like "bytes 0-0/4096".
+//
+// Parameters:
+//   - contentRange: Header value
+//
+// Returns:
+//   - int64: Total size of the file
+//   - bool: False if the header is missing, malformed or the size is unknown ("*")
+func contentRangeSize(contentRange string) (int64, bool) {
+	_, total, found := strings.Cut(contentRange, "/")
+	if !found {
+		return 0, false
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(total), 10, 64)
+	if err != nil || size < 0 {
+		return 0, false
+	}
+	return size, true
+}
+
 // mimeExtensionFromContentType extracts the file extension from a Content-Type header
 //
 // Working:
@@ -0,0 +1,138 @@
+package udm
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newHeadRejectingServer serves content to GET requests and answers HEAD requests with 405.
+// Range requests are honored only if supportRanges is set, recording the Range header of each GET.
+func newHeadRejectingServer(t *testing.T, content []byte, supportRanges bool) (*httptest.Server, *[]string) {
+	var mu sync.Mutex
+	var ranges []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/zip")
+		if supportRanges {
+			http.ServeContent(w, r, "archive.zip", time.Time{}, bytes.NewReader(content))
+			return
+		}
+
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.Write(content)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &ranges
+}
+
+func TestGetServerDataFallsBackToRangedGet(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	server, ranges := newHeadRejectingServer(t, content, true)
+
+	data, err := GetServerDataWithContext(context.Background(), server.URL+"/files/archive.zip")
+	if err != nil {
+		t.Fatalf("GetServerDataWithContext failed: %v", err)
+	}
+
+	if data.Filesize != int64(len(content)) {
+		t.Errorf("Filesize = %d, want %d from Content-Range", data.Filesize, len(content))
+	}
+	if !data.AcceptsRanges {
+		t.Error("AcceptsRanges = false, want true for a 206 response")
+	}
+	if data.Filename != "archive.zip" {
+		t.Errorf("Filename = %q, want %q", data.Filename, "archive.zip")
+	}
+	if data.Filetype != "application/zip" {
+		t.Errorf("Filetype = %q, want %q", data.Filetype, "application/zip")
+	}
+	if len(*ranges) != 1 || (*ranges)[0] != "bytes=0-0" {
+		t.Errorf("GET requests sent Range headers %q, want a single \"bytes=0-0\"", *ranges)
+	}
+}
+
+func TestGetServerDataFallsBackToGetWithoutRanges(t *testing.T) {
+	content := bytes.Repeat([]byte("x"), 64*1024)
+	server, _ := newHeadRejectingServer(t, content, false)
+
+	data, err := GetServerDataWithContext(context.Background(), server.URL+"/archive.zip")
+	if err != nil {
+		t.Fatalf("GetServerDataWithContext failed: %v", err)
+	}
+
+	if data.Filesize != int64(len(content)) {
+		t.Errorf("Filesize = %d, want %d from Content-Length", data.Filesize, len(content))
+	}
+	if data.AcceptsRanges {
+		t.Error("AcceptsRanges = true, want false for a 200 response")
+	}
+}
+
+func TestContentRangeSize(t *testing.T) {
+	tests := []struct {
+		header string
+		want   int64
+		wantOK bool
+	}{
+		{header: "bytes 0-0/4096", want: 4096, wantOK: true},
+		{header: "bytes 0-0/ 12 ", want: 12, wantOK: true},
+		{header: "bytes 0-0/*", wantOK: false},
+		{header: "bytes 0-0", wantOK: false},
+		{header: "bytes 0-0/-1", wantOK: false},
+		{header: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := contentRangeSize(tt.header)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("contentRangeSize(%q) = %d, %v, want %d, %v", tt.header, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// countingTransport counts the requests sent through it
+type countingTransport struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.requests = append(t.requests, req.Method)
+	t.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestPrefetchUsesCustomTransport(t *testing.T) {
+	content := bytes.Repeat([]byte("x"), 1024)
+	server, _ := newHeadRejectingServer(t, content, true)
+
+	transport := &countingTransport{}
+	d := &Downloader{Url: server.URL + "/archive.zip"}
+	d.Prefs.DownloadDir = t.TempDir()
+	d.UseTransport(transport)
+
+	if err := d.Prefetch(); err != nil {
+		t.Fatalf("Prefetch failed: %v", err)
+	}
+
+	if len(transport.requests) != 2 || transport.requests[0] != http.MethodHead || transport.requests[1] != http.MethodGet {
+		t.Errorf("custom transport saw %v, want the HEAD request and the GET fallback", transport.requests)
+	}
+}
@@ -0,0 +1,86 @@
+package udm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// SETTINGS_WATCH_INTERVAL is how often Watch checks the config file for changes
+const SETTINGS_WATCH_INTERVAL = 5 * time.Second
+
+// Watch polls the config file at path and calls onChange with the newly parsed settings
+// whenever its modification time changes. UDMSettings is not updated automatically,
+// the caller decides whether and when to apply the new settings, e.g. only to
+// downloads started afterwards.
+//
+// Parameters:
+//   - ctx: Context for cancellation, watching stops when it is done
+//   - path: Path of the JSON config file to watch
+//   - onChange: Called from the watching goroutine with the new settings
+//
+// Returns:
+//   - func(): Stops watching, safe to call more than once
+//   - error: Error if the config file cannot be accessed
+//
+// Example:
+//
+//	stop, err := UDMSettings.Watch(ctx, CONFIG_FILE_PATH, func(s *Settings) {
+//		UDMSettings = s // Apply to downloads started from now on
+//	})
+//	if err != nil {
+//		log.Fatal("Failed to watch settings:", err)
+//	}
+//	defer stop()
+//
+// Notes:
+//   - Changes are detected within SETTINGS_WATCH_INTERVAL
+//   - A file that fails to parse is retried on the next poll, onChange is not called
+func (s *Settings) Watch(ctx context.Context, path string, onChange func(*Settings)) (func(), error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch settings: %v", err)
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	go watchSettingsFile(watchCtx, path, info.ModTime(), onChange)
+
+	return cancel, nil
+}
+
+// watchSettingsFile polls the config file until ctx is done.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - path: Path of the config file
+//   - lastModTime: Modification time of the currently loaded version
+//   - onChange: Called with the settings of each new version
+func watchSettingsFile(ctx context.Context, path string, lastModTime time.Time, onChange func(*Settings)) {
+	ticker := time.NewTicker(SETTINGS_WATCH_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil || info.ModTime().Equal(lastModTime) {
+				continue
+			}
+
+			settings, err := LoadSettings(path)
+			if err != nil {
+				// The file may still be being written, try again on the next poll
+				logf("Failed to reload settings: %v\n", err)
+				continue
+			}
+
+			lastModTime = info.ModTime()
+			if onChange != nil {
+				onChange(settings)
+			}
+		}
+	}
+}
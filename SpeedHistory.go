@@ -0,0 +1,112 @@
+package udm
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// maxHistorySamples bounds the history buffer, one hour of samples at one sample per second
+const maxHistorySamples = 3600
+
+// ProgressSample is a snapshot of the download progress at a point in time
+type ProgressSample struct {
+	Timestamp      time.Time // When the sample was taken
+	BytesCompleted int64     // Total bytes downloaded at that time
+	SpeedBps       float64   // Speed in bytes per second at that time
+	Percentage     float64   // Completion percentage (0-100) at that time
+}
+
+// addHistorySample appends the current progress to the history buffer.
+// The oldest sample is dropped once the buffer is full.
+// The caller must hold pt.mu.
+//
+// Parameters:
+//   - now: Time of the sample
+func (pt *ProgressTracker) addHistorySample(now time.Time) {
+	if len(pt.HistoryBuffer) >= maxHistorySamples {
+		pt.HistoryBuffer = pt.HistoryBuffer[1:]
+	}
+
+	pt.HistoryBuffer = append(pt.HistoryBuffer, ProgressSample{
+		Timestamp:      now,
+		BytesCompleted: pt.BytesCompleted,
+		SpeedBps:       pt.SpeedBps,
+		Percentage:     pt.Percentage,
+	})
+}
+
+// GetSpeedHistory returns a copy of the recorded progress samples.
+//
+// Returns:
+//   - []ProgressSample: Samples in chronological order (empty if none recorded)
+func (d *Downloader) GetSpeedHistory() []ProgressSample {
+	if d.Progress == nil {
+		return nil
+	}
+
+	d.Progress.mu.Lock()
+	defer d.Progress.mu.Unlock()
+
+	history := make([]ProgressSample, len(d.Progress.HistoryBuffer))
+	copy(history, d.Progress.HistoryBuffer)
+	return history
+}
+
+// ExportSpeedHistory writes the recorded progress samples to a CSV file.
+// The file starts with a header row followed by one row per sample with
+// both the unix timestamp and the RFC 3339 timestamp of the sample.
+//
+// Parameters:
+//   - csvPath: Path of the CSV file to create (overwritten if it exists)
+//
+// Returns:
+//   - error: Error if the file cannot be written
+//
+// Example:
+//
+//	downloader.Callbacks = &Callbacks{
+//		OnFinish: func(d *Downloader) {
+//			if err := d.ExportSpeedHistory("speed.csv"); err != nil {
+//				fmt.Println("Error:", err)
+//			}
+//		},
+//	}
+func (d *Downloader) ExportSpeedHistory(csvPath string) error {
+	history := d.GetSpeedHistory()
+
+	file, err := os.Create(csvPath)
+	if err != nil {
+		return fmt.Errorf("failed to create csv file: %v", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	header := []string{"timestamp_unix", "timestamp", "bytes_completed", "speed_bps", "percentage"}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write csv header: %v", err)
+	}
+
+	for _, sample := range history {
+		row := []string{
+			strconv.FormatInt(sample.Timestamp.Unix(), 10),
+			sample.Timestamp.Format(time.RFC3339),
+			strconv.FormatInt(sample.BytesCompleted, 10),
+			strconv.FormatFloat(sample.SpeedBps, 'f', 2, 64),
+			strconv.FormatFloat(sample.Percentage, 'f', 2, 64),
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write csv row: %v", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush csv file: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package udm
+
+// DEFAULT_SMOOTHING_FACTOR is the weight of the newest speed sample used when
+// ProgressTracker.SmoothingFactor is not set
+const DEFAULT_SMOOTHING_FACTOR = 0.3
+
+// updateSmoothedSpeed folds a new speed sample into SmoothedSpeedBps using an
+// exponential moving average: smoothed = α * instant + (1-α) * smoothed.
+// The first sample is used as is so the average doesn't start from zero.
+// The caller must hold pt.mu.
+//
+// Parameters:
+//   - instantSpeed: The latest measured speed in bytes per second
+func (pt *ProgressTracker) updateSmoothedSpeed(instantSpeed float64) {
+	alpha := pt.SmoothingFactor
+	if alpha <= 0 || alpha > 1 {
+		alpha = DEFAULT_SMOOTHING_FACTOR
+	}
+
+	if pt.SmoothedSpeedBps == 0 {
+		pt.SmoothedSpeedBps = instantSpeed
+		return
+	}
+
+	pt.SmoothedSpeedBps = alpha*instantSpeed + (1-alpha)*pt.SmoothedSpeedBps
+}
+
+// GetSmoothedSpeed returns the download speed smoothed with an exponential moving average.
+// Use it for display instead of SpeedBps which can jump between readings.
+//
+// Returns:
+//   - float64: Smoothed speed in bytes per second
+//
+// Example:
+//
+//	speed := downloader.Progress.GetSmoothedSpeed()
+//	fmt.Printf("Speed: %.2f KB/s\n", speed/1024)
+func (pt *ProgressTracker) GetSmoothedSpeed() float64 {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	return pt.SmoothedSpeedBps
+}
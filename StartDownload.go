@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"github.com/utsav-56/ulog"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
+	"udl/udm/ratelimit"
+	"udl/udm/ufs"
 )
 
 // StartDownload initiates the download process by analyzing server capabilities
@@ -25,6 +28,15 @@ import (
 //   - User preference handling with config fallbacks
 //   - Error handling and recovery
 func (d *Downloader) StartDownload() {
+	// Let Wait return however the download ends
+	d.prepareDone()
+	defer d.markDone()
+
+	// Forget the error of a previous attempt
+	d.Error = nil
+	d.ErrorCode = ""
+	d.SkippedUnchanged = false
+	d.replaceOutput = false
 
 	// Initialize context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
@@ -32,25 +44,45 @@ func (d *Downloader) StartDownload() {
 	d.cancelFunc = cancel
 	d.isStopped = false
 
+	// Send credentials in the URL as an Authorization header instead,
+	// before the URL is recorded anywhere
+	d.applyURLCredentials()
+
+	// Initialize download session, errors from here on go through handleDownloadError
+	if err := d.initializeDownload(); err != nil {
+		d.handleDownloadError(err)
+		return
+	}
+
+	// Trace the whole download if a tracer is set, after initializeDownload assigned the ID
+	d.startDownloadSpan()
+
+	// Reject invalid URLs before any network call
+	if err := ValidateURL(d.requestURL()); err != nil {
+		ulog.Error(err.Error(), "UDM_START_DOWNLOAD_ERROR")
+		d.handleDownloadError(err)
+		return
+	}
+
 	// Initialize settings if not already loaded
 	if UDMSettings == nil {
 		if err := InitializeSettings(); err != nil {
-			d.handleDownloadError(fmt.Errorf("failed to load settings: %v", err))
+			d.handleDownloadError(fmt.Errorf("failed to load settings: %w", err))
 			return
 		}
 	}
 
-	if d.Url == "" {
-		//d.handleDownloadError(fmt.Errorf("no download URL provided"))
-		ulog.Error("No download URL provided", "UDM_START_DOWNLOAD_ERROR")
+	// Count retries of this download only
+	d.resetRetryStats()
+	d.hasElevated = false
 
-		return
-	}
+	// One limiter for the whole download so all streams share the speed cap
+	d.speedLimiter = ratelimit.NewTokenBucketLimiter(d.Prefs.MaxSpeedBps)
 
-	// Initialize download session
-	if err := d.initializeDownload(); err != nil {
-		d.handleDownloadError(err)
-		return
+	// JSON lines replace the progress bar, which needs a TTY
+	if d.Prefs.JSONProgressMode {
+		d.UseProgressBar = false
+		d.Progress.ShowProgress = false
 	}
 
 	// Prefetch server information
@@ -62,6 +94,20 @@ func (d *Downloader) StartDownload() {
 	// Apply settings to downloader (after we have filename information)
 	UDMSettings.ApplySettingsToDownloader(d)
 
+	// Continue from a state saved by a previous process
+	d.restoreSavedState()
+
+	// Keep an up to date file instead of downloading it again
+	if d.skipIfUnchanged() {
+		return
+	}
+
+	// Reject files exceeding the size limit before any bytes are transferred
+	if err := d.checkFileSizeLimit(); err != nil {
+		d.handleDownloadError(err)
+		return
+	}
+
 	// Initialise the progress tracker
 	d.InitializeProgressTracker()
 
@@ -74,6 +120,9 @@ func (d *Downloader) StartDownload() {
 // Returns:
 //   - error: Error if initialization fails
 func (d *Downloader) initializeDownload() error {
+	// Every download needs an ID for the history, queues and progress output
+	d.ensureID()
+
 	// Initialize progress tracker
 	if d.Progress == nil {
 		d.Progress = &ProgressTracker{}
@@ -90,7 +139,7 @@ func (d *Downloader) initializeDownload() error {
 	}
 
 	// Set initial status
-	d.Status = DOWNLOAD_QUEUED
+	d.setStatus(DOWNLOAD_QUEUED)
 
 	return nil
 }
@@ -102,22 +151,53 @@ func (d *Downloader) initializeDownload() error {
 // Returns:
 //   - error: Error if prefetch fails
 func (d *Downloader) Prefetch() error {
+	if err := d.fetchServerData(); err != nil {
+		return err
+	}
+
+	// Check and apply user preferences
+	if err := d.CheckPreferences(); err != nil {
+		return fmt.Errorf("failed to check preferences: %w", err)
+	}
+
+	return nil
+}
+
+// fetchServerData requests the metadata of the file and stores it in ServerHeaders,
+// without resolving the output path like Prefetch does.
+//
+// Returns:
+//   - error: Error if the server data could not be retrieved
+func (d *Downloader) fetchServerData() error {
+	// Reuse metadata from a previous run for a conditional request when resuming
+	var existing *ServerData
+	if d.ServerHeaders.ETag != "" || !d.ServerHeaders.LastModified.IsZero() {
+		previous := d.ServerHeaders
+		existing = &previous
+	}
+
 	// Get server data with retry mechanism
-	headers, err := GetServerData(d.Url)
+	ctx := d.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// Credentials are set on the requests, so the client without authentication is used
+	client := d.buildBaseHTTPClient(0)
+	defer client.CloseIdleConnections()
+
+	headers, err := getServerData(ctx, d.requestURL(), d.getJitterSource(), existing, d.basicAuth(), &d.Prefs, client, func(attempt int, err error) {
+		d.recordRetry(serverRetryIndex, attempt, err)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get server data: %v", err)
+		return fmt.Errorf("failed to get server data: %w", err)
 	}
 
 	if headers == nil {
-		return fmt.Errorf("failed to get server data: %v", err)
+		return fmt.Errorf("failed to get server data: %w", err)
 	}
 	// Store server headers
-	d.ServerHeaders = *headers
-
-	// Check and apply user preferences
-	if err := d.CheckPreferences(); err != nil {
-		return fmt.Errorf("failed to check preferences: %v", err)
-	}
+	d.setServerHeaders(*headers)
 
 	return nil
 }
@@ -180,19 +260,21 @@ func (d *Downloader) CheckPreferences() error {
 	if d.Prefs.FileName != "" {
 		// User specified filename takes priority
 		d.fileInfo.Name = d.Prefs.FileName
-	} else if headers.Filename != "" {
+	} else if name := d.templateFilename(); name != "" {
+		// Use the filename template of the download
+		d.fileInfo.Name = name
+	} else if headers.Filename != "" && !headers.IsFallbackName {
 		// Use server-provided filename
 		d.fileInfo.Name = headers.Filename
 	} else {
-		// Use fallback name
-		d.fileInfo.Name = "downloaded_file"
-		// Add extension from MIME type if available
-		if headers.Filetype != "" {
-			ext := mimeExtensionFromContentType(headers.Filetype)
-			if ext != "" {
-				d.fileInfo.Name += ext
-			}
-		}
+		// Use filename template from settings
+		d.fileInfo.Name = d.defaultFilename()
+	}
+
+	// Never trust a filename from the server or user to stay inside the download directory
+	d.fileInfo.Name = ufs.SanitizeFilename(d.fileInfo.Name)
+	if d.fileInfo.Name == "" {
+		d.fileInfo.Name = d.defaultFilename()
 	}
 
 	// Determine download directory
@@ -210,7 +292,7 @@ func (d *Downloader) CheckPreferences() error {
 			// Fallback to current working directory
 			cwd, err := os.Getwd()
 			if err != nil {
-				return fmt.Errorf("failed to get current directory: %v", err)
+				return fmt.Errorf("failed to get current directory: %w", err)
 			}
 			d.fileInfo.Dir = cwd
 		} else {
@@ -221,13 +303,13 @@ func (d *Downloader) CheckPreferences() error {
 	// Ensure directory path is absolute
 	absDir, err := filepath.Abs(d.fileInfo.Dir)
 	if err != nil {
-		return fmt.Errorf("failed to resolve absolute path: %v", err)
+		return fmt.Errorf("failed to resolve absolute path: %w", err)
 	}
 	d.fileInfo.Dir = absDir
 
 	// Create the directory if it doesn't exist
-	if err := os.MkdirAll(d.fileInfo.Dir, 0755); err != nil {
-		return fmt.Errorf("failed to create download directory: %v", err)
+	if err := os.MkdirAll(d.fileInfo.Dir, d.getDirMode()); err != nil {
+		return &DiskError{Op: "mkdir", Path: d.fileInfo.Dir, Err: err}
 	}
 
 	// Create full path
@@ -236,6 +318,31 @@ func (d *Downloader) CheckPreferences() error {
 	return nil
 }
 
+// checkFileSizeLimit verifies the file size reported by the server against the effective limit.
+//
+// Returns:
+//   - error: FileSizeLimitError if the file is too large, nil if it fits or the size is unknown
+func (d *Downloader) checkFileSizeLimit() error {
+	limit := d.Prefs.MaxFileSizeBytes
+	if limit > 0 && d.ServerHeaders.Filesize > limit {
+		return &FileSizeLimitError{Size: d.ServerHeaders.Filesize, Limit: limit}
+	}
+	return nil
+}
+
+// getJitterSource returns the random source used for retry jitter.
+// Each downloader gets its own source, seeded from the global generator,
+// so concurrent downloads never share a seed.
+//
+// Returns:
+//   - *rand.Rand: Per-downloader random source
+func (d *Downloader) getJitterSource() *rand.Rand {
+	if d.jitterRand == nil {
+		d.jitterRand = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	return d.jitterRand
+}
+
 func (d *Downloader) InitializeProgressTracker() {
 
 }
@@ -0,0 +1,30 @@
+package udm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStartDownloadInvalidURLFails(t *testing.T) {
+	var callbackErr error
+	d := &Downloader{
+		Url: "ftp://example.com/file.zip",
+		Callbacks: &Callbacks{
+			OnError: func(d *Downloader, err error) {
+				callbackErr = err
+			},
+		},
+	}
+
+	d.StartDownload()
+
+	if status := d.GetStatus(); status != DOWNLOAD_FAILED {
+		t.Errorf("status = %q, want %q", status, DOWNLOAD_FAILED)
+	}
+	if !errors.Is(d.Error, ErrInvalidScheme) {
+		t.Errorf("Error = %v, want ErrInvalidScheme", d.Error)
+	}
+	if !errors.Is(callbackErr, ErrInvalidScheme) {
+		t.Errorf("OnError received %v, want ErrInvalidScheme", callbackErr)
+	}
+}
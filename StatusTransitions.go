@@ -0,0 +1,86 @@
+package udm
+
+import "github.com/utsav-56/ulog"
+
+// validTransitions lists the statuses a download can move to from each status.
+// The empty status is the state of a downloader that has never been started,
+// it can fail or be stopped before it is initialized, e.g. on an invalid URL.
+var validTransitions = map[string][]string{
+	"":                   {DOWNLOAD_QUEUED, DOWNLOAD_IN_PROGRESS, DOWNLOAD_FAILED, DOWNLOAD_STOPPED},
+	DOWNLOAD_QUEUED:      {DOWNLOAD_IN_PROGRESS, DOWNLOAD_STOPPED, DOWNLOAD_FAILED},
+	DOWNLOAD_IN_PROGRESS: {DOWNLOAD_PAUSED, DOWNLOAD_COMPLETED, DOWNLOAD_FAILED, DOWNLOAD_STOPPED},
+	DOWNLOAD_PAUSED:      {DOWNLOAD_IN_PROGRESS, DOWNLOAD_FAILED, DOWNLOAD_STOPPED},
+	DOWNLOAD_FAILED:      {DOWNLOAD_QUEUED, DOWNLOAD_IN_PROGRESS},
+	DOWNLOAD_STOPPED:     {DOWNLOAD_QUEUED, DOWNLOAD_IN_PROGRESS},
+	DOWNLOAD_COMPLETED:   {DOWNLOAD_QUEUED},
+}
+
+// SetStatus changes the status of the download if the transition is allowed.
+// Setting the current status again is always allowed and does nothing.
+//
+// Valid transitions:
+//   - not started → queued, in_progress, failed, stopped
+//   - queued → in_progress, stopped, failed
+//   - in_progress → paused, completed, failed, stopped
+//   - paused → in_progress, failed, stopped
+//   - failed, stopped → queued, in_progress (restart)
+//   - completed → queued (download again)
+//
+// Parameters:
+//   - newStatus: One of the DOWNLOAD_* status constants
+//
+// Returns:
+//   - error: *InvalidTransitionError if the transition is not allowed
+//
+// Example:
+//
+//	if err := downloader.SetStatus(DOWNLOAD_PAUSED); err != nil {
+//		fmt.Println("Cannot pause:", err)
+//	}
+func (d *Downloader) SetStatus(newStatus string) error {
+	d.statusMu.Lock()
+	defer d.statusMu.Unlock()
+
+	if d.Status == newStatus {
+		return nil
+	}
+
+	if !isValidTransition(d.Status, newStatus) {
+		return &InvalidTransitionError{From: d.Status, To: newStatus}
+	}
+
+	d.Status = newStatus
+	return nil
+}
+
+// status returns the current status, read under the lock SetStatus writes it with
+//
+// Returns:
+//   - string: One of the DOWNLOAD_* status constants
+func (d *Downloader) status() string {
+	d.statusMu.Lock()
+	defer d.statusMu.Unlock()
+
+	return d.Status
+}
+
+// setStatus changes the status like SetStatus, used by the download flow itself.
+// A rejected transition is a bug in the flow, it is logged instead of silently ignored.
+//
+// Parameters:
+//   - newStatus: One of the DOWNLOAD_* status constants
+func (d *Downloader) setStatus(newStatus string) {
+	if err := d.SetStatus(newStatus); err != nil {
+		ulog.Error(err.Error(), "UDM_STATUS_TRANSITION_ERROR")
+	}
+}
+
+// isValidTransition reports whether a download can move from one status to another
+func isValidTransition(from, to string) bool {
+	for _, allowed := range validTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
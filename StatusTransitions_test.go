@@ -0,0 +1,72 @@
+package udm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSetStatus(t *testing.T) {
+	tests := []struct {
+		from    string
+		to      string
+		allowed bool
+	}{
+		{from: "", to: DOWNLOAD_QUEUED, allowed: true},
+		{from: "", to: DOWNLOAD_FAILED, allowed: true},
+		{from: "", to: DOWNLOAD_STOPPED, allowed: true},
+		{from: "", to: DOWNLOAD_COMPLETED, allowed: false},
+		{from: DOWNLOAD_PAUSED, to: DOWNLOAD_STOPPED, allowed: true},
+		{from: DOWNLOAD_COMPLETED, to: DOWNLOAD_IN_PROGRESS, allowed: false},
+		{from: DOWNLOAD_COMPLETED, to: DOWNLOAD_COMPLETED, allowed: true},
+		{from: DOWNLOAD_FAILED, to: DOWNLOAD_QUEUED, allowed: true},
+	}
+
+	for _, tt := range tests {
+		d := &Downloader{Status: tt.from}
+		err := d.SetStatus(tt.to)
+
+		if tt.allowed {
+			if err != nil || d.Status != tt.to {
+				t.Errorf("SetStatus from %q to %q = %v with status %q, want allowed", tt.from, tt.to, err, d.Status)
+			}
+			continue
+		}
+
+		var transitionErr *ErrInvalidStatusTransition
+		if !errors.As(err, &transitionErr) || transitionErr.From != tt.from || transitionErr.To != tt.to {
+			t.Errorf("SetStatus from %q to %q = %v, want ErrInvalidStatusTransition", tt.from, tt.to, err)
+		}
+		if d.Status != tt.from {
+			t.Errorf("SetStatus from %q to %q changed the status to %q", tt.from, tt.to, d.Status)
+		}
+	}
+}
+
+func TestStatusGettersDuringTransitions(t *testing.T) {
+	d := &Downloader{}
+
+	// The download goroutine changes the status while the getters are polled
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			d.setStatus(DOWNLOAD_IN_PROGRESS)
+			d.setStatus(DOWNLOAD_PAUSED)
+		}
+		d.setStatus(DOWNLOAD_STOPPED)
+	}()
+
+	for {
+		select {
+		case <-done:
+			if !d.IsStopped() || d.GetStatus() != DOWNLOAD_STOPPED {
+				t.Errorf("GetStatus() = %q, want %q", d.GetStatus(), DOWNLOAD_STOPPED)
+			}
+			return
+		default:
+			_ = d.GetStatus()
+			_ = d.IsPaused()
+			_ = d.IsInProgress()
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package udm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"udl/udm/ufs"
+)
+
+// hashWriter passes writes to w and feeds the written bytes to a hash,
+// so the checksum of a download is known without reading the file again
+type hashWriter struct {
+	w    io.Writer
+	hash hash.Hash
+}
+
+// Write writes p to the underlying writer and hashes the bytes that were written
+func (h *hashWriter) Write(p []byte) (int, error) {
+	n, err := h.w.Write(p)
+	h.hash.Write(p[:n])
+	return n, err
+}
+
+// sum returns the hex digest of the bytes written so far
+func (h *hashWriter) sum() string {
+	return hex.EncodeToString(h.hash.Sum(nil))
+}
+
+// needsStreamingHash reports whether the SHA-256 of the file is verified after the download,
+// the hash is then computed while downloading instead of reading the file again.
+//
+// Returns:
+//   - bool: True if ExpectedSHA256 is set or Prefs.Checksum uses sha256
+func (d *Downloader) needsStreamingHash() bool {
+	if d.ExpectedSHA256 != "" {
+		return true
+	}
+	algo := strings.ToLower(d.Prefs.ChecksumAlgo)
+	return d.Prefs.Checksum != "" && (algo == "" || algo == "sha256")
+}
+
+// newResumedHashWriter creates a hashWriter for the single-stream output file. When resuming,
+// the bytes already on disk are hashed first, which only reads the partial file once.
+//
+// Parameters:
+//   - w: The output file
+//   - resumeOffset: Bytes already in the incomplete file
+//
+// Returns:
+//   - *hashWriter: Writer hashing the whole file, nil if the partial file could not be read
+func (d *Downloader) newResumedHashWriter(w io.Writer, resumeOffset int64) *hashWriter {
+	writer := &hashWriter{w: w, hash: sha256.New()}
+	if resumeOffset == 0 {
+		return writer
+	}
+
+	partial, err := os.Open(d.incompletePath())
+	if err != nil {
+		return nil
+	}
+	defer partial.Close()
+
+	if _, err := io.CopyN(writer.hash, partial, resumeOffset); err != nil {
+		return nil
+	}
+	return writer
+}
+
+// fileSHA256 returns the SHA-256 of the downloaded file, computed while downloading if
+// possible, otherwise by reading the file.
+//
+// Returns:
+//   - string: Lowercase hex digest
+//   - error: Error if the file has to be read and cannot be
+func (d *Downloader) fileSHA256() (string, error) {
+	if d.streamedSHA256 != "" {
+		return d.streamedSHA256, nil
+	}
+	return ufs.HashFile(d.verificationPath(), "sha256")
+}
+
+// setChunkHash stores the SHA-256 of a chunk downloaded in one pass.
+//
+// Parameters:
+//   - index: Index of the chunk
+//   - hash: Hex digest of the chunk
+func (cm *ChunkManager) setChunkHash(index int, hash string) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	cm.Chunks[index].Hash = hash
+}
@@ -0,0 +1,63 @@
+package udm
+
+import (
+	"path/filepath"
+	"strings"
+
+	"udl/udm/ufs"
+)
+
+// ListTempFiles returns the temporary chunk files that belong to this download,
+// followed by the incomplete output file if there is one.
+// This is useful for diagnostic UIs and cleanup tools to find orphaned
+// .udtemp and .udtmp files left behind by failed or interrupted downloads.
+//
+// Returns:
+//   - []string: Absolute paths of matching temporary files (empty if none exist)
+//
+// Example:
+//
+//	for _, tempFile := range downloader.ListTempFiles() {
+//		fmt.Println("Leftover chunk:", tempFile)
+//	}
+func (d *Downloader) ListTempFiles() []string {
+	dir := d.GetOutputDir()
+	baseName := ufs.FileNameWithoutExtension(filepath.Base(d.GetFilename()))
+
+	// Chunk files follow the "{name} ({index}).udtemp" convention of ufs.GenerateChunkFileNames
+	pattern := filepath.Join(escapeGlob(dir), escapeGlob(baseName)+" (*).udtemp")
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return []string{}
+	}
+
+	// Output file written by a single-stream download or being merged
+	incomplete := filepath.Join(dir, filepath.Base(d.GetFilename())+IncompleteFileSuffix)
+	if ufs.FileExists(incomplete) {
+		matches = append(matches, incomplete)
+	}
+
+	tempFiles := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if absPath, err := filepath.Abs(match); err == nil {
+			tempFiles = append(tempFiles, absPath)
+		} else {
+			tempFiles = append(tempFiles, match)
+		}
+	}
+
+	return tempFiles
+}
+
+// escapeGlob escapes characters that have a special meaning in filepath.Match patterns.
+//
+// Parameters:
+//   - s: Literal path fragment
+//
+// Returns:
+//   - string: Fragment safe to embed in a glob pattern
+func escapeGlob(s string) string {
+	replacer := strings.NewReplacer("*", "[*]", "?", "[?]", "[", "[[]")
+	return replacer.Replace(s)
+}
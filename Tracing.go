@@ -0,0 +1,148 @@
+package udm
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SetTracer enables OpenTelemetry tracing of the download.
+// StartDownload creates a root span for the download with a child span per chunk,
+// chunk progress is recorded as chunk.start, chunk.end and chunk.retry events.
+//
+// Parameters:
+//   - tracer: The tracer to create spans with, nil disables tracing
+//
+// Example:
+//
+//	downloader := &Downloader{Url: "https://example.com/model.bin"}
+//	downloader.SetTracer(otel.Tracer("model-loader"))
+//	downloader.StartDownload()
+func (d *Downloader) SetTracer(tracer trace.Tracer) {
+	d.tracer = tracer
+}
+
+// startDownloadSpan starts the root span of the download if a tracer is set.
+func (d *Downloader) startDownloadSpan() {
+	if d.tracer == nil {
+		return
+	}
+
+	d.traceCtx, d.downloadSpan = d.tracer.Start(context.Background(), "udm.download",
+		trace.WithAttributes(
+			attribute.String("download.id", d.ID),
+			attribute.String("download.url", d.requestURL()),
+		),
+	)
+	d.chunkSpans = nil
+}
+
+// startChunkSpans starts a child span for each chunk of the download.
+// Spans of a previous division of the chunks are ended first, ending a span twice has no effect.
+func (d *Downloader) startChunkSpans() {
+	if d.downloadSpan == nil {
+		return
+	}
+
+	for _, span := range d.chunkSpans {
+		span.End()
+	}
+
+	d.chunkSpans = make([]trace.Span, len(d.Chunks))
+	for i, chunk := range d.Chunks {
+		d.chunkSpans[i] = d.startChunkSpan(chunk)
+	}
+}
+
+// startChunkSpan starts the child span of a single chunk.
+//
+// Parameters:
+//   - chunk: The chunk to trace
+//
+// Returns:
+//   - trace.Span: The started span
+func (d *Downloader) startChunkSpan(chunk ChunkData) trace.Span {
+	_, span := d.tracer.Start(d.traceCtx, "udm.chunk",
+		trace.WithAttributes(
+			attribute.Int("chunk.index", chunk.Index),
+			attribute.Int64("chunk.start", chunk.Start),
+			attribute.Int64("chunk.end", chunk.End),
+		),
+	)
+	return span
+}
+
+// chunkSpan returns the span of a chunk.
+//
+// Parameters:
+//   - chunkIndex: Index of the chunk
+//
+// Returns:
+//   - trace.Span: The span, nil if the chunk is not traced
+func (d *Downloader) chunkSpan(chunkIndex int) trace.Span {
+	unlock := d.lockChunks()
+	defer unlock()
+
+	if chunkIndex < 0 || chunkIndex >= len(d.chunkSpans) {
+		return nil
+	}
+	return d.chunkSpans[chunkIndex]
+}
+
+// addChunkEvent records an event on the span of a chunk.
+//
+// Parameters:
+//   - chunkIndex: Index of the chunk
+//   - name: Event name, e.g. "chunk.start"
+//   - attrs: Additional attributes of the event
+func (d *Downloader) addChunkEvent(chunkIndex int, name string, attrs ...attribute.KeyValue) {
+	if span := d.chunkSpan(chunkIndex); span != nil {
+		span.AddEvent(name, trace.WithAttributes(attrs...))
+	}
+}
+
+// endChunkSpan ends the span of a chunk, recording the error if it failed.
+//
+// Parameters:
+//   - chunkIndex: Index of the chunk
+//   - err: The error the chunk failed with, nil on success
+func (d *Downloader) endChunkSpan(chunkIndex int, err error) {
+	span := d.chunkSpan(chunkIndex)
+	if span == nil {
+		return
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// endDownloadSpan ends the root span of the download, recording the error if it failed.
+//
+// Parameters:
+//   - err: The error the download failed with, nil on success
+func (d *Downloader) endDownloadSpan(err error) {
+	if d.downloadSpan == nil {
+		return
+	}
+
+	if err != nil {
+		d.downloadSpan.RecordError(err)
+		d.downloadSpan.SetStatus(codes.Error, err.Error())
+	} else {
+		d.downloadSpan.SetStatus(codes.Ok, "")
+	}
+	d.downloadSpan.End()
+
+	// End chunk spans that didn't finish, ending a span twice has no effect
+	for _, span := range d.chunkSpans {
+		span.End()
+	}
+
+	d.downloadSpan = nil
+	d.chunkSpans = nil
+}
@@ -26,6 +26,11 @@ type UDMProgressTracker struct {
 	// Multi-stream specific
 	IsMultiStream bool
 	ChunkProgress []ChunkProgress // Progress for each chunk
+
+	// Assembly phase of multi-stream downloads
+	IsAssembling   bool
+	AssembledBytes int64
+	AssembleTotal  int64
 }
 
 // ChunkProgress represents progress for individual chunks in multi-stream downloads
@@ -39,6 +44,7 @@ type ChunkProgress struct {
 type UDMProgressModel struct {
 	tracker     *UDMProgressTracker
 	progressBar progress.Model
+	theme       ProgressTheme
 	width       int
 	height      int
 }
@@ -47,14 +53,18 @@ type progressTickMsg time.Time
 type progressUpdateMsg UDMProgressTracker
 type progressCompletionMsg struct{}
 
-// NewUDMProgress creates a new UDM progress bar
-func NewUDMProgress(tracker *UDMProgressTracker) *UDMProgressModel {
-	p := progress.New(progress.WithGradient("#00d7af", "#5fafff"))
+// NewUDMProgress creates a new UDM progress bar.
+// An optional ProgressTheme sets the colors, ThemeDefault is used without one.
+func NewUDMProgress(tracker *UDMProgressTracker, theme ...ProgressTheme) *UDMProgressModel {
+	selected := themeOrDefault(theme)
+
+	p := progress.New(progress.WithGradient(selected.ActiveGradientStart, selected.ActiveGradientEnd))
 	p.Width = 50
 
 	return &UDMProgressModel{
 		tracker:     tracker,
 		progressBar: p,
+		theme:       selected,
 		width:       80,
 		height:      20,
 	}
@@ -122,9 +132,9 @@ func (m UDMProgressModel) View() string {
 // renderProgressView renders the active download progress
 func (m UDMProgressModel) renderProgressView() string {
 	// Style definitions
-	filenameStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#00d7af")).Bold(true)
+	filenameStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.ActiveGradientStart)).Bold(true)
 	sizeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ffffff")).Bold(true)
-	speedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#5fafff")).Bold(true)
+	speedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.ActiveGradientEnd)).Bold(true)
 	etaStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ffaf00")).Bold(true)
 	chunkStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#767676"))
 
@@ -139,8 +149,8 @@ func (m UDMProgressModel) renderProgressView() string {
 	var progressBar string
 
 	if m.tracker.IsPaused {
-		// Yellow progress bar for paused state
-		pausedBar := progress.New(progress.WithGradient("#ffff00", "#ffa500"))
+		// Progress bar in the paused colors of the theme
+		pausedBar := progress.New(progress.WithGradient(m.theme.PausedGradientStart, m.theme.PausedGradientEnd))
 		pausedBar.Width = m.progressBar.Width
 		progressBar = pausedBar.ViewAs(progressPercent)
 
@@ -162,7 +172,7 @@ func (m UDMProgressModel) renderProgressView() string {
 	detailsLine := fmt.Sprintf("completed : %s / %s      Speed :: %s   ETA:: %s",
 		formatProgressBytes(m.tracker.BytesCompleted),
 		formatProgressBytes(m.tracker.TotalBytes),
-		speedStyle.Render(formatProgressSpeed(m.tracker.SpeedBps)),
+		speedStyle.Render(ReadableSpeed(m.tracker.SpeedBps)),
 		etaStyle.Render(formatProgressDuration(m.tracker.ETA)),
 	)
 
@@ -202,14 +212,40 @@ func (m UDMProgressModel) renderProgressView() string {
 		}
 	}
 
+	// Add the assembly bar while chunks are merged into the final file
+	if m.tracker.IsAssembling {
+		view.WriteString("\n" + m.renderAssembleView() + "\n")
+	}
+
 	return view.String()
 }
 
+// renderAssembleView renders the progress of merging chunk files into the final file
+func (m UDMProgressModel) renderAssembleView() string {
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ff5fff")).Bold(true)
+
+	assembleBar := progress.New(progress.WithGradient("#af5fff", "#ff5fff"))
+	assembleBar.Width = m.progressBar.Width
+
+	var assemblePercent float64
+	if m.tracker.AssembleTotal > 0 {
+		assemblePercent = float64(m.tracker.AssembledBytes) / float64(m.tracker.AssembleTotal)
+	}
+
+	return fmt.Sprintf("%s %s / %s\n%s %.1f%%",
+		labelStyle.Render("assembling ::"),
+		formatProgressBytes(m.tracker.AssembledBytes),
+		formatProgressBytes(m.tracker.AssembleTotal),
+		assembleBar.ViewAs(assemblePercent),
+		assemblePercent*100,
+	)
+}
+
 // renderCompletionView renders the final completion message
 func (m UDMProgressModel) renderCompletionView() string {
 	// Style definitions
-	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#00d7af")).Bold(true)
-	filenameStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#00d7af")).Bold(true)
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.CompletedColor)).Bold(true)
+	filenameStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.CompletedColor)).Bold(true)
 	dirStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ffaf00")).Bold(true)
 	timeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#5fafff")).Bold(true)
 	speedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ff5fff")).Bold(true)
@@ -233,7 +269,7 @@ Average speed :: %s
 		filenameStyle.Render(m.tracker.Filename),
 		dirStyle.Render(m.tracker.OutputDir),
 		timeStyle.Render(formatProgressDuration(elapsed)),
-		speedStyle.Render(formatProgressSpeed(avgSpeed)),
+		speedStyle.Render(ReadableSpeed(avgSpeed)),
 		border,
 	)
 
@@ -254,27 +290,11 @@ func formatProgressBytes(bytes int64) string {
 	return fmt.Sprintf("%.2f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
-// formatProgressSpeed formats speed into human readable format
-func formatProgressSpeed(speedBps float64) string {
-	speedMBps := speedBps / (1024 * 1024)
-	return fmt.Sprintf("%.2f MB/s", speedMBps)
-}
-
 // formatProgressDuration formats duration into human readable format
 func formatProgressDuration(d time.Duration) string {
 	if d < 0 {
 		return "∞"
 	}
 
-	d = d.Round(time.Second)
-	h := d / time.Hour
-	d -= h * time.Hour
-	m := d / time.Minute
-	d -= m * time.Minute
-	s := d / time.Second
-
-	if h > 0 {
-		return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
-	}
-	return fmt.Sprintf("%02d:%02d", m, s)
+	return ReadableTimeShort(int64(d.Round(time.Second) / time.Second))
 }
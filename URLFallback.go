@@ -0,0 +1,93 @@
+package udm
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// doWithFallback sends a request and, when it fails or the response status is not
+// accepted, sends the same request (including its Range header) to the next URL of
+// FallbackURLs. The first mirror that works becomes the download URL, so later
+// requests go to it directly and data already downloaded is kept.
+//
+// Parameters:
+//   - req: Request to the current download URL
+//   - send: Function sending a request, e.g. client.Do
+//   - accept: Reports whether a response status means the request succeeded
+//
+// Returns:
+//   - *http.Response: Response of the last URL tried, its status may not be accepted if all URLs failed
+//   - error: Error of the last URL tried if no response was received
+func (d *Downloader) doWithFallback(req *http.Request, send func(*http.Request) (*http.Response, error), accept func(statusCode int) bool) (*http.Response, error) {
+	for {
+		resp, err := send(req)
+		if err == nil && accept(resp.StatusCode) {
+			return resp, nil
+		}
+
+		// Don't switch mirrors when the download was cancelled
+		if req.Context().Err() != nil {
+			return resp, err
+		}
+
+		failure := err
+		if failure == nil {
+			failure = &ServerError{StatusCode: resp.StatusCode, URL: req.URL.String()}
+		}
+
+		nextURL, ok := d.nextFallbackURL(req.URL.String(), failure)
+		if !ok {
+			return resp, err
+		}
+
+		parsed, parseErr := url.Parse(nextURL)
+		if parseErr != nil {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		req = req.Clone(req.Context())
+		req.URL = parsed
+		req.Host = ""
+	}
+}
+
+// nextFallbackURL switches the download URL to the next fallback URL after failedURL failed.
+// If another request already switched away from failedURL, the current URL is returned
+// without switching again, so concurrent chunks don't skip mirrors.
+//
+// Parameters:
+//   - failedURL: URL the failed request was sent to
+//   - err: Error of the failed request, passed to OnURLFallback
+//
+// Returns:
+//   - string: URL to retry the request with
+//   - bool: False if there is no URL left to try
+func (d *Downloader) nextFallbackURL(failedURL string, err error) (string, bool) {
+	d.urlMu.Lock()
+	if d.Url != failedURL {
+		current := d.Url
+		d.urlMu.Unlock()
+		return current, true
+	}
+	if d.fallbackIndex >= len(d.FallbackURLs) {
+		d.urlMu.Unlock()
+		return "", false
+	}
+
+	nextURL := d.FallbackURLs[d.fallbackIndex]
+	d.fallbackIndex++
+	d.Url = nextURL
+	d.ServerHeaders.FinalURL = nextURL
+	d.urlMu.Unlock()
+
+	logf("Switching to mirror %s: %v\n", nextURL, err)
+	d.logEvent(EVENT_URL_FALLBACK, "%s failed, switching to %s: %v", failedURL, nextURL, err)
+	if callbacks := d.callbacks(); callbacks != nil && callbacks.OnURLFallback != nil {
+		callbacks.OnURLFallback(d, failedURL, nextURL, err)
+	}
+
+	return nextURL, true
+}
@@ -0,0 +1,47 @@
+package udm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestURLReadsDuringMirrorSwitch(t *testing.T) {
+	previous := diagnosticOutput
+	defer func() { diagnosticOutput = previous }()
+	diagnosticOutput = io.Discard
+
+	d := &Downloader{Url: "https://example.com/file.zip"}
+	for i := range 100 {
+		d.FallbackURLs = append(d.FallbackURLs, fmt.Sprintf("https://mirror%d.example.com/file.zip", i))
+	}
+
+	// Readers run while the download switches mirrors
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		failed := d.requestURL()
+		for {
+			next, ok := d.nextFallbackURL(failed, errors.New("mirror down"))
+			if !ok {
+				return
+			}
+			failed = next
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			if got, want := d.GetFinalURL(), "https://mirror99.example.com/file.zip"; got != want {
+				t.Errorf("GetFinalURL() = %q, want %q", got, want)
+			}
+			return
+		default:
+			_ = d.GetURL()
+			_ = d.GetFinalURL()
+			_ = d.requestHost()
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package udm
+
+import (
+	"context"
+	"time"
+)
+
+// SetURLRefresher sets a callback used to obtain a fresh download URL.
+// This is needed for expiring links like S3 presigned URLs which can expire
+// in the middle of a large download. The callback is called every
+// UserPreferences.URLRefreshIntervalSecs seconds during single-stream and multi-stream
+// downloads, requests that are retried or resumed pick up the new URL on their next attempt.
+// DownloadToWriter does not refresh the URL.
+//
+// Parameters:
+//   - fn: Function returning a fresh URL for the same file, nil disables refreshing
+//
+// Example:
+//
+//	downloader := &Downloader{Url: presignedURL}
+//	downloader.Prefs.URLRefreshIntervalSecs = 30 * 60
+//	downloader.SetURLRefresher(func() (string, error) {
+//		return s3Client.PresignGetObject(bucket, key, time.Hour)
+//	})
+//	downloader.StartDownload()
+func (d *Downloader) SetURLRefresher(fn func() (string, error)) {
+	d.urlMu.Lock()
+	defer d.urlMu.Unlock()
+	d.urlRefresher = fn
+}
+
+// requestURL returns the URL to use for new requests.
+// It is safe to call while the URL refresher is running.
+//
+// Returns:
+//   - string: The current download URL
+func (d *Downloader) requestURL() string {
+	d.urlMu.RLock()
+	defer d.urlMu.RUnlock()
+	return d.Url
+}
+
+// finalURL returns the URL of the file after redirects, or the download URL if
+// the server data is not known yet. It is safe to call while the URL refresher is running.
+//
+// Returns:
+//   - string: The final download URL
+func (d *Downloader) finalURL() string {
+	d.urlMu.RLock()
+	defer d.urlMu.RUnlock()
+
+	if d.ServerHeaders.FinalURL != "" {
+		return d.ServerHeaders.FinalURL
+	}
+	return d.Url
+}
+
+// setServerHeaders stores the server data, FinalURL is written under the URL lock
+//
+// Parameters:
+//   - headers: Server data of the download
+func (d *Downloader) setServerHeaders(headers ServerData) {
+	d.urlMu.Lock()
+	defer d.urlMu.Unlock()
+
+	d.ServerHeaders = headers
+}
+
+// refreshURLPeriodically replaces the download URL with the one returned by the
+// URL refresher until the context is done. Nothing is done if no refresher is set
+// or UserPreferences.URLRefreshIntervalSecs is not set.
+//
+// Parameters:
+//   - ctx: Context for cancellation, refreshing stops when it is done
+func (d *Downloader) refreshURLPeriodically(ctx context.Context) {
+	d.urlMu.RLock()
+	refresher := d.urlRefresher
+	d.urlMu.RUnlock()
+
+	if refresher == nil || d.Prefs.URLRefreshIntervalSecs <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(d.Prefs.URLRefreshIntervalSecs) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			newURL, err := refresher()
+			if err != nil {
+				// Keep using the current URL, it may still be valid
+				logf("Failed to refresh download URL: %v\n", err)
+				continue
+			}
+			if newURL == "" {
+				continue
+			}
+
+			d.urlMu.Lock()
+			d.Url = newURL
+			d.ServerHeaders.FinalURL = newURL
+			d.urlMu.Unlock()
+		}
+	}
+}
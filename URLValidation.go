@@ -0,0 +1,157 @@
+package udm
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// MAX_URL_PATH_LENGTH is the longest URL path accepted by ValidateURL
+const MAX_URL_PATH_LENGTH = 8192
+
+// invalidHostChars are characters that never appear in a valid host name,
+// but are used to smuggle extra requests or headers into a URL
+const invalidHostChars = " \t\r\n<>\"'`{}|\\^%;"
+
+// sha256Pattern matches a hex encoded SHA-256 digest
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// ValidateURL checks that a download URL can be requested, without any network call.
+//
+// Parameters:
+//   - rawURL: The URL to check
+//
+// Returns:
+//   - error: ErrEmptyURL, ErrInvalidScheme, ErrMissingHost, ErrInvalidHost or ErrURLTooLong
+//     (check with errors.Is), or a parse error, nil if the URL is valid
+//
+// Example:
+//
+//	if err := ValidateURL("ftp://example.com/file.zip"); errors.Is(err, ErrInvalidScheme) {
+//	    fmt.Println("Only http and https are supported")
+//	}
+func ValidateURL(rawURL string) error {
+	if strings.TrimSpace(rawURL) == "" {
+		return ErrEmptyURL
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %v", err)
+	}
+
+	scheme := strings.ToLower(parsed.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return fmt.Errorf("%w, got %q", ErrInvalidScheme, parsed.Scheme)
+	}
+
+	host := parsed.Hostname()
+	if host == "" {
+		return ErrMissingHost
+	}
+	if strings.ContainsAny(host, invalidHostChars) {
+		return fmt.Errorf("%w: %q", ErrInvalidHost, host)
+	}
+
+	if len(parsed.EscapedPath()) >= MAX_URL_PATH_LENGTH {
+		return fmt.Errorf("%w: %d bytes, the limit is %d", ErrURLTooLong, len(parsed.EscapedPath()), MAX_URL_PATH_LENGTH)
+	}
+
+	return nil
+}
+
+// Validate checks the downloader configuration before starting the download.
+//
+// Returns:
+//   - []ValidationError: All problems found, empty if the downloader is ready to start
+//
+// Example:
+//
+//	for _, problem := range downloader.Validate() {
+//	    fmt.Println(problem.Error())
+//	}
+func (d *Downloader) Validate() []ValidationError {
+	var problems []ValidationError
+
+	addProblem := func(field, message string) {
+		problems = append(problems, ValidationError{
+			Field:    field,
+			Message:  message,
+			Severity: VALIDATION_ERROR,
+		})
+	}
+
+	if err := ValidateURL(d.requestURL()); err != nil {
+		addProblem("Url", err.Error())
+	}
+
+	if d.Prefs.threadCount < 0 {
+		addProblem("Prefs.threadCount", "thread count must not be negative")
+	}
+
+	if d.Prefs.maxRetries < 0 {
+		addProblem("Prefs.maxRetries", "retry count must not be negative")
+	}
+
+	if d.Prefs.DownloadDir != "" {
+		if err := checkDirWritable(d.Prefs.DownloadDir); err != nil {
+			addProblem("Prefs.DownloadDir", err.Error())
+		}
+	}
+
+	if d.Prefs.LocalAddress != "" {
+		if _, err := parseLocalAddress(d.Prefs.LocalAddress); err != nil {
+			addProblem("Prefs.LocalAddress", err.Error())
+		}
+	}
+
+	if d.ExpectedSHA256 != "" && !sha256Pattern.MatchString(strings.TrimSpace(d.ExpectedSHA256)) {
+		addProblem("ExpectedSHA256", "must be 64 hexadecimal characters")
+	}
+
+	return problems
+}
+
+// checkDirWritable checks that files can be created in dir. A directory that does not
+// exist yet is accepted if it can be created inside its closest existing parent.
+//
+// Parameters:
+//   - dir: Directory to check
+//
+// Returns:
+//   - error: Error describing why the directory is not writable
+func checkDirWritable(dir string) error {
+	// Find the closest directory that exists
+	existing := dir
+	for {
+		info, err := os.Stat(existing)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", existing)
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("cannot access %s: %v", existing, err)
+		}
+
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return fmt.Errorf("no parent of %s exists", dir)
+		}
+		existing = parent
+	}
+
+	// Creating a file is the only reliable check across platforms
+	file, err := os.CreateTemp(existing, ".udm-write-test-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %v", existing, err)
+	}
+	file.Close()
+	os.Remove(file.Name())
+
+	return nil
+}
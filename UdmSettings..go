@@ -1,7 +1,10 @@
 package udm
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"udl/udm/ufs"
@@ -11,23 +14,61 @@ import (
 var CONFIG_FILE_PATH = "D:\\GO_projects\\nudm_backend\\udm\\udmConfigs.json"
 
 type CategoryInfo struct {
-	Name      string   `json:"name"`
-	Exts      []string `json:"exts"`
-	OutputDir string   `json:"outputDir"`
+	Name             string   `json:"name"`
+	Exts             []string `json:"exts"`
+	OutputDir        string   `json:"outputDir"`
+	MaxFileSizeBytes int64    `json:"maxFileSizeBytes"`
+	ThreadCount      int      `json:"threadCount"` // Overrides Settings.ThreadCount for this category (0 to use the global value)
+	Priority         int      `json:"priority"`    // Higher wins when several categories match a file, exact extensions always beat glob patterns
 }
 
 type Settings struct {
-	ThreadCount            int               `json:"ThreadCount"`
-	MaxRetries             int               `json:"MaxRetries"`
-	MinimumFileSize        int64             `json:"MinimumFileSize"`
-	MaxConcurrentDownloads int               `json:"MaxConcurrentDownloads"`
-	Categories             []string          `json:"Categories"`
-	Extensions             []string          `json:"Extensions"`
-	OutputDir              string            `json:"OutputDir"`
-	MainOutputDir          string            `json:"MainOutputDir"`
-	CategoryInfo           []CategoryInfo    `json:"categoryInfo"`
-	CustomHeaders          map[string]string `json:"CustomHeaders"`
-	CustomCookies          string            `json:"CustomCookies"`
+	ThreadCount               int               `json:"ThreadCount"`
+	MaxRetries                int               `json:"MaxRetries"`
+	MinimumFileSize           int64             `json:"MinimumFileSize"`
+	MaxConcurrentDownloads    int               `json:"MaxConcurrentDownloads"`
+	Categories                []string          `json:"Categories"`
+	Extensions                []string          `json:"Extensions"`
+	OutputDir                 string            `json:"OutputDir"`
+	MainOutputDir             string            `json:"MainOutputDir"`
+	CategoryInfo              []CategoryInfo    `json:"categoryInfo"`
+	CustomHeaders             map[string]string `json:"CustomHeaders"`
+	CustomCookies             string            `json:"CustomCookies"`
+	StrictValidation          bool              `json:"StrictValidation"`
+	DefaultFilenameTemplate   string            `json:"DefaultFilenameTemplate"`
+	DateFormat                string            `json:"DateFormat"`
+	MaxFileSizeBytes          int64             `json:"MaxFileSizeBytes"`
+	BenchmarkedOptimalThreads int               `json:"BenchmarkedOptimalThreads"` // Written by BenchmarkNetwork
+	RetryStatusCodes          []int             `json:"RetryStatusCodes"`
+	RetryPolicy               RetryPolicy       `json:"RetryPolicy"`
+	MinChunkSizeBytes         int64             `json:"MinChunkSizeBytes"` // Smallest chunk of a multi-stream download (defaults to 1MB)
+	MaxChunkSizeBytes         int64             `json:"MaxChunkSizeBytes"` // Largest chunk of a multi-stream download (0 for no limit)
+
+	// Webhook notified about download events, see sendWebhook
+	WebhookURL    string   `json:"WebhookURL"`    // URL to POST events to (empty to disable)
+	WebhookEvents []string `json:"WebhookEvents"` // Events to send: start, finish, error, stop (defaults to finish and error)
+	WebhookSecret string   `json:"WebhookSecret"` // Key of the X-UDM-Signature HMAC (empty to send no signature)
+
+	OnQueueChange func(event QueueEvent) `json:"-"` // Called on every change of a DownloadQueue using these settings
+}
+
+// ValidationError describes a single problem found while validating settings
+type ValidationError struct {
+	Field    string // Path of the invalid field, e.g. categoryInfo[2].exts
+	Message  string // Description of the problem
+	Severity string // VALIDATION_WARNING or VALIDATION_ERROR
+}
+
+// Validation severities
+// In strict mode all category problems are reported as errors
+const (
+	VALIDATION_WARNING = "warning"
+	VALIDATION_ERROR   = "error"
+)
+
+// Error returns the validation problem as a readable string
+func (v ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", v.Field, v.Message)
 }
 
 // UDMSettings holds the global settings instance
@@ -49,6 +90,21 @@ func LoadSettings(configPath string) (*Settings, error) {
 	return &settings, nil
 }
 
+// SaveSettings writes the settings to the JSON configuration file
+func (s *Settings) SaveSettings(configPath string) error {
+	// Use default path if not provided
+	if configPath == "" {
+		configPath = "udmConfigs.json"
+	}
+
+	data, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(configPath, data, 0644)
+}
+
 // InitializeSettings loads and initializes the global settings
 func InitializeSettings() error {
 	settings, err := LoadSettings(CONFIG_FILE_PATH)
@@ -86,14 +142,8 @@ func (s *Settings) GetOutputDirForFile(filename string) string {
 	}
 
 	// Look for extension in category info
-	for _, category := range s.CategoryInfo {
-		for _, categoryExt := range category.Exts {
-			if strings.ToLower(categoryExt) == ext {
-				if category.OutputDir != "" {
-					return category.OutputDir
-				}
-			}
-		}
+	if category := s.findCategory(filename); category != nil && category.OutputDir != "" {
+		return category.OutputDir
 	}
 
 	// Use MainOutputDir if available
@@ -126,6 +176,44 @@ func (s *Settings) getDefaultOutputDir() string {
 	return filepath.Join(userHomeDir, "Downloads")
 }
 
+// GetDefaultFilenameTemplate returns the filename template for unnamed downloads with fallback
+func (s *Settings) GetDefaultFilenameTemplate() string {
+	if s.DefaultFilenameTemplate != "" {
+		return s.DefaultFilenameTemplate
+	}
+	return DEFAULT_FILENAME_TEMPLATE // Default fallback
+}
+
+// GetMaxFileSizeForFile returns the effective file size limit for a file.
+// The limit is the smallest of the global limit and the limit of the category
+// matching the file extension, limits of 0 or less are ignored.
+//
+// Parameters:
+//   - filename: Name of the file used to find its category
+//
+// Returns:
+//   - int64: Limit in bytes, 0 if no limit applies
+func (s *Settings) GetMaxFileSizeForFile(filename string) int64 {
+	limit := s.MaxFileSizeBytes
+
+	if category := s.findCategory(filename); category != nil {
+		limit = minFileSizeLimit(limit, category.MaxFileSizeBytes)
+	}
+
+	return max(limit, 0)
+}
+
+// minFileSizeLimit returns the stricter of two limits, treating 0 or less as no limit
+func minFileSizeLimit(a, b int64) int64 {
+	if a <= 0 {
+		return b
+	}
+	if b <= 0 {
+		return a
+	}
+	return min(a, b)
+}
+
 // GetCustomHeaders returns custom headers if configured
 func (s *Settings) GetCustomHeaders() map[string]string {
 	if s.CustomHeaders != nil && len(s.CustomHeaders) > 0 {
@@ -147,11 +235,56 @@ func (s *Settings) GetMaxRetries() int {
 	return 3 // Default fallback
 }
 
-// ApplySettingsToDownloader applies settings to a downloader instance
+// GetMaxConcurrentDownloads returns the number of downloads a DownloadQueue runs at the same time with fallback
+func (s *Settings) GetMaxConcurrentDownloads() int {
+	if s.MaxConcurrentDownloads > 0 {
+		return s.MaxConcurrentDownloads
+	}
+	return DEFAULT_MAX_CONCURRENT_DOWNLOADS // Default fallback
+}
+
+// GetMinChunkSizeBytes returns the smallest chunk size of multi-stream downloads with fallback
+func (s *Settings) GetMinChunkSizeBytes() int64 {
+	if s.MinChunkSizeBytes > 0 {
+		return s.MinChunkSizeBytes
+	}
+	return DEFAULT_MIN_CHUNK_SIZE_BYTES // Default fallback
+}
+
+// GetRetryStatusCodes returns the HTTP status codes that trigger a retry with fallback
+func (s *Settings) GetRetryStatusCodes() []int {
+	if len(s.RetryStatusCodes) > 0 {
+		return s.RetryStatusCodes
+	}
+	return DEFAULT_RETRY_STATUS_CODES // Default fallback
+}
+
+// GetRetryPolicy returns the retry backoff policy with fallback
+func (s *Settings) GetRetryPolicy() RetryPolicy {
+	if s.RetryPolicy == (RetryPolicy{}) {
+		return DEFAULT_RETRY_POLICY // Default fallback
+	}
+	return s.RetryPolicy
+}
+
+// ApplySettingsToDownloader applies settings to a downloader instance.
+//
+// The thread count is chosen with the following precedence:
+//  1. UserPreferences.threadCount set on the downloader
+//  2. ThreadCount of the category matching the file extension
+//  3. The global Settings.ThreadCount
+//  4. BenchmarkedOptimalThreads if a benchmark was run, otherwise the default of
+//     GetThreadCount (the file size heuristic is used when no settings are loaded at all)
+//
+// The last step is left to getOptimalThreadCount, threadCount stays 0 here.
 func (s *Settings) ApplySettingsToDownloader(d *Downloader) {
-	// Apply thread count (always from config)
+	// Apply thread count from the file's category or the config
 	if d.Prefs.threadCount <= 0 {
-		d.Prefs.threadCount = s.GetThreadCount()
+		if categoryThreads := s.GetCategoryThreadCount(d.fileInfo.Name); categoryThreads > 0 {
+			d.Prefs.threadCount = categoryThreads
+		} else if s.ThreadCount > 0 {
+			d.Prefs.threadCount = s.ThreadCount
+		}
 	}
 
 	// Apply max retries if not set
@@ -170,6 +303,13 @@ func (s *Settings) ApplySettingsToDownloader(d *Downloader) {
 		}
 	}
 
+	// Apply the stricter of the user and config file size limits
+	if d.fileInfo.Name != "" {
+		d.Prefs.MaxFileSizeBytes = minFileSizeLimit(d.Prefs.MaxFileSizeBytes, s.GetMaxFileSizeForFile(d.fileInfo.Name))
+	} else {
+		d.Prefs.MaxFileSizeBytes = minFileSizeLimit(d.Prefs.MaxFileSizeBytes, s.MaxFileSizeBytes)
+	}
+
 	// Apply custom headers if not already set and available in config
 	configHeaders := s.GetCustomHeaders()
 	if configHeaders != nil && len(configHeaders) > 0 {
@@ -194,20 +334,82 @@ func (s *Settings) ApplySettingsToDownloader(d *Downloader) {
 
 // GetCategoryForExtension returns the category name for a given file extension
 func (s *Settings) GetCategoryForExtension(filename string) string {
+	if category := s.findCategory(filename); category != nil {
+		return category.Name
+	}
+
+	return "unknown"
+}
+
+// findCategory returns the category of a file based on its extension.
+// Category extensions are either exact names like "mp4" or glob patterns like "mp*"
+// matched with path.Match, both case-insensitive.
+//
+// Categories listing the extension exactly always win over glob patterns. Among several
+// matches of the same kind the highest Priority wins, then the category defined first.
+//
+// Parameters:
+//   - filename: Name of the file
+//
+// Returns:
+//   - *CategoryInfo: The matching category, nil if the file has no extension or none matches
+//
+// Example:
+//
+//	// Exts ["mp*"] with priority 1, Exts ["mp3"] with priority 0
+//	category := UDMSettings.findCategory("song.mp3") // The "mp3" category, exact matches win
+func (s *Settings) findCategory(filename string) *CategoryInfo {
 	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
 	if ext == "" {
-		return "unknown"
+		return nil
 	}
 
-	for _, category := range s.CategoryInfo {
+	var exactMatch, globMatch *CategoryInfo
+	for i := range s.CategoryInfo {
+		category := &s.CategoryInfo[i]
 		for _, categoryExt := range category.Exts {
-			if strings.ToLower(categoryExt) == ext {
-				return category.Name
+			pattern := strings.ToLower(categoryExt)
+			if pattern == ext {
+				if exactMatch == nil || category.Priority > exactMatch.Priority {
+					exactMatch = category
+				}
+				break
+			}
+			if isExtensionPattern(pattern) {
+				if matched, _ := path.Match(pattern, ext); matched {
+					if globMatch == nil || category.Priority > globMatch.Priority {
+						globMatch = category
+					}
+					break
+				}
 			}
 		}
 	}
 
-	return "unknown"
+	if exactMatch != nil {
+		return exactMatch
+	}
+	return globMatch
+}
+
+// isExtensionPattern reports whether a category extension is a glob pattern instead of an exact name
+func isExtensionPattern(ext string) bool {
+	return strings.ContainsAny(ext, "*?[")
+}
+
+// GetCategoryThreadCount returns the thread count configured for the category of a file.
+//
+// Parameters:
+//   - filename: Name of the file used to find its category
+//
+// Returns:
+//   - int: Thread count of the category, 0 if the category doesn't override it
+func (s *Settings) GetCategoryThreadCount(filename string) int {
+	if category := s.findCategory(filename); category != nil {
+		return max(category.ThreadCount, 0)
+	}
+
+	return 0
 }
 
 // ValidateSettings performs basic validation of the settings
@@ -237,9 +439,113 @@ func (s *Settings) ValidateSettings() []string {
 		}
 	}
 
+	// Validate category definitions
+	for _, validationErr := range s.ValidateCategoryInfo() {
+		if validationErr.Severity == VALIDATION_ERROR {
+			warnings = append(warnings, "Error: "+validationErr.Error())
+		} else {
+			warnings = append(warnings, validationErr.Error())
+		}
+	}
+
 	return warnings
 }
 
+// ValidateCategoryInfo checks the category definitions for common mistakes.
+//
+// Checks:
+//   - Each category name is unique
+//   - Each category has at least one extension
+//   - Each OutputDir is an absolute path
+//   - Each glob pattern in the extensions is valid
+//   - Each extension appears in at most one category (strict mode only)
+//
+// Returns:
+//   - []ValidationError: Problems found, reported as errors in strict mode and warnings otherwise
+//
+// Example:
+//
+//	for _, problem := range UDMSettings.ValidateCategoryInfo() {
+//		fmt.Printf("[%s] %s\n", problem.Severity, problem.Error())
+//	}
+func (s *Settings) ValidateCategoryInfo() []ValidationError {
+	var problems []ValidationError
+
+	severity := VALIDATION_WARNING
+	if s.StrictValidation {
+		severity = VALIDATION_ERROR
+	}
+
+	addProblem := func(field, message string) {
+		problems = append(problems, ValidationError{
+			Field:    field,
+			Message:  message,
+			Severity: severity,
+		})
+	}
+
+	seenNames := make(map[string]int)
+	seenExts := make(map[string]string)
+
+	for i, category := range s.CategoryInfo {
+		field := fmt.Sprintf("categoryInfo[%d]", i)
+
+		// Category names must be unique
+		name := strings.ToLower(category.Name)
+		if name == "" {
+			addProblem(field+".name", "category name is empty")
+		} else if first, exists := seenNames[name]; exists {
+			addProblem(field+".name", fmt.Sprintf("duplicate category name %q (first defined at categoryInfo[%d])", category.Name, first))
+		} else {
+			seenNames[name] = i
+		}
+
+		// Each category needs at least one extension
+		if len(category.Exts) == 0 {
+			addProblem(field+".exts", fmt.Sprintf("category %q has no extensions", category.Name))
+		}
+
+		// Output directories must be absolute
+		if category.OutputDir == "" {
+			addProblem(field+".outputDir", fmt.Sprintf("category %q has an empty output directory", category.Name))
+		} else if !filepath.IsAbs(category.OutputDir) {
+			addProblem(field+".outputDir", fmt.Sprintf("output directory is not an absolute path: %s", category.OutputDir))
+		}
+
+		// Thread count overrides must not be negative
+		if category.ThreadCount < 0 {
+			addProblem(field+".threadCount", fmt.Sprintf("category %q has a negative thread count: %d", category.Name, category.ThreadCount))
+		}
+
+		// Glob patterns must be valid, otherwise they never match
+		for _, ext := range category.Exts {
+			if isExtensionPattern(ext) {
+				if _, err := path.Match(ext, ""); err != nil {
+					addProblem(field+".exts", fmt.Sprintf("invalid extension pattern %q in category %q", ext, category.Name))
+				}
+			}
+		}
+
+		// Extensions may only be claimed by a single category in strict mode,
+		// overlapping glob patterns are resolved by priority instead
+		if s.StrictValidation {
+			for _, ext := range category.Exts {
+				ext = strings.ToLower(ext)
+				if isExtensionPattern(ext) {
+					continue
+				}
+				if owner, exists := seenExts[ext]; exists && owner != category.Name {
+					addProblem(field+".exts", fmt.Sprintf("extension %q is already used by category %q", ext, owner))
+					continue
+				}
+				seenExts[ext] = category.Name
+			}
+		}
+	}
+
+	return problems
+}
+
 // CreateMissingDirectories creates any missing output directories
 func (s *Settings) CreateMissingDirectories() error {
 	// Create main output directory
@@ -0,0 +1,47 @@
+package udm
+
+import "testing"
+
+func TestApplySettingsToDownloaderThreadCount(t *testing.T) {
+	categories := []CategoryInfo{
+		{Name: "Videos", Exts: []string{"mp4", "mkv"}, ThreadCount: 8},
+		{Name: "Documents", Exts: []string{"pdf", "docx"}, ThreadCount: 2},
+	}
+
+	tests := []struct {
+		name       string
+		settings   Settings
+		filename   string
+		userThread int
+		want       int
+	}{
+		{name: "video category", settings: Settings{CategoryInfo: categories, ThreadCount: 4}, filename: "movie.mp4", want: 8},
+		{name: "document category", settings: Settings{CategoryInfo: categories, ThreadCount: 4}, filename: "report.PDF", want: 2},
+		{name: "global without category", settings: Settings{CategoryInfo: categories, ThreadCount: 4}, filename: "notes.txt", want: 4},
+		{name: "user over category", settings: Settings{CategoryInfo: categories, ThreadCount: 4}, filename: "movie.mkv", userThread: 3, want: 3},
+		{name: "category over benchmark", settings: Settings{CategoryInfo: categories, BenchmarkedOptimalThreads: 16}, filename: "movie.mp4", want: 8},
+		{name: "global over benchmark", settings: Settings{CategoryInfo: categories, ThreadCount: 4, BenchmarkedOptimalThreads: 16}, filename: "notes.txt", want: 4},
+		{name: "user over benchmark", settings: Settings{CategoryInfo: categories, BenchmarkedOptimalThreads: 16}, filename: "notes.txt", userThread: 3, want: 3},
+		{name: "benchmark without global", settings: Settings{CategoryInfo: categories, BenchmarkedOptimalThreads: 16}, filename: "notes.txt", want: 16},
+		{name: "default", settings: Settings{CategoryInfo: categories}, filename: "notes.txt", want: 8},
+	}
+
+	previous := UDMSettings
+	defer func() { UDMSettings = previous }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := tt.settings
+			UDMSettings = &settings
+
+			d := &Downloader{}
+			d.fileInfo.Name = tt.filename
+			d.Prefs.threadCount = tt.userThread
+
+			settings.ApplySettingsToDownloader(d)
+			if got := d.getOptimalThreadCount(); got != tt.want {
+				t.Errorf("thread count for %s = %d, want %d", tt.filename, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package udm
+
+import (
+	"context"
+	"fmt"
+)
+
+// StartDownloadAsync starts the download in a new goroutine and returns immediately.
+// Use Wait or WaitWithContext to block until it has finished.
+//
+// Returns:
+//   - error: Error if the URL is invalid (see ValidateURL) or the download is already running
+//
+// Example:
+//
+//	downloader := &Downloader{Url: "https://example.com/file.zip"}
+//	if err := downloader.StartDownloadAsync(); err != nil {
+//	    log.Fatal(err)
+//	}
+//	// ... do other work ...
+//	if err := downloader.Wait(); err != nil {
+//	    log.Println("Download failed:", err)
+//	}
+func (d *Downloader) StartDownloadAsync() error {
+	if err := ValidateURL(d.requestURL()); err != nil {
+		return err
+	}
+
+	if !d.prepareDone() {
+		return fmt.Errorf("download is already running")
+	}
+
+	go d.StartDownload()
+	return nil
+}
+
+// Wait blocks until the download started with StartDownload or StartDownloadAsync has finished.
+//
+// Returns:
+//   - error: The download error (d.Error), nil if it completed or was stopped
+func (d *Downloader) Wait() error {
+	return d.WaitWithContext(context.Background())
+}
+
+// WaitWithContext blocks until the download has finished or ctx is done.
+// The download keeps running when ctx is done, use StopDownload to cancel it.
+//
+// Parameters:
+//   - ctx: Context limiting how long to wait
+//
+// Returns:
+//   - error: The download error, ctx.Err() if ctx was done first, or an error if the download was never started
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+//	defer cancel()
+//	if err := downloader.WaitWithContext(ctx); errors.Is(err, context.DeadlineExceeded) {
+//	    downloader.StopDownload()
+//	}
+func (d *Downloader) WaitWithContext(ctx context.Context) error {
+	d.doneMu.Lock()
+	done := d.done
+	d.doneMu.Unlock()
+
+	if done == nil {
+		return fmt.Errorf("download was not started")
+	}
+
+	select {
+	case <-done:
+		return d.Error
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// prepareDone creates the channel closed when the download finishes, unless one is still open.
+//
+// Returns:
+//   - bool: True if a new channel was created, false if the download is still running
+func (d *Downloader) prepareDone() bool {
+	d.doneMu.Lock()
+	defer d.doneMu.Unlock()
+
+	if d.done != nil {
+		select {
+		case <-d.done:
+		default:
+			return false
+		}
+	}
+
+	d.done = make(chan struct{})
+	return true
+}
+
+// markDone wakes up everyone waiting for the download, safe to call more than once.
+func (d *Downloader) markDone() {
+	d.doneMu.Lock()
+	defer d.doneMu.Unlock()
+
+	if d.done == nil {
+		return
+	}
+
+	select {
+	case <-d.done:
+	default:
+		close(d.done)
+	}
+}
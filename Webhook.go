@@ -0,0 +1,119 @@
+package udm
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// WEBHOOK_TIMEOUT limits each webhook request, including reading the response
+const WEBHOOK_TIMEOUT = 10 * time.Second
+
+// Events that can be sent to Settings.WebhookURL, listed in Settings.WebhookEvents
+const (
+	WEBHOOK_EVENT_START  = "start"
+	WEBHOOK_EVENT_FINISH = "finish"
+	WEBHOOK_EVENT_ERROR  = "error"
+	WEBHOOK_EVENT_STOP   = "stop"
+)
+
+// defaultWebhookEvents are sent when Settings.WebhookEvents is empty
+var defaultWebhookEvents = []string{WEBHOOK_EVENT_FINISH, WEBHOOK_EVENT_ERROR}
+
+// sendWebhook posts the state of the download to Settings.WebhookURL in the background.
+// The body is the JSON of GetFinishedMap for the finish event and of GetProgressMap
+// otherwise, with the event name in "event" and the error message in "error".
+//
+// The X-UDM-Signature header holds "sha256=" and the hex HMAC-SHA256 of the body keyed with
+// Settings.WebhookSecret, so receivers can check that the request was sent by UDM.
+//
+// Parameters:
+//   - event: One of the WEBHOOK_EVENT_* constants
+//
+// Notes:
+//   - Failures are only logged, they never affect the download
+func (d *Downloader) sendWebhook(event string) {
+	settings := UDMSettings
+	if settings == nil || settings.WebhookURL == "" {
+		return
+	}
+
+	events := settings.WebhookEvents
+	if len(events) == 0 {
+		events = defaultWebhookEvents
+	}
+	if !slices.Contains(events, event) {
+		return
+	}
+
+	// Build the body now so it describes the download at the time of the event
+	var payload map[string]interface{}
+	if event == WEBHOOK_EVENT_FINISH {
+		payload = d.GetFinishedMap()
+	} else {
+		payload = d.GetProgressMap()
+	}
+	payload["event"] = event
+	if event == WEBHOOK_EVENT_ERROR && d.Error != nil {
+		payload["error"] = d.Error.Error()
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		logf("Failed to encode webhook payload: %v\n", err)
+		return
+	}
+
+	go postWebhook(settings.WebhookURL, settings.WebhookSecret, event, body)
+}
+
+// postWebhook sends a webhook request and logs failures.
+//
+// Parameters:
+//   - webhookURL: URL to post to
+//   - secret: Key of the HMAC signature, empty to send no signature
+//   - event: Event name sent in the X-UDM-Event header
+//   - body: JSON body
+func postWebhook(webhookURL, secret, event string, body []byte) {
+	req, err := http.NewRequest("POST", webhookURL, bytes.NewReader(body))
+	if err != nil {
+		logf("Failed to create webhook request: %v\n", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-UDM-Event", event)
+	if secret != "" {
+		req.Header.Set("X-UDM-Signature", "sha256="+webhookSignature(secret, body))
+	}
+
+	client := &http.Client{Timeout: WEBHOOK_TIMEOUT}
+	resp, err := client.Do(req)
+	if err != nil {
+		logf("Failed to send webhook: %v\n", err)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		logf("Webhook %s rejected the %s event: %s\n", webhookURL, event, resp.Status)
+	}
+}
+
+// webhookSignature computes the hex HMAC-SHA256 of a webhook body.
+//
+// Parameters:
+//   - secret: Key of the signature
+//   - body: Request body
+//
+// Returns:
+//   - string: Lowercase hex digest
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
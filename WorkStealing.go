@@ -0,0 +1,266 @@
+package udm
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"udl/udm/ufs"
+)
+
+// MIN_SPLIT_CHUNK_SIZE is the number of bytes a chunk must have left to be split,
+// smaller remainders finish sooner than a new connection can be opened
+const MIN_SPLIT_CHUNK_SIZE = 4 * 1024 * 1024 // 4MB
+
+// SplitChunk bisects the bytes of a chunk that are not downloaded yet, so an idle
+// worker can download the second half while the current worker finishes the first.
+// The chunk is shrunk in place and the second half is appended as a new chunk.
+//
+// Parameters:
+//   - index: Index of the chunk to split
+//
+// Returns:
+//   - ChunkData: The shrunk chunk, still downloaded by its current worker
+//   - ChunkData: The new chunk holding the second half
+//   - bool: False if the chunk is completed or has less than MIN_SPLIT_CHUNK_SIZE bytes left
+//
+// Example:
+//
+//	first, second, ok := downloader.ChunkManager.SplitChunk(3)
+//	if ok {
+//	    fmt.Printf("Chunk %d now ends at %d, chunk %d starts at %d\n", first.Index, first.End, second.Index, second.Start)
+//	}
+func (cm *ChunkManager) SplitChunk(index int) (ChunkData, ChunkData, bool) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	if index < 0 || index >= len(cm.Chunks) {
+		return ChunkData{}, ChunkData{}, false
+	}
+
+	chunk := cm.Chunks[index]
+	remaining := chunk.Size - cm.downloaded[index]
+	if chunk.IsCompleted || remaining < MIN_SPLIT_CHUNK_SIZE {
+		return ChunkData{}, ChunkData{}, false
+	}
+
+	// The current worker keeps the first half of the remaining bytes
+	mid := chunk.Start + cm.downloaded[index] + remaining/2
+	second := ChunkData{
+		Index: len(cm.Chunks),
+		Start: mid,
+		End:   chunk.End,
+		Size:  chunk.End - mid + 1,
+	}
+
+	chunk.End = mid - 1
+	chunk.Size = mid - chunk.Start
+	cm.Chunks[index] = chunk
+	cm.Chunks = append(cm.Chunks, second)
+
+	return chunk, second, true
+}
+
+// chunk returns the current range of a chunk, which shrinks when the chunk is split.
+//
+// Parameters:
+//   - index: Index of the chunk
+//
+// Returns:
+//   - ChunkData: Copy of the chunk
+func (cm *ChunkManager) chunk(index int) ChunkData {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	return cm.Chunks[index]
+}
+
+// startChunk marks a chunk as being downloaded, starting at the bytes already on disk.
+//
+// Parameters:
+//   - index: Index of the chunk
+//   - resumeOffset: Bytes of the chunk already written
+func (cm *ChunkManager) startChunk(index int, resumeOffset int64) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	cm.downloaded[index] = resumeOffset
+	cm.active[index] = true
+}
+
+// stopChunk marks a chunk as no longer being downloaded.
+//
+// Parameters:
+//   - index: Index of the chunk
+//   - completed: Whether the chunk was downloaded completely
+func (cm *ChunkManager) stopChunk(index int, completed bool) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	delete(cm.active, index)
+	if completed {
+		cm.Chunks[index].IsCompleted = true
+	}
+}
+
+// claimBytes records received bytes of a chunk, limited to the current end of the chunk.
+//
+// Parameters:
+//   - index: Index of the chunk
+//   - n: Number of bytes received
+//
+// Returns:
+//   - int64: Number of bytes to write, less than n if the chunk was split in the meantime
+func (cm *ChunkManager) claimBytes(index int, n int64) int64 {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	n = max(min(n, cm.Chunks[index].Size-cm.downloaded[index]), 0)
+	cm.downloaded[index] += n
+	return n
+}
+
+// largestActiveChunk returns the running chunk with the most bytes left.
+//
+// Returns:
+//   - int: Index of the chunk
+//   - bool: False if no chunk is running
+func (cm *ChunkManager) largestActiveChunk() (int, bool) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	largest, found := -1, false
+	var largestRemaining int64
+	for index := range cm.active {
+		remaining := cm.Chunks[index].Size - cm.downloaded[index]
+		if !found || remaining > largestRemaining {
+			largest, largestRemaining, found = index, remaining, true
+		}
+	}
+	return largest, found
+}
+
+// prepareChunkManager points the chunk manager at the chunks about to be downloaded.
+// Chunks restored from a saved state don't have a chunk manager yet.
+func (d *Downloader) prepareChunkManager() {
+	if d.ChunkManager == nil {
+		d.ChunkManager = &ChunkManager{TotalSize: d.ServerHeaders.Filesize}
+	}
+
+	d.ChunkManager.mutex.Lock()
+	defer d.ChunkManager.mutex.Unlock()
+
+	d.ChunkManager.Chunks = d.Chunks
+	d.ChunkManager.downloaded = make(map[int]int64)
+	d.ChunkManager.active = make(map[int]bool)
+}
+
+// syncChunks makes d.Chunks include the chunks added by SplitChunk and extends the
+// chunk progress and the chunk spans to them.
+func (d *Downloader) syncChunks() {
+	d.ChunkManager.mutex.Lock()
+	defer d.ChunkManager.mutex.Unlock()
+
+	d.Chunks = d.ChunkManager.Chunks
+
+	// Chunk progress is only tracked if it was initialized, see InitializeChunkProgress
+	if len(d.ChunkProgress) > 0 {
+		for i := len(d.ChunkProgress); i < len(d.Chunks); i++ {
+			d.ChunkProgress = append(d.ChunkProgress, ChunkProgressData{Index: i})
+		}
+	}
+
+	if d.downloadSpan != nil {
+		for i := len(d.chunkSpans); i < len(d.Chunks); i++ {
+			d.chunkSpans = append(d.chunkSpans, d.startChunkSpan(d.Chunks[i]))
+		}
+	}
+}
+
+// lockChunks locks the mutex of the chunk manager, which guards d.Chunks, d.ChunkProgress
+// and d.chunkSpans while workers can split chunks. Nothing is locked without a chunk manager.
+//
+// Returns:
+//   - func(): Unlocks the mutex again
+func (d *Downloader) lockChunks() func() {
+	cm := d.ChunkManager
+	if cm == nil {
+		return func() {}
+	}
+
+	cm.mutex.Lock()
+	return cm.mutex.Unlock
+}
+
+// stealChunks keeps an idle worker busy by splitting the largest running chunk and
+// downloading its second half, until no running chunk is large enough to split.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - client: HTTP client shared by all chunk downloads
+//   - totalCompletedBytes: Pointer to atomic counter for total progress
+//
+// Returns:
+//   - error: Error if downloading a second half fails
+func (d *Downloader) stealChunks(ctx context.Context, client *http.Client, totalCompletedBytes *int64) error {
+	for ctx.Err() == nil {
+		index, ok := d.ChunkManager.largestActiveChunk()
+		if !ok {
+			return nil
+		}
+
+		_, second, ok := d.ChunkManager.SplitChunk(index)
+		if !ok {
+			return nil
+		}
+		d.syncChunks()
+
+		// Chunk file names only depend on the index, so the new name follows the existing ones
+		chunkFile := ufs.GenerateChunkFileNames(d.fileInfo.Name, second.Index+1, d.fileInfo.Dir)[second.Index]
+		if err := ufs.CreateFile(chunkFile); err != nil {
+			return &DiskError{Op: "create", Path: chunkFile, Err: err}
+		}
+
+		if err := d.downloadChunk(ctx, client, second.Index, chunkFile, totalCompletedBytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chunkFilesInFileOrder returns the chunk files sorted by the start of their chunk.
+// Chunks added by SplitChunk are at the end of d.Chunks but belong in the middle of the file.
+//
+// Returns:
+//   - []string: Paths of the chunk files in the order they have to be merged
+func (d *Downloader) chunkFilesInFileOrder() []string {
+	chunkFileNames := ufs.GenerateChunkFileNames(d.fileInfo.Name, len(d.Chunks), d.fileInfo.Dir)
+
+	order := make([]int, len(d.Chunks))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return d.Chunks[order[i]].Start < d.Chunks[order[j]].Start
+	})
+
+	ordered := make([]string, len(order))
+	for i, index := range order {
+		ordered[i] = chunkFileNames[index]
+	}
+	return ordered
+}
+
+// chunkOffsetsInFileOrder returns the offset of each chunk in the output file,
+// in the order of chunkFilesInFileOrder.
+//
+// Returns:
+//   - []int64: Start offsets sorted in ascending order
+func (d *Downloader) chunkOffsetsInFileOrder() []int64 {
+	offsets := make([]int64, len(d.Chunks))
+	for i, chunk := range d.Chunks {
+		offsets[i] = chunk.Start
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	return offsets
+}
@@ -0,0 +1,96 @@
+package udm
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// slowReader delays every read, to keep a chunk running long enough to be split
+type slowReader struct {
+	*bytes.Reader
+}
+
+func (r slowReader) Read(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	return r.Reader.Read(p[:min(len(p), 64*1024)])
+}
+
+func TestWorkStealingSplitsChunksSafely(t *testing.T) {
+	if testing.Short() {
+		t.Skip("downloads 24 MB from a throttled server")
+	}
+
+	previous := UDMSettings
+	UDMSettings = &Settings{}
+	defer func() { UDMSettings = previous }()
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), 24*1024*1024/16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Only the first chunk is slow, so the worker of the second one splits it
+		if strings.HasPrefix(r.Header.Get("Range"), "bytes=0-") && r.Header.Get("Range") != "bytes=0-0" {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			http.ServeContent(w, r, "data.bin", time.Time{}, slowReaderSeeker{slowReader{bytes.NewReader(content)}})
+			return
+		}
+		http.ServeContent(w, r, "data.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	d := &Downloader{Url: server.URL + "/data.bin"}
+	d.Prefs.DownloadDir = t.TempDir()
+	d.Prefs.threadCount = 2
+	d.SetTracer(noop.NewTracerProvider().Tracer("test"))
+	// Read the chunks the way a progress display does while they are split
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var startReading sync.Once
+	readChunks := func() {
+		for ctx.Err() == nil {
+			d.GetChunkCount()
+			d.GetCompletedChunkCount()
+			d.GetChunkProgressData()
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	d.Callbacks = &Callbacks{
+		OnStart: func(d *Downloader) { d.InitializeChunkProgress(2) },
+		OnChunkStart: func(d *Downloader, chunkIndex int, start, end int64) {
+			startReading.Do(func() { go readChunks() })
+		},
+	}
+
+	d.StartDownload()
+	cancel()
+
+	if d.Error != nil {
+		t.Fatalf("download failed: %v", d.Error)
+	}
+	if len(d.Chunks) <= 2 {
+		t.Errorf("download used %d chunks, want the slow chunk split", len(d.Chunks))
+	}
+	if len(d.ChunkProgress) != len(d.Chunks) {
+		t.Errorf("tracking progress of %d chunks, want %d", len(d.ChunkProgress), len(d.Chunks))
+	}
+	if got, _ := os.ReadFile(d.OutputPath); !bytes.Equal(got, content) {
+		t.Errorf("downloaded %d bytes that differ from the %d bytes of the file", len(got), len(content))
+	}
+}
+
+// slowReaderSeeker adds Seek to slowReader for http.ServeContent
+type slowReaderSeeker struct {
+	slowReader
+}
+
+func (r slowReaderSeeker) Seek(offset int64, whence int) (int64, error) {
+	return r.Reader.Seek(offset, whence)
+}
@@ -3,24 +3,27 @@ package udm
 // Returns a map for progress with all info
 // all fields are mandatory and should fill with a valid value
 func (d *Downloader) GetProgressMap() map[string]interface{} {
+	// Read all progress values at once so they belong to the same moment
+	progress := d.GetProgressSnapshot()
+	filesize := d.GetFileSize()
 
 	return map[string]interface{}{
 		"id":         d.GetID(),
 		"status":     d.GetStatus(),
-		"percentage": d.GetProgressPercent(),
-		"downloaded": d.GetDownloadedBytes(),
-		"filesize":   d.GetFileSize(),
-		"speed":      d.GetCurrentSpeed(),
-		"eta":        d.GetETA().Seconds(),
+		"percentage": progress.Percentage,
+		"downloaded": progress.BytesCompleted,
+		"filesize":   filesize,
+		"speed":      progress.SpeedBps,
+		"eta":        progress.ETA.Seconds(),
 
 		"readable": map[string]interface{}{
 			"id":         d.GetID(),
 			"status":     d.GetStatus(),
-			"percent":    ReadablePercentage(d.GetProgressPercent()),
-			"downloaded": ReadableFileSize(d.GetDownloadedBytes()),
-			"filesize":   ReadableFileSize(d.GetFileSize()),
-			"speed":      InMBPS(d.GetCurrentSpeed()),
-			"eta":        ReadableTime(int64(d.GetETA().Seconds())),
+			"percent":    ReadablePercentage(progress.Percentage),
+			"downloaded": ReadableFileSize(progress.BytesCompleted),
+			"filesize":   ReadableFileSize(filesize),
+			"speed":      ReadableSpeed(progress.SpeedBps),
+			"eta":        ReadableTime(int64(progress.ETA.Seconds())),
 		},
 	}
 }
@@ -28,14 +31,15 @@ func (d *Downloader) GetProgressMap() map[string]interface{} {
 // Returns a map for finished download with all info
 func (d *Downloader) GetFinishedMap() map[string]interface{} {
 	return map[string]interface{}{
-		"id":         d.GetID(),
-		"status":     d.GetStatus(),
-		"filename":   d.GetFilename(),
-		"output_dir": d.GetOutputDir(),
-		"filepath":   d.GetFilePath(),
-		"filesize":   d.GetFileSize(),
-		"time_taken": int64(d.GetTimeTaken().Seconds()),
-		"avg_speed":  d.GetAverageSpeed(),
+		"id":          d.GetID(),
+		"status":      d.GetStatus(),
+		"filename":    d.GetFilename(),
+		"output_dir":  d.GetOutputDir(),
+		"filepath":    d.GetFilePath(),
+		"filesize":    d.GetFileSize(),
+		"time_taken":  int64(d.GetTimeTaken().Seconds()),
+		"avg_speed":   d.GetAverageSpeed(),
+		"retry_stats": d.GetRetryStats(),
 
 		"readable": map[string]interface{}{
 			"id":         d.GetID(),
@@ -45,7 +49,7 @@ func (d *Downloader) GetFinishedMap() map[string]interface{} {
 			"filepath":   d.GetFilePath(),
 			"filesize":   ReadableFileSize(d.GetFileSize()),
 			"time_taken": ReadableTime(int64(d.GetTimeTaken().Seconds())),
-			"avg_speed":  InMBPS(d.GetAverageSpeed()),
+			"avg_speed":  ReadableSpeed(d.GetAverageSpeed()),
 		},
 	}
 }
@@ -0,0 +1,238 @@
+// Package metrics exports download statistics of udm downloaders as Prometheus metrics.
+// The metrics are registered with the default Prometheus registry when this package
+// is imported, callers who don't import it are not affected.
+package metrics
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"udl/udm"
+)
+
+var (
+	downloadsStarted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "udm_downloads_started_total",
+		Help: "Number of downloads started.",
+	})
+
+	downloadsCompleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "udm_downloads_completed_total",
+		Help: "Number of downloads completed successfully.",
+	})
+
+	downloadsFailed = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "udm_downloads_failed_total",
+		Help: "Number of downloads that failed.",
+	})
+
+	bytesDownloaded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "udm_bytes_downloaded_total",
+		Help: "Number of bytes downloaded, by host of the download URL.",
+	}, []string{"url_host"})
+
+	activeDownloads = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "udm_active_downloads",
+		Help: "Number of downloads currently running.",
+	})
+
+	currentSpeed = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "udm_current_speed_bytes_per_second",
+		Help: "Combined download speed of all running downloads.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		downloadsStarted,
+		downloadsCompleted,
+		downloadsFailed,
+		bytesDownloaded,
+		activeDownloads,
+		currentSpeed,
+	)
+}
+
+// downloadState holds what was already reported for a running download
+type downloadState struct {
+	host          string
+	reportedBytes int64
+	speed         float64
+}
+
+var (
+	mu      sync.Mutex
+	running = make(map[*udm.Downloader]*downloadState)
+)
+
+// Handler returns the HTTP handler serving the metrics of the default Prometheus registry.
+//
+// Returns:
+//   - http.Handler: Handler to mount, usually at /metrics
+//
+// Example:
+//
+//	http.Handle("/metrics", metrics.Handler())
+//	go http.ListenAndServe(":9090", nil)
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// AttachToDownloader reports the progress of a download to the metrics.
+// The existing callbacks of the downloader are kept and still called.
+// Call it before starting the download.
+//
+// Parameters:
+//   - d: The downloader to monitor
+//
+// Example:
+//
+//	downloader := &udm.Downloader{Url: "https://example.com/file.zip"}
+//	metrics.AttachToDownloader(downloader)
+//	downloader.StartDownload()
+func AttachToDownloader(d *udm.Downloader) {
+	callbacks := udm.Callbacks{}
+	if d.Callbacks != nil {
+		callbacks = *d.Callbacks
+	}
+	original := callbacks
+
+	callbacks.OnStart = func(d *udm.Downloader) {
+		started(d)
+		if original.OnStart != nil {
+			original.OnStart(d)
+		}
+	}
+
+	callbacks.OnProgress = func(d *udm.Downloader) {
+		progressed(d)
+		if original.OnProgress != nil {
+			original.OnProgress(d)
+		}
+	}
+
+	callbacks.OnFinish = func(d *udm.Downloader) {
+		if finished(d) {
+			downloadsCompleted.Inc()
+		}
+		if original.OnFinish != nil {
+			original.OnFinish(d)
+		}
+	}
+
+	callbacks.OnError = func(d *udm.Downloader, err error) {
+		finished(d)
+		downloadsFailed.Inc()
+		if original.OnError != nil {
+			original.OnError(d, err)
+		}
+	}
+
+	callbacks.OnStop = func(d *udm.Downloader) {
+		finished(d)
+		if original.OnStop != nil {
+			original.OnStop(d)
+		}
+	}
+
+	d.Callbacks = &callbacks
+}
+
+// started counts a download and marks it as running.
+//
+// Parameters:
+//   - d: The started downloader
+func started(d *udm.Downloader) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := running[d]; ok {
+		return
+	}
+
+	running[d] = &downloadState{host: urlHost(d.GetURL())}
+	downloadsStarted.Inc()
+	activeDownloads.Inc()
+}
+
+// progressed adds the bytes received since the last report and updates the speed.
+//
+// Parameters:
+//   - d: The downloader reporting progress
+func progressed(d *udm.Downloader) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	state, ok := running[d]
+	if !ok {
+		return
+	}
+
+	progress := d.GetProgressSnapshot()
+	reportBytes(progress.BytesCompleted, state)
+	state.speed = progress.SpeedBps
+	updateSpeed()
+}
+
+// finished reports the last bytes of a download and removes it from the running downloads.
+//
+// Parameters:
+//   - d: The finished downloader
+//
+// Returns:
+//   - bool: True if the download was running, false if it was already reported
+func finished(d *udm.Downloader) bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	state, ok := running[d]
+	if !ok {
+		return false
+	}
+
+	reportBytes(d.GetDownloadedBytes(), state)
+	delete(running, d)
+	activeDownloads.Dec()
+	updateSpeed()
+	return true
+}
+
+// reportBytes adds the bytes downloaded since the last report to the byte counter, mu must be held.
+//
+// Parameters:
+//   - downloaded: Bytes the download has received so far
+//   - state: What was already reported for it
+func reportBytes(downloaded int64, state *downloadState) {
+	if downloaded > state.reportedBytes {
+		bytesDownloaded.WithLabelValues(state.host).Add(float64(downloaded - state.reportedBytes))
+		state.reportedBytes = downloaded
+	}
+}
+
+// updateSpeed sets the speed gauge to the sum of all running downloads, mu must be held
+func updateSpeed() {
+	var total float64
+	for _, state := range running {
+		total += state.speed
+	}
+	currentSpeed.Set(total)
+}
+
+// urlHost returns the host name of a URL for the url_host label.
+//
+// Parameters:
+//   - rawURL: The download URL
+//
+// Returns:
+//   - string: Host name without port, "unknown" if the URL cannot be parsed
+func urlHost(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return "unknown"
+	}
+	return parsed.Hostname()
+}
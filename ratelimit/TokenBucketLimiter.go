@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+
+	"golang.org/x/time/rate"
+)
+
+// TokenBucketLimiter limits the number of bytes transferred per second.
+// A single limiter can be shared by several goroutines, in which case they
+// compete for the same budget and their combined speed stays under the cap.
+type TokenBucketLimiter struct {
+	limiter *rate.Limiter
+	burst   int
+}
+
+// NewTokenBucketLimiter creates a limiter allowing bytesPerSecond bytes per second.
+// The bucket holds one second worth of tokens so short bursts are smoothed out.
+//
+// Parameters:
+//   - bytesPerSecond: Maximum transfer speed in bytes per second (must be > 0)
+//
+// Returns:
+//   - *TokenBucketLimiter: Limiter ready to use, nil if bytesPerSecond <= 0
+//
+// Example:
+//
+//	limiter := NewTokenBucketLimiter(512 * 1024) // 512 KB/s
+//	n, _ := reader.Read(buffer)
+//	if err := limiter.WaitN(ctx, n); err != nil {
+//	    return err
+//	}
+func NewTokenBucketLimiter(bytesPerSecond int64) *TokenBucketLimiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+
+	burst := int(min(bytesPerSecond, int64(math.MaxInt32)))
+	return &TokenBucketLimiter{
+		limiter: rate.NewLimiter(rate.Limit(bytesPerSecond), burst),
+		burst:   burst,
+	}
+}
+
+// WaitN blocks until n bytes may be transferred or ctx is done.
+// Requests larger than the bucket size are split into several waits.
+//
+// Parameters:
+//   - ctx: Context for cancellation
+//   - n: Number of bytes just transferred
+//
+// Returns:
+//   - error: ctx error if the wait was cancelled, nil otherwise
+func (l *TokenBucketLimiter) WaitN(ctx context.Context, n int) error {
+	for n > 0 {
+		take := min(n, l.burst)
+		if err := l.limiter.WaitN(ctx, take); err != nil {
+			return err
+		}
+		n -= take
+	}
+	return nil
+}
+
+// Limit returns the configured speed in bytes per second.
+func (l *TokenBucketLimiter) Limit() int64 {
+	return int64(l.limiter.Limit())
+}
@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketLimiterThroughput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("measures throughput for about two seconds")
+	}
+
+	const bytesPerSecond = 1024 * 1024
+	const streams = 4
+	const bytesPerStream = bytesPerSecond / 2 // Two seconds in total
+
+	payload := bytes.Repeat([]byte("u"), bytesPerStream)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(payload)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	limiter := NewTokenBucketLimiter(bytesPerSecond)
+
+	// The bucket starts full, empty it so the measurement only covers the refill rate
+	if err := limiter.WaitN(ctx, bytesPerSecond); err != nil {
+		t.Fatal(err)
+	}
+
+	// All streams share the limiter like the chunk workers of a multi-stream download
+	var received atomic.Int64
+	var wg sync.WaitGroup
+	errs := make(chan error, streams)
+	start := time.Now()
+
+	for range streams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			resp, err := http.Get(server.URL)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer resp.Body.Close()
+
+			buffer := make([]byte, 32*1024)
+			for {
+				n, err := resp.Body.Read(buffer)
+				if n > 0 {
+					received.Add(int64(n))
+					if waitErr := limiter.WaitN(ctx, n); waitErr != nil {
+						errs <- waitErr
+						return
+					}
+				}
+				if err == io.EOF {
+					return
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	elapsed := time.Since(start).Seconds()
+	if received.Load() != streams*bytesPerStream {
+		t.Fatalf("received %d bytes, want %d", received.Load(), streams*bytesPerStream)
+	}
+
+	throughput := float64(received.Load()) / elapsed
+	if throughput < 0.9*bytesPerSecond || throughput > 1.1*bytesPerSecond {
+		t.Fatalf("throughput %.0f B/s is not within 10%% of the %d B/s cap", throughput, bytesPerSecond)
+	}
+}
+
+func TestNewTokenBucketLimiterDisabled(t *testing.T) {
+	for _, bytesPerSecond := range []int64{0, -1} {
+		if limiter := NewTokenBucketLimiter(bytesPerSecond); limiter != nil {
+			t.Errorf("NewTokenBucketLimiter(%d) = %v, want nil", bytesPerSecond, limiter)
+		}
+	}
+}
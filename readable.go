@@ -1,6 +1,16 @@
 package udm
 
-import "fmt"
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
 
 func printMap(m map[string]any) {
 	for key, value := range m {
@@ -35,12 +45,90 @@ func ReadableFileSize(size int64) string {
 
 }
 
+// numberFormat holds the separators used to format numbers in a locale
+type numberFormat struct {
+	decimal   string
+	thousands string
+}
+
+// numberFormats maps the supported locales to their separators
+var numberFormats = map[string]numberFormat{
+	"en": {decimal: ".", thousands: ","},
+	"de": {decimal: ",", thousands: "."},
+	"fr": {decimal: ",", thousands: " "},
+}
+
+// ReadableFileSizeLocale works like ReadableFileSize but formats the number
+// using the decimal and thousands separators of the given locale.
+//
+// Parameters:
+//   - size: The size in bytes
+//   - locale: "en" (1,023.50 KB), "de" (1.023,50 KB) or "fr" (1 023,50 KB), unknown locales use "en"
+//
+// Returns:
+//   - string: The formatted size
+//
+// Example:
+//
+//	fmt.Println(ReadableFileSizeLocale(1536, "de")) // 1,50 KB
+func ReadableFileSizeLocale(size int64, locale string) string {
+	format, ok := numberFormats[locale]
+	if !ok {
+		format = numberFormats["en"]
+	}
+
+	units := []string{"KB", "MB", "GB", "TB"}
+	if size < 1024 {
+		return formatNumberLocale(strconv.FormatInt(size, 10), format) + " B"
+	}
+
+	value := float64(size) / 1024
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+
+	return formatNumberLocale(strconv.FormatFloat(value, 'f', 2, 64), format) + " " + units[unit]
+}
+
+// formatNumberLocale replaces the separators of a number formatted with
+// strconv, e.g. "1023.50", with the separators of the given format
+func formatNumberLocale(number string, format numberFormat) string {
+	integer, fraction, hasFraction := strings.Cut(number, ".")
+
+	// Keep the sign out of the digits being grouped
+	var sb strings.Builder
+	if digits, ok := strings.CutPrefix(integer, "-"); ok {
+		sb.WriteString("-")
+		integer = digits
+	}
+
+	// Group the integer part in thousands
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			sb.WriteString(format.thousands)
+		}
+		sb.WriteRune(digit)
+	}
+
+	if hasFraction {
+		sb.WriteString(format.decimal)
+		sb.WriteString(fraction)
+	}
+	return sb.String()
+}
+
+// ReadableTime formats a duration in seconds as words, e.g. "45 seconds",
+// "5 minutes 30 seconds" or "2 hours 10 minutes". Negative durations are shown as 0 seconds.
 func ReadableTime(seconds int64) string {
+	seconds = max(seconds, 0)
+
 	if seconds < 60 {
 		return fmt.Sprintf("%d seconds", seconds)
 	} else if seconds < 3600 {
 		minutes := seconds / 60
-		return fmt.Sprintf("%d minutes", minutes)
+		return fmt.Sprintf("%d minutes %d seconds", minutes, seconds%60)
 	} else if seconds < 86400 {
 		hours := seconds / 3600
 		minutes := (seconds % 3600) / 60
@@ -53,11 +141,54 @@ func ReadableTime(seconds int64) string {
 	}
 }
 
-func InMBPS(speed float64) string {
-	if speed <= 0 {
-		return "0.00 MB/s" // Avoid division by zero
+// ReadableTimeShort formats a duration in seconds compactly as "MM:SS", or "HH:MM:SS"
+// from one hour on, for places with little space like the progress bar.
+// Negative durations are shown as "00:00".
+func ReadableTimeShort(seconds int64) string {
+	seconds = max(seconds, 0)
+
+	hours := seconds / 3600
+	minutes := (seconds % 3600) / 60
+	if hours > 0 {
+		return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds%60)
 	}
-	return fmt.Sprintf("%.2f MB/s", speed/(1024*1024)) // Convert bytes to megabytes
+	return fmt.Sprintf("%02d:%02d", minutes, seconds%60)
+}
+
+// ReadableSpeed formats a speed in bytes per second with the largest fitting unit,
+// e.g. "512 B/s", "12.50 KB/s", "3.20 MB/s" or "1.05 GB/s".
+//
+// Parameters:
+//   - bps: Speed in bytes per second, negative values are shown as 0
+//
+// Returns:
+//   - string: The formatted speed
+func ReadableSpeed(bps float64) string {
+	if !(bps > 0) {
+		return "0 B/s" // Negative speeds and NaN
+	}
+
+	// Units are chosen on the rounded value, so 1023.9 B/s is shown as "1.00 KB/s" instead of "1024 B/s"
+	if math.Round(bps) < 1024 {
+		return fmt.Sprintf("%.0f B/s", bps)
+	}
+
+	units := []string{"KB/s", "MB/s", "GB/s"}
+	value := bps / 1024
+	for _, unit := range units[:len(units)-1] {
+		if math.Round(value*100) < 1024*100 {
+			return fmt.Sprintf("%.2f %s", value, unit)
+		}
+		value /= 1024
+	}
+	return fmt.Sprintf("%.2f %s", value, units[len(units)-1])
+}
+
+// InMBPS formats a speed in bytes per second.
+//
+// Deprecated: Use ReadableSpeed, the unit is no longer always MB/s.
+func InMBPS(speed float64) string {
+	return ReadableSpeed(speed)
 }
 
 func ReadablePercentage(percentage float64) string {
@@ -68,3 +199,122 @@ func ReadablePercentage(percentage float64) string {
 	}
 	return fmt.Sprintf("%.2f%%", percentage)
 }
+
+// MakeProgressBar renders a plain-text progress bar using Unicode block elements,
+// e.g. [████████░░░░░░░░░░░░] 42.3%
+//
+// Parameters:
+//   - percentage: Completion percentage (0-100), clamped to that range
+//   - width: Number of block characters in the bar, excluding brackets and percentage
+//
+// Returns:
+//   - string: The rendered progress bar
+//
+// Example:
+//
+//	fmt.Printf("\r%s", MakeProgressBar(42.3, 20))
+func MakeProgressBar(percentage float64, width int) string {
+	percentage = max(0, min(percentage, 100))
+	width = max(width, 1)
+
+	filled := int(percentage / 100 * float64(width))
+	return fmt.Sprintf("[%s%s] %.1f%%", strings.Repeat("█", filled), strings.Repeat("░", width-filled), percentage)
+}
+
+// ReadableError converts a download error into a user-friendly message.
+// Structured errors (NetworkError, DiskError, ServerError, ChecksumError)
+// are detected anywhere in the wrap chain and explained with a hint on how
+// to fix them. Other errors are returned as is.
+//
+// Example:
+//
+//	downloader.Callbacks = &Callbacks{
+//		OnError: func(d *Downloader, err error) {
+//			fmt.Println(ReadableError(err))
+//		},
+//	}
+func ReadableError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var checksumErr *ChecksumError
+	if errors.As(err, &checksumErr) {
+		return fmt.Sprintf("The downloaded file does not match the expected %s checksum — the file may be corrupted, try downloading it again", checksumErr.Algo)
+	}
+
+	var hashErr *HashMismatchError
+	if errors.As(err, &hashErr) {
+		return "The SHA-256 of the downloaded file does not match the expected value — the file may be corrupted or tampered with, try downloading it again"
+	}
+
+	var spaceErr *InsufficientDiskSpaceError
+	if errors.As(err, &spaceErr) {
+		return fmt.Sprintf("Not enough disk space: the download needs %s but only %s is free — free up some space or choose a different download directory", ReadableFileSize(spaceErr.Required), ReadableFileSize(spaceErr.Available))
+	}
+
+	var incompleteErr *IncompleteDownloadError
+	if errors.As(err, &incompleteErr) {
+		return fmt.Sprintf("The server closed the connection after %s of %s — try resuming the download", ReadableFileSize(incompleteErr.Got), ReadableFileSize(incompleteErr.Expected))
+	}
+
+	var sizeErr *FileSizeLimitError
+	if errors.As(err, &sizeErr) {
+		return fmt.Sprintf("The file is %s but the limit is %s — raise MaxFileSizeBytes to download it", ReadableFileSize(sizeErr.Size), ReadableFileSize(sizeErr.Limit))
+	}
+
+	if errorCode(err) == ERROR_CODE_INVALID_URL {
+		return fmt.Sprintf("The download URL is invalid (%v) — check the download URL", err)
+	}
+
+	var serverErr *ServerError
+	if errors.As(err, &serverErr) {
+		switch {
+		case serverErr.StatusCode == 401 || serverErr.StatusCode == 403:
+			return fmt.Sprintf("Access denied by the server (%d %s) — check your credentials or cookies", serverErr.StatusCode, serverErr.StatusText())
+		case serverErr.StatusCode == 404:
+			return "The file was not found on the server (404) — check the download URL"
+		case serverErr.StatusCode == 429:
+			return "The server is rate limiting requests (429) — wait a while before trying again"
+		case serverErr.StatusCode >= 500:
+			return fmt.Sprintf("The server failed to handle the request (%d %s) — try again later", serverErr.StatusCode, serverErr.StatusText())
+		default:
+			return fmt.Sprintf("The server returned an unexpected response (%d %s)", serverErr.StatusCode, serverErr.StatusText())
+		}
+	}
+
+	var diskErr *DiskError
+	if errors.As(err, &diskErr) {
+		switch {
+		case errors.Is(diskErr.Err, syscall.ENOSPC):
+			return fmt.Sprintf("Not enough disk space to write %s — free up some space and resume the download", diskErr.Path)
+		case errors.Is(diskErr.Err, os.ErrPermission):
+			return fmt.Sprintf("Permission denied while writing %s — choose a different download directory", diskErr.Path)
+		default:
+			return fmt.Sprintf("Failed to %s %s — check that the download directory is accessible", diskErr.Op, diskErr.Path)
+		}
+	}
+
+	var tlsErr *TLSVersionError
+	if errors.As(err, &tlsErr) {
+		return fmt.Sprintf("%s does not support %s or newer — lower TLSMinVersion if you trust this server", tlsErr.Host, tls.VersionName(tlsErr.MinVersion))
+	}
+
+	var networkErr *NetworkError
+	if errors.As(err, &networkErr) {
+		var dnsErr *net.DNSError
+		var netErr net.Error
+		switch {
+		case errors.As(networkErr.Err, &dnsErr):
+			return fmt.Sprintf("Could not resolve %s — check the URL and your DNS settings", networkErr.Host)
+		case errors.Is(networkErr.Err, syscall.ECONNREFUSED):
+			return fmt.Sprintf("Connection refused while connecting to %s — check your internet connection", networkErr.Host)
+		case errors.As(networkErr.Err, &netErr) && netErr.Timeout():
+			return fmt.Sprintf("Connection to %s timed out — the server may be slow or unreachable", networkErr.Host)
+		default:
+			return fmt.Sprintf("Network error while connecting to %s — check your internet connection", networkErr.Host)
+		}
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,77 @@
+package udm
+
+import (
+	"math"
+	"testing"
+)
+
+func TestReadableSpeed(t *testing.T) {
+	const (
+		kb = 1024.0
+		mb = 1024 * kb
+		gb = 1024 * mb
+	)
+
+	tests := []struct {
+		name string
+		bps  float64
+		want string
+	}{
+		{name: "zero", bps: 0, want: "0 B/s"},
+		{name: "negative", bps: -1, want: "0 B/s"},
+		{name: "large negative", bps: -5 * mb, want: "0 B/s"},
+		{name: "NaN", bps: math.NaN(), want: "0 B/s"},
+		{name: "fraction of a byte", bps: 0.4, want: "0 B/s"},
+		{name: "bytes", bps: 512, want: "512 B/s"},
+		{name: "just below a kilobyte", bps: 1023, want: "1023 B/s"},
+		{name: "rounds up to a kilobyte", bps: 1023.6, want: "1.00 KB/s"},
+		{name: "one kilobyte", bps: kb, want: "1.00 KB/s"},
+		{name: "kilobytes", bps: 12.5 * kb, want: "12.50 KB/s"},
+		{name: "just below a megabyte", bps: mb - 1, want: "1.00 MB/s"},
+		{name: "below a megabyte", bps: 1023.99 * kb, want: "1023.99 KB/s"},
+		{name: "one megabyte", bps: mb, want: "1.00 MB/s"},
+		{name: "megabytes", bps: 3.2 * mb, want: "3.20 MB/s"},
+		{name: "just below a gigabyte", bps: gb - 1, want: "1.00 GB/s"},
+		{name: "one gigabyte", bps: gb, want: "1.00 GB/s"},
+		{name: "terabytes stay in gigabytes", bps: 2048 * gb, want: "2048.00 GB/s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReadableSpeed(tt.bps); got != tt.want {
+				t.Errorf("ReadableSpeed(%v) = %q, want %q", tt.bps, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInMBPSMatchesReadableSpeed(t *testing.T) {
+	for _, bps := range []float64{-1, 0, 100, 2048, 5 * 1024 * 1024} {
+		if got, want := InMBPS(bps), ReadableSpeed(bps); got != want {
+			t.Errorf("InMBPS(%v) = %q, want %q", bps, got, want)
+		}
+	}
+}
+
+func TestFormatNumberLocale(t *testing.T) {
+	tests := []struct {
+		number string
+		locale string
+		want   string
+	}{
+		{number: "100", locale: "en", want: "100"},
+		{number: "-100", locale: "en", want: "-100"},
+		{number: "-1000", locale: "en", want: "-1,000"},
+		{number: "-123456", locale: "de", want: "-123.456"},
+		{number: "1023.50", locale: "en", want: "1,023.50"},
+		{number: "-1023.50", locale: "fr", want: "-1 023,50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.number+"_"+tt.locale, func(t *testing.T) {
+			if got := formatNumberLocale(tt.number, numberFormats[tt.locale]); got != tt.want {
+				t.Errorf("formatNumberLocale(%q, %q) = %q, want %q", tt.number, tt.locale, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package ufs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// AtomicWriteFile moves a completely written file from src to dst so that dst
+// never exists in a partially written state.
+// This function uses os.Rename, and when src and dst are on different devices
+// it copies src into a temporary file next to dst which is then renamed over dst,
+// so readers of dst see either nothing or the complete file.
+//
+// Parameters:
+//   - src: Path of the fully written file
+//   - dst: Final path (replaced if it exists)
+//
+// Returns:
+//   - error: Error if the file could not be moved, nil on success
+//
+// Example:
+//
+//	err := AtomicWriteFile("/tmp/video.mp4.udtmp", "/mnt/media/video.mp4")
+//	if err != nil {
+//	    log.Fatal("Failed to finish download:", err)
+//	}
+//
+// Notes:
+//   - On copy failure the temporary file is removed and src is kept
+func AtomicWriteFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+
+	var linkErr *os.LinkError
+	if !errors.As(err, &linkErr) || !isCrossDeviceError(linkErr.Err) {
+		return err
+	}
+
+	// Copy next to dst first, a rename within the same directory is atomic
+	tempFile, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	tempPath := tempFile.Name()
+	tempFile.Close()
+
+	if err := copyFile(src, tempPath); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to copy across devices: %v", err)
+	}
+
+	if err := os.Rename(tempPath, dst); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	if err := os.Remove(src); err != nil {
+		return fmt.Errorf("moved file but failed to remove source: %v", err)
+	}
+
+	return nil
+}
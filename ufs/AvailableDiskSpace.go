@@ -0,0 +1,31 @@
+package ufs
+
+import "errors"
+
+// ErrDiskSpaceUnsupported is returned by AvailableDiskSpace on platforms where free space cannot be queried
+var ErrDiskSpaceUnsupported = errors.New("available disk space is not supported on this platform")
+
+// AvailableDiskSpace returns the number of bytes available to the current user
+// on the filesystem containing dir.
+//
+// Parameters:
+//   - dir: Any existing directory on the filesystem to check
+//
+// Returns:
+//   - int64: Free bytes usable without elevated privileges
+//   - error: Error if the filesystem cannot be queried
+//
+// Example:
+//
+//	free, err := AvailableDiskSpace("/home/user/Downloads")
+//	if err != nil {
+//	    log.Fatal("Failed to get free space:", err)
+//	}
+//	fmt.Printf("%d bytes free\n", free)
+//
+// Notes:
+//   - Uses statfs on Linux and macOS and GetDiskFreeSpaceEx on Windows
+//   - Returns ErrDiskSpaceUnsupported on other platforms
+func AvailableDiskSpace(dir string) (int64, error) {
+	return availableDiskSpace(dir)
+}
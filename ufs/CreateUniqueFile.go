@@ -0,0 +1,51 @@
+package ufs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CreateUniqueFile creates a new file that doesn't overwrite an existing one, like
+// GenerateUniqueFilename, but checks and creates the file in one atomic step.
+// No other process can claim the name between the check and the creation.
+//
+// Parameters:
+//   - path: Preferred file path
+//
+// Returns:
+//   - *os.File: The new empty file, opened for writing
+//   - string: Path of the created file, path or "filename (N).ext" if it was taken
+//   - error: Error if the file could not be created for another reason than the name being taken
+//
+// Example:
+//
+//	file, path, err := CreateUniqueFile("./downloads/file.zip")
+//	if err != nil {
+//	    log.Fatal("Failed to create file:", err)
+//	}
+//	defer file.Close()
+//	fmt.Println("Writing to", path) // "./downloads/file (1).zip" if file.zip existed
+//
+// Notes:
+//   - Uses the same naming pattern as GenerateUniqueFilename
+//   - The file is created with mode 0666 before the umask, like os.Create
+func CreateUniqueFile(path string) (*os.File, string, error) {
+	fileName := FileNameWithoutExtension(filepath.Base(path))
+	extension := FileExtension(filepath.Base(path))
+	dirPath := filepath.Dir(path)
+
+	candidate := path
+	for i := 1; ; i++ {
+		file, err := os.OpenFile(candidate, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0666)
+		if err == nil {
+			return file, candidate, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, "", fmt.Errorf("failed to create %s: %w", candidate, err)
+		}
+
+		candidate = filepath.Join(dirPath, fmt.Sprintf("%s (%d)%s", fileName, i, extension))
+	}
+}
@@ -0,0 +1,130 @@
+package ufs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// UNKNOWN_FILE_TYPE is returned by DetectFileType when no signature matches
+const UNKNOWN_FILE_TYPE = "application/octet-stream"
+
+// fileSignature identifies a file format by the bytes at the start of the file.
+// Formats like WAV and WEBP share a container and also need a second part to match.
+type fileSignature struct {
+	mime      string
+	offset    int
+	magic     string
+	subOffset int
+	sub       string
+}
+
+// fileSignatures are checked in order, more specific signatures come first
+var fileSignatures = []fileSignature{
+	// Images
+	{mime: "image/png", magic: "\x89PNG\r\n\x1a\n"},
+	{mime: "image/jpeg", magic: "\xff\xd8\xff"},
+	{mime: "image/gif", magic: "GIF87a"},
+	{mime: "image/gif", magic: "GIF89a"},
+	{mime: "image/webp", magic: "RIFF", subOffset: 8, sub: "WEBP"},
+	{mime: "image/tiff", magic: "II*\x00"},
+	{mime: "image/tiff", magic: "MM\x00*"},
+	{mime: "image/x-icon", magic: "\x00\x00\x01\x00"},
+	{mime: "image/bmp", magic: "BM"},
+
+	// Audio
+	{mime: "audio/wav", magic: "RIFF", subOffset: 8, sub: "WAVE"},
+	{mime: "audio/mpeg", magic: "ID3"},
+	{mime: "audio/ogg", magic: "OggS"},
+	{mime: "audio/flac", magic: "fLaC"},
+	{mime: "audio/midi", magic: "MThd"},
+
+	// Video
+	{mime: "video/x-msvideo", magic: "RIFF", subOffset: 8, sub: "AVI "},
+	{mime: "video/quicktime", offset: 4, magic: "ftypqt"},
+	{mime: "video/mp4", offset: 4, magic: "ftyp"},
+	{mime: "video/x-matroska", magic: "\x1a\x45\xdf\xa3"},
+	{mime: "video/x-flv", magic: "FLV\x01"},
+
+	// Documents
+	{mime: "application/pdf", magic: "%PDF-"},
+	{mime: "application/postscript", magic: "%!PS"},
+	{mime: "application/rtf", magic: "{\\rtf"},
+	{mime: "application/x-ole-storage", magic: "\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1"},
+	{mime: "application/vnd.sqlite3", magic: "SQLite format 3\x00"},
+
+	// Archives
+	{mime: "application/zip", magic: "PK\x03\x04"},
+	{mime: "application/zip", magic: "PK\x05\x06"},
+	{mime: "application/vnd.rar", magic: "Rar!\x1a\x07"},
+	{mime: "application/x-7z-compressed", magic: "7z\xbc\xaf\x27\x1c"},
+	{mime: "application/gzip", magic: "\x1f\x8b"},
+	{mime: "application/x-bzip2", magic: "BZh"},
+	{mime: "application/x-xz", magic: "\xfd7zXZ\x00"},
+	{mime: "application/zstd", magic: "\x28\xb5\x2f\xfd"},
+	{mime: "application/vnd.debian.binary-package", magic: "!<arch>\ndebian"},
+	{mime: "application/x-tar", offset: 257, magic: "ustar"},
+
+	// Executables
+	{mime: "application/x-elf", magic: "\x7fELF"},
+	{mime: "application/vnd.microsoft.portable-executable", magic: "MZ"},
+	{mime: "application/x-mach-binary", magic: "\xcf\xfa\xed\xfe"},
+	{mime: "application/java-vm", magic: "\xca\xfe\xba\xbe"},
+	{mime: "application/wasm", magic: "\x00asm"},
+
+	// Fonts
+	{mime: "font/woff", magic: "wOFF"},
+	{mime: "font/woff2", magic: "wOF2"},
+
+	// MP3 without an ID3 tag starts with a frame header, checked last since it is short
+	{mime: "audio/mpeg", magic: "\xff\xfb"},
+}
+
+// DetectFileType identifies the format of a file by the magic bytes in its first 512 bytes.
+//
+// Parameters:
+//   - path: Path of the file to inspect
+//
+// Returns:
+//   - string: MIME type of the format, UNKNOWN_FILE_TYPE if no signature matches
+//   - error: Error if the file cannot be read
+//
+// Example:
+//
+//	mimeType, err := DetectFileType("./downloads/archive.zip")
+//	if err == nil && mimeType != "application/zip" {
+//	    fmt.Println("Not a ZIP file:", mimeType)
+//	}
+//
+// Notes:
+//   - Formats based on ZIP, like DOCX, JAR or APK, are reported as application/zip
+//   - Text formats have no signature and are reported as UNKNOWN_FILE_TYPE
+func DetectFileType(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	header := make([]byte, 512)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", fmt.Errorf("failed to read file: %v", err)
+	}
+	header = header[:n]
+
+	for _, signature := range fileSignatures {
+		if hasBytesAt(header, signature.offset, signature.magic) &&
+			(signature.sub == "" || hasBytesAt(header, signature.subOffset, signature.sub)) {
+			return signature.mime, nil
+		}
+	}
+
+	return UNKNOWN_FILE_TYPE, nil
+}
+
+// hasBytesAt reports whether data contains magic at the given offset
+func hasBytesAt(data []byte, offset int, magic string) bool {
+	return len(data) >= offset+len(magic) && bytes.Equal(data[offset:offset+len(magic)], []byte(magic))
+}
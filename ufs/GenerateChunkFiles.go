@@ -2,6 +2,8 @@ package ufs
 
 import (
 	"fmt"
+	"hash"
+	"io"
 	"os"
 	"path/filepath"
 )
@@ -120,8 +122,7 @@ func GenerateChunkFileNames(originalFilename string, chunkCount int, paths ...st
 
 	// Step 3: Generate chunk file names with absolute paths
 	for i := 0; i < chunkCount; i++ {
-		chunkName := fmt.Sprintf("%s (%d).udtemp", filenameWithoutExt, i)
-		absoluteChunkPath := filepath.Join(targetDir, chunkName)
+		absoluteChunkPath := ChunkFileName(filenameWithoutExt, i, targetDir, ".udtemp")
 
 		// Ensure the path is absolute (double-check)
 		if !filepath.IsAbs(absoluteChunkPath) {
@@ -134,6 +135,49 @@ func GenerateChunkFileNames(originalFilename string, chunkCount int, paths ...st
 	return chunkFiles
 }
 
+// ChunkFileName builds the path of a single chunk file.
+// This follows the same naming convention as GenerateChunkFileNames
+// without allocating the names of all other chunks.
+//
+// Parameters:
+//   - baseFilename: Filename without extension, e.g. "video"
+//   - chunkIndex: Index of the chunk
+//   - dir: Directory containing the chunk files
+//   - ext: Chunk file extension including the dot, e.g. ".udtemp"
+//
+// Returns:
+//   - string: Chunk file path, e.g. "C:/downloads/video (3).udtemp"
+//
+// Example:
+//
+//	path := ChunkFileName("video", 3, "C:/downloads", ".udtemp")
+//	// Result: "C:/downloads/video (3).udtemp"
+func ChunkFileName(baseFilename string, chunkIndex int, dir, ext string) string {
+	return filepath.Join(dir, fmt.Sprintf("%s (%d)%s", baseFilename, chunkIndex, ext))
+}
+
+// ChunkFileExists checks whether the file of a specific chunk exists.
+// This is cheaper than generating all chunk names when checking for
+// resumable chunks in loops over hundreds of chunks.
+//
+// Parameters:
+//   - baseFilename: Filename without extension, e.g. "video"
+//   - chunkIndex: Index of the chunk
+//   - dir: Directory containing the chunk files
+//   - ext: Chunk file extension including the dot, e.g. ".udtemp"
+//
+// Returns:
+//   - bool: true if the chunk file exists
+//
+// Example:
+//
+//	if ChunkFileExists("video", 3, "C:/downloads", ".udtemp") {
+//	    fmt.Println("Chunk 3 can be resumed")
+//	}
+func ChunkFileExists(baseFilename string, chunkIndex int, dir, ext string) bool {
+	return FileExists(ChunkFileName(baseFilename, chunkIndex, dir, ext))
+}
+
 // GenerateChunkFiles creates physical temporary files for download chunks.
 // This function takes a list of chunk file names and creates empty files
 // on the filesystem, preparing them for parallel download operations.
@@ -295,6 +339,67 @@ func CreateFile(pathStr string) error {
 //   - Original chunk files are deleted after successful merge
 //   - Output file overwrites existing files
 func MergeChunkFiles(chunkFileNames []string, outputFilePath string) error {
+	return MergeChunkFilesWithProgress(chunkFileNames, outputFilePath, nil)
+}
+
+// MERGE_PROGRESS_INTERVAL is the number of merged bytes between two progress reports
+const MERGE_PROGRESS_INTERVAL = 1024 * 1024
+
+// MergeChunkFilesWithProgress combines downloaded chunk files into the final output file
+// like MergeChunkFiles, reporting the merge progress while copying.
+//
+// Parameters:
+//   - chunkFileNames: Array of chunk file paths in the correct order
+//   - outputFilePath: Path where the final merged file should be created
+//   - progressFn: Called every 1 MB merged and once the merge is done, nil to disable
+//
+// Returns:
+//   - error: Error if merging fails, nil on success
+//
+// Example:
+//
+//	err := MergeChunkFilesWithProgress(chunkNames, "video.mp4", func(merged, total int64) {
+//	    fmt.Printf("\rAssembling: %.1f%%", float64(merged)/float64(total)*100)
+//	})
+//
+// Notes:
+//   - total is the combined size of the chunk files when the merge starts
+func MergeChunkFilesWithProgress(chunkFileNames []string, outputFilePath string, progressFn func(merged, total int64)) error {
+	return MergeChunkFilesWithHash(chunkFileNames, outputFilePath, progressFn, nil)
+}
+
+// MergeChunkFilesWithHash combines downloaded chunk files into the final output file like
+// MergeChunkFilesWithProgress, feeding the merged bytes to a hash on the way. This gives
+// the checksum of the output file without reading it again after the merge.
+//
+// Parameters:
+//   - chunkFileNames: Array of chunk file paths in the correct order
+//   - outputFilePath: Path where the final merged file should be created
+//   - progressFn: Called every 1 MB merged and once the merge is done, nil to disable
+//   - h: Hash receiving the content of the output file, nil to disable
+//
+// Returns:
+//   - error: Error if merging fails, nil on success
+//
+// Example:
+//
+//	h := sha256.New()
+//	err := MergeChunkFilesWithHash(chunkNames, "video.mp4", nil, h)
+//	if err == nil {
+//	    fmt.Printf("SHA-256: %x\n", h.Sum(nil))
+//	}
+//
+// Notes:
+//   - Without a hash and progressFn the chunks are copied using the fastest method of the OS
+func MergeChunkFilesWithHash(chunkFileNames []string, outputFilePath string, progressFn func(merged, total int64), h hash.Hash) error {
+	// Total size of all chunks, for progress reporting
+	var total int64
+	for _, chunkFileName := range chunkFileNames {
+		if info, err := os.Stat(chunkFileName); err == nil {
+			total += info.Size()
+		}
+	}
+
 	// Create the output file
 	err := CreateFile(outputFilePath)
 	if err != nil {
@@ -307,6 +412,17 @@ func MergeChunkFiles(chunkFileNames []string, outputFilePath string) error {
 	}
 	defer outputFile.Close()
 
+	var output io.Writer = outputFile
+	if h != nil {
+		output = io.MultiWriter(outputFile, h)
+	}
+
+	var counter *mergeProgressWriter
+	if progressFn != nil {
+		counter = &mergeProgressWriter{w: output, total: total, progressFn: progressFn}
+		output = counter
+	}
+
 	// Merge each chunk file
 	for i, chunkFileName := range chunkFileNames {
 		chunkFile, err := os.Open(chunkFileName)
@@ -315,7 +431,11 @@ func MergeChunkFiles(chunkFileNames []string, outputFilePath string) error {
 		}
 
 		// Copy chunk content to output file
-		_, err = outputFile.ReadFrom(chunkFile)
+		if counter == nil && h == nil {
+			_, err = outputFile.ReadFrom(chunkFile)
+		} else {
+			_, err = io.Copy(output, chunkFile)
+		}
 		chunkFile.Close()
 
 		if err != nil {
@@ -326,13 +446,39 @@ func MergeChunkFiles(chunkFileNames []string, outputFilePath string) error {
 		err = os.Remove(chunkFileName)
 		if err != nil {
 			// Log warning but don't fail the merge
-			fmt.Printf("Warning: failed to remove chunk file %s: %v\n", chunkFileName, err)
+			fmt.Fprintf(os.Stderr, "Warning: failed to remove chunk file %s: %v\n", chunkFileName, err)
 		}
 	}
 
+	if counter != nil {
+		progressFn(counter.merged, max(total, counter.merged))
+	}
+
 	return nil
 }
 
+// mergeProgressWriter counts the bytes written and reports them every MERGE_PROGRESS_INTERVAL bytes
+type mergeProgressWriter struct {
+	w          io.Writer
+	merged     int64
+	reported   int64
+	total      int64
+	progressFn func(merged, total int64)
+}
+
+// Write writes p to the underlying writer and reports progress when an interval is crossed
+func (m *mergeProgressWriter) Write(p []byte) (int, error) {
+	n, err := m.w.Write(p)
+	m.merged += int64(n)
+
+	if m.merged-m.reported >= MERGE_PROGRESS_INTERVAL {
+		m.reported = m.merged
+		m.progressFn(m.merged, max(m.total, m.merged))
+	}
+
+	return n, err
+}
+
 // CleanupChunkFiles removes temporary chunk files in case of download failure.
 // This utility function ensures proper cleanup when downloads are cancelled
 // or fail, preventing accumulation of temporary files.
@@ -0,0 +1,124 @@
+package ufs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// MergeChunkFilesParallel combines downloaded chunk files into the final output file,
+// copying several chunks at the same time to their offsets in the output file.
+// The output file is pre-allocated to its final size, so the chunks can be written
+// in any order. On fast storage this removes the sequential merge bottleneck.
+//
+// Parameters:
+//   - chunkFiles: Array of chunk file paths
+//   - offsets: Byte offset of each chunk in the output file, in the same order as chunkFiles
+//   - outputPath: Path where the final merged file should be created
+//   - parallelism: Number of chunks copied at the same time, 1 or less for a sequential merge
+//
+// Returns:
+//   - error: Error if merging fails, nil on success
+//
+// Example:
+//
+//	chunkNames := GenerateChunkFileNames("video.mp4", 4, "./downloads")
+//	offsets := []int64{0, 25000000, 50000000, 75000000}
+//	err := MergeChunkFilesParallel(chunkNames, offsets, "./downloads/video.mp4", 4)
+//	if err != nil {
+//	    log.Fatal("Failed to merge chunks:", err)
+//	}
+//
+// Notes:
+//   - The sequential merge copies one chunk after the other, still to their offsets
+//   - Original chunk files are deleted only after every chunk was copied
+//   - Output file overwrites existing files
+func MergeChunkFilesParallel(chunkFiles []string, offsets []int64, outputPath string, parallelism int) error {
+	if len(chunkFiles) != len(offsets) {
+		return fmt.Errorf("got %d chunk files but %d offsets", len(chunkFiles), len(offsets))
+	}
+
+	parallelism = max(parallelism, 1)
+
+	// The output file ends where the furthest chunk ends
+	var totalSize int64
+	for i, chunkFile := range chunkFiles {
+		info, err := os.Stat(chunkFile)
+		if err != nil {
+			return fmt.Errorf("failed to stat chunk file %d (%s): %v", i, chunkFile, err)
+		}
+		totalSize = max(totalSize, offsets[i]+info.Size())
+	}
+
+	// Create and pre-allocate the output file
+	err := CreateFile(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %v", err)
+	}
+
+	if err := os.Truncate(outputPath, totalSize); err != nil {
+		return fmt.Errorf("failed to allocate output file: %v", err)
+	}
+
+	outputFile, err := os.OpenFile(outputPath, os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open output file for writing: %v", err)
+	}
+	defer outputFile.Close()
+
+	// Copy the chunks, at most parallelism at a time
+	semaphore := make(chan struct{}, parallelism)
+	errs := make([]error, len(chunkFiles))
+	var wg sync.WaitGroup
+
+	for i, chunkFile := range chunkFiles {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			errs[i] = copyChunkAt(outputFile, chunkFile, offsets[i])
+		}()
+	}
+
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("failed to copy chunk %d to output file: %v", i, err)
+		}
+	}
+
+	// Clean up chunk files after successful merge
+	for _, chunkFile := range chunkFiles {
+		if err := os.Remove(chunkFile); err != nil {
+			// Log warning but don't fail the merge
+			fmt.Fprintf(os.Stderr, "Warning: failed to remove chunk file %s: %v\n", chunkFile, err)
+		}
+	}
+
+	return nil
+}
+
+// copyChunkAt copies a chunk file into the output file at the given offset.
+//
+// Parameters:
+//   - outputFile: Output file, safe to share since only WriteAt is used
+//   - chunkFile: Path of the chunk file to copy
+//   - offset: Byte offset of the chunk in the output file
+//
+// Returns:
+//   - error: Error if the chunk cannot be read or written
+func copyChunkAt(outputFile *os.File, chunkFile string, offset int64) error {
+	file, err := os.Open(chunkFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(io.NewOffsetWriter(outputFile, offset), file)
+	return err
+}
@@ -0,0 +1,48 @@
+package ufs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeChunkFilesParallel(t *testing.T) {
+	for _, parallelism := range []int{0, 1, 2, 8} {
+		dir := t.TempDir()
+
+		// Chunks listed out of file order must still land at their offsets
+		parts := []string{"world", "hello ", "!"}
+		offsets := []int64{6, 0, 11}
+		chunkFiles := make([]string, len(parts))
+		for i, part := range parts {
+			chunkFiles[i] = filepath.Join(dir, "chunk"+string(rune('a'+i)))
+			if err := os.WriteFile(chunkFiles[i], []byte(part), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		output := filepath.Join(dir, "merged.txt")
+		if err := MergeChunkFilesParallel(chunkFiles, offsets, output, parallelism); err != nil {
+			t.Fatalf("parallelism %d: MergeChunkFilesParallel failed: %v", parallelism, err)
+		}
+
+		got, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "hello world!" {
+			t.Errorf("parallelism %d: merged file = %q, want %q", parallelism, got, "hello world!")
+		}
+		for _, chunkFile := range chunkFiles {
+			if FileExists(chunkFile) {
+				t.Errorf("parallelism %d: chunk file %s was not removed", parallelism, chunkFile)
+			}
+		}
+	}
+}
+
+func TestMergeChunkFilesParallelOffsetCount(t *testing.T) {
+	if err := MergeChunkFilesParallel([]string{"a", "b"}, []int64{0}, filepath.Join(t.TempDir(), "out"), 2); err == nil {
+		t.Error("MergeChunkFilesParallel accepted fewer offsets than chunk files")
+	}
+}
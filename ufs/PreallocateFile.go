@@ -0,0 +1,76 @@
+package ufs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrPreallocateUnsupported is returned by PreallocateFile on platforms or file systems
+// that cannot reserve disk space in advance
+var ErrPreallocateUnsupported = errors.New("preallocating disk space is not supported on this platform")
+
+// PreallocateFile reserves disk space for a file that will grow to size bytes, so the
+// file system can place it in one contiguous region instead of fragmenting it while
+// it is written piece by piece.
+//
+// Parameters:
+//   - path: Path of the file, created if it does not exist
+//   - size: Final size of the file in bytes
+//
+// Returns:
+//   - error: ErrPreallocateUnsupported if space cannot be reserved on this platform or
+//     file system, another error if the file cannot be opened or the disk is full
+//
+// Example:
+//
+//	if err := PreallocateFile("video.mp4.udtmp", 700*1024*1024); err != nil && !errors.Is(err, ErrPreallocateUnsupported) {
+//	    log.Println("Failed to preallocate:", err)
+//	}
+//
+// Notes:
+//   - The apparent size of the file is not changed, only the disk space is reserved,
+//     so the file size still tells how many bytes were written (needed to resume)
+//   - Uses fallocate on Linux, F_PREALLOCATE on macOS and the allocation size on Windows
+//   - On Windows the reserved space is released again when the last handle of the file is
+//     closed, use PreallocateOpenFile on the handle the file is written with instead
+func PreallocateFile(path string, size int64) error {
+	if size <= 0 {
+		return nil
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open file for preallocation: %v", err)
+	}
+	defer file.Close()
+
+	return PreallocateOpenFile(file, size)
+}
+
+// PreallocateOpenFile reserves disk space like PreallocateFile for a file that is already open.
+// The reservation holds on every platform as long as file stays open, so it should be the
+// handle the file is written with.
+//
+// Parameters:
+//   - file: Open file, writable
+//   - size: Final size of the file in bytes
+//
+// Returns:
+//   - error: ErrPreallocateUnsupported if space cannot be reserved on this platform or
+//     file system, another error if the disk is full
+//
+// Example:
+//
+//	file, _ := os.Create("video.mp4.udtmp")
+//	defer file.Close()
+//	if err := PreallocateOpenFile(file, 700*1024*1024); err != nil && !errors.Is(err, ErrPreallocateUnsupported) {
+//	    log.Println("Failed to preallocate:", err)
+//	}
+func PreallocateOpenFile(file *os.File, size int64) error {
+	if size <= 0 {
+		return nil
+	}
+
+	return preallocate(file, size)
+}
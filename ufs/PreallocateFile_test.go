@@ -0,0 +1,32 @@
+package ufs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPreallocateOpenFileKeepsSize(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "file.udtmp"))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	defer file.Close()
+
+	if err := PreallocateOpenFile(file, 1024*1024); err != nil && !errors.Is(err, ErrPreallocateUnsupported) {
+		t.Fatalf("PreallocateOpenFile() error = %v", err)
+	}
+
+	// Resuming relies on the size telling how many bytes were written
+	if _, err := file.Write([]byte("data")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Size() != 4 {
+		t.Errorf("size after writing 4 bytes = %d, want 4", info.Size())
+	}
+}
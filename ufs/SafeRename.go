@@ -0,0 +1,98 @@
+package ufs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// SafeRename moves a file from src to dst, even across filesystems.
+// This function tries os.Rename first and falls back to copying the file
+// and removing the source when the rename fails because src and dst are
+// on different devices (EXDEV on Unix, ERROR_NOT_SAME_DEVICE on Windows).
+//
+// Parameters:
+//   - src: Path of the file to move
+//   - dst: Destination path (overwritten if it exists)
+//
+// Returns:
+//   - error: Error if the file could not be moved, nil on success
+//
+// Example:
+//
+//	err := SafeRename("/tmp/video.mp4", "/mnt/media/video.mp4")
+//	if err != nil {
+//	    log.Fatal("Failed to move file:", err)
+//	}
+//
+// Notes:
+//   - The rename is atomic when src and dst are on the same device
+//   - The copy fallback is not atomic, dst may be partially written if it fails
+//   - On copy failure the partially written dst is removed and src is kept
+func SafeRename(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+
+	var linkErr *os.LinkError
+	if !errors.As(err, &linkErr) || !isCrossDeviceError(linkErr.Err) {
+		return err
+	}
+
+	// Source and destination are on different devices, copy instead
+	if err := copyFile(src, dst); err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("failed to copy across devices: %v", err)
+	}
+
+	if err := os.Remove(src); err != nil {
+		return fmt.Errorf("copied file but failed to remove source: %v", err)
+	}
+
+	return nil
+}
+
+// copyFile copies the content and permissions of src to dst and syncs it to disk.
+//
+// Parameters:
+//   - src: Path of the file to copy
+//   - dst: Destination path (created or truncated)
+//
+// Returns:
+//   - error: Error if copying fails
+func copyFile(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	info, err := srcFile.Stat()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return err
+	}
+
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		dstFile.Close()
+		return err
+	}
+
+	if err := dstFile.Sync(); err != nil {
+		dstFile.Close()
+		return err
+	}
+
+	return dstFile.Close()
+}
@@ -0,0 +1,99 @@
+package ufs
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// MAX_FILENAME_BYTES is the longest file name accepted by common file systems
+const MAX_FILENAME_BYTES = 255
+
+// windowsReservedNames are device names that cannot be used as file names on Windows,
+// with or without an extension
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// SanitizeFilename makes a file name received from a server or user safe to create
+// inside the download directory, preventing path traversal and invalid names.
+//
+// Parameters:
+//   - name: The raw file name, e.g. from a Content-Disposition header
+//
+// Returns:
+//   - string: Safe file name, or an empty string if nothing usable is left
+//
+// Example:
+//
+//	SanitizeFilename("../../etc/passwd") // "passwd"
+//	SanitizeFilename("CON.txt")          // "_CON.txt"
+//	SanitizeFilename("report\x00.pdf")   // "report.pdf"
+//
+// Notes:
+//   - Only the last path element is kept, both "/" and "\" are treated as separators
+//   - Control characters (below 32 and 127) are removed
+//   - Reserved Windows device names get an underscore prefix
+//   - Names longer than 255 bytes are truncated, keeping the extension when possible
+func SanitizeFilename(name string) string {
+	// Keep only the last path element
+	if index := strings.LastIndexAny(name, `/\`); index >= 0 {
+		name = name[index+1:]
+	}
+
+	// Remove control characters and invalid UTF-8
+	name = strings.Map(func(r rune) rune {
+		if r < 32 || r == 127 || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, name)
+
+	// Windows does not allow trailing dots and spaces
+	name = strings.TrimSpace(name)
+	name = strings.TrimRight(name, ". ")
+	if name == "" {
+		return ""
+	}
+
+	// Reserved names are matched without the extension, e.g. "CON.txt"
+	baseName, _, _ := strings.Cut(name, ".")
+	if windowsReservedNames[strings.ToUpper(strings.TrimSpace(baseName))] {
+		name = "_" + name
+	}
+
+	return truncateFilename(name, MAX_FILENAME_BYTES)
+}
+
+// truncateFilename shortens a file name to at most maxBytes bytes,
+// keeping the extension and not splitting multi-byte characters.
+//
+// Parameters:
+//   - name: The file name to shorten
+//   - maxBytes: Maximum length in bytes
+//
+// Returns:
+//   - string: The file name, unchanged if it already fits
+func truncateFilename(name string, maxBytes int) string {
+	if len(name) <= maxBytes {
+		return name
+	}
+
+	ext := filepath.Ext(name)
+	if len(ext) >= maxBytes/2 {
+		// Unreasonably long extension, cut the whole name
+		ext = ""
+	}
+
+	baseName := name[:len(name)-len(ext)]
+	cut := maxBytes - len(ext)
+	for cut > 0 && !utf8.RuneStart(baseName[cut]) {
+		cut--
+	}
+
+	return baseName[:cut] + ext
+}
@@ -0,0 +1,72 @@
+package ufs
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "plain name", input: "report.pdf", want: "report.pdf"},
+		{name: "unix path traversal", input: "../../etc/passwd", want: "passwd"},
+		{name: "windows path traversal", input: `..\..\Windows\System32\config`, want: "config"},
+		{name: "mixed separators", input: `a/b\c.txt`, want: "c.txt"},
+		{name: "absolute path", input: "/etc/shadow", want: "shadow"},
+		{name: "only dots", input: "..", want: ""},
+		{name: "trailing separator", input: "dir/", want: ""},
+		{name: "empty", input: "", want: ""},
+		{name: "null byte", input: "report\x00.pdf", want: "report.pdf"},
+		{name: "control characters", input: "a\x01b\x1fc\x7f.txt", want: "abc.txt"},
+		{name: "newline", input: "file\nname.txt", want: "filename.txt"},
+		{name: "reserved name with extension", input: "CON.txt", want: "_CON.txt"},
+		{name: "reserved name", input: "NUL", want: "_NUL"},
+		{name: "reserved name lowercase", input: "prn.log", want: "_prn.log"},
+		{name: "reserved name with several extensions", input: "aux.tar.gz", want: "_aux.tar.gz"},
+		{name: "reserved device number", input: "COM1.bin", want: "_COM1.bin"},
+		{name: "not reserved", input: "CONSOLE.txt", want: "CONSOLE.txt"},
+		{name: "not reserved device number", input: "COM10.txt", want: "COM10.txt"},
+		{name: "trailing dots and spaces", input: "file.txt. . ", want: "file.txt"},
+		{name: "surrounding spaces", input: "  file.txt  ", want: "file.txt"},
+		{name: "unicode", input: "résumé 履歴書.pdf", want: "résumé 履歴書.pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeFilename(tt.input); got != tt.want {
+				t.Errorf("SanitizeFilename(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeFilenameTruncates(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantExt string
+	}{
+		{name: "ascii", input: strings.Repeat("a", 300) + ".zip", wantExt: ".zip"},
+		{name: "multi-byte characters", input: strings.Repeat("é", 200) + ".mp4", wantExt: ".mp4"},
+		{name: "long extension", input: "a." + strings.Repeat("b", 300), wantExt: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SanitizeFilename(tt.input)
+			if len(got) > MAX_FILENAME_BYTES {
+				t.Errorf("SanitizeFilename returned %d bytes, want at most %d", len(got), MAX_FILENAME_BYTES)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("SanitizeFilename split a multi-byte character: %q", got)
+			}
+			if tt.wantExt != "" && !strings.HasSuffix(got, tt.wantExt) {
+				t.Errorf("SanitizeFilename(%q...) = %q, want the %s extension kept", tt.input[:10], got, tt.wantExt)
+			}
+		})
+	}
+}
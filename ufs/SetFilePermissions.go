@@ -0,0 +1,32 @@
+package ufs
+
+import (
+	"fmt"
+	"os"
+)
+
+// SetFilePermissions sets the permission bits of a file, regardless of the process umask.
+//
+// Parameters:
+//   - path: Path of the file
+//   - mode: Permission bits, e.g. 0644
+//
+// Returns:
+//   - error: Error if the permissions could not be changed, nil on success
+//
+// Example:
+//
+//	err := SetFilePermissions("./downloads/file.zip", 0600)
+//	if err != nil {
+//	    log.Fatal("Failed to set permissions:", err)
+//	}
+//
+// Notes:
+//   - Only the permission bits of mode are used, other mode bits are ignored
+//   - On Windows only the read-only attribute is changed, based on the owner write bit
+func SetFilePermissions(path string, mode os.FileMode) error {
+	if err := os.Chmod(path, mode.Perm()); err != nil {
+		return fmt.Errorf("failed to set permissions of %s: %w", path, err)
+	}
+	return nil
+}
@@ -155,7 +155,8 @@ func FileExtension(filename string) string {
 //   - Preserves original directory path
 //   - Works with files that have no extension
 //   - Thread-safe for individual calls (but not atomic across processes)
-//   - May create race conditions in multi-threaded environments
+//   - May create race conditions in multi-threaded environments, use CreateUniqueFile
+//     to claim the name atomically
 func GenerateUniqueFilename(path string) string {
 	if !FileExists(path) {
 		return path
@@ -0,0 +1,122 @@
+package ufs
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+// checksumBufferSize is the size of the buffer used to read files while hashing (4 MB)
+const checksumBufferSize = 4 * 1024 * 1024
+
+// NewChecksumHash returns a new hash for the given algorithm name.
+//
+// Parameters:
+//   - algo: Algorithm name, one of "md5", "sha1", "sha256" or "sha512" (case-insensitive)
+//
+// Returns:
+//   - hash.Hash: A fresh hash instance
+//   - error: Error if the algorithm is not supported
+//
+// Example:
+//
+//	h, err := NewChecksumHash("sha256")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	h.Write([]byte("hello"))
+func NewChecksumHash(algo string) (hash.Hash, error) {
+	switch strings.ToLower(algo) {
+	case "md5":
+		return md5.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm: %s", algo)
+	}
+}
+
+// VerifyFileChecksum checks whether the checksum of a file matches the expected value.
+// This function reads the file in 4 MB chunks and feeds them to the selected hash,
+// so even very large files can be verified with constant memory usage.
+//
+// Parameters:
+//   - path: Path of the file to verify
+//   - algo: Algorithm name, one of "md5", "sha1", "sha256" or "sha512"
+//   - expectedHex: Expected digest as a hex string (case-insensitive)
+//
+// Returns:
+//   - bool: true if the digest of the file matches expectedHex
+//   - error: Error if the algorithm is unsupported or the file cannot be read
+//
+// Example:
+//
+//	ok, err := VerifyFileChecksum("./downloads/file.zip", "sha256", "9f86d08...")
+//	if err != nil {
+//	    log.Fatal("Checksum verification failed:", err)
+//	}
+//	if !ok {
+//	    fmt.Println("File is corrupted")
+//	}
+//
+// Notes:
+//   - Leading and trailing whitespace in expectedHex is ignored
+//   - A mismatch is reported as false with a nil error
+func VerifyFileChecksum(path string, algo string, expectedHex string) (bool, error) {
+	actualHex, err := HashFile(path, algo)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.EqualFold(actualHex, strings.TrimSpace(expectedHex)), nil
+}
+
+// HashFile computes the digest of a file with the given algorithm.
+// The file is streamed through the hash in 4 MB chunks, so memory usage
+// does not depend on the file size.
+//
+// Parameters:
+//   - path: Path of the file to hash
+//   - algorithm: Algorithm name, one of "md5", "sha1", "sha256" or "sha512" (case-insensitive)
+//
+// Returns:
+//   - string: Lowercase hex digest of the file
+//   - error: Error if the algorithm is unsupported or the file cannot be read
+//
+// Example:
+//
+//	digest, err := HashFile("./downloads/file.zip", "sha512")
+//	if err != nil {
+//	    log.Fatal("Failed to hash file:", err)
+//	}
+//	fmt.Println("SHA-512:", digest)
+func HashFile(path, algorithm string) (string, error) {
+	h, err := NewChecksumHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	buffer := make([]byte, checksumBufferSize)
+	if _, err := io.CopyBuffer(h, file, buffer); err != nil {
+		return "", fmt.Errorf("failed to read file: %v", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,61 @@
+package ufs
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// VerifyChunkTail checks that the end of a chunk file contains data.
+// When the system crashes while a chunk is written, the file size may already be updated
+// while the data never reached the disk, which leaves the tail of the file filled with zeros.
+//
+// Parameters:
+//   - path: Path of the chunk file
+//   - sampleSize: Number of bytes at the end of the file to inspect
+//
+// Returns:
+//   - bool: false if the inspected bytes are all zero, true otherwise
+//   - error: Error if the file cannot be read
+//
+// Example:
+//
+//	valid, err := VerifyChunkTail("./downloads/file.zip.part0", 4096)
+//	if err == nil && !valid {
+//	    fmt.Println("Chunk was corrupted by a crash, restarting it")
+//	}
+//
+// Notes:
+//   - Empty files and a sampleSize <= 0 are reported as valid
+//   - Files shorter than sampleSize are inspected entirely
+//   - Data that legitimately ends with zeros is reported as invalid, so use a sample large enough to make this unlikely
+func VerifyChunkTail(path string, sampleSize int) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to stat file: %v", err)
+	}
+
+	size := min(int64(sampleSize), fileInfo.Size())
+	if size <= 0 {
+		return true, nil
+	}
+
+	tail := make([]byte, size)
+	if _, err := file.ReadAt(tail, fileInfo.Size()-size); err != nil && err != io.EOF {
+		return false, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	for _, b := range tail {
+		if b != 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
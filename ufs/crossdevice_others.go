@@ -0,0 +1,13 @@
+//go:build !windows
+
+package ufs
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isCrossDeviceError reports whether err means the rename crossed devices.
+func isCrossDeviceError(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package ufs
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errorNotSameDevice is the Windows ERROR_NOT_SAME_DEVICE error code
+const errorNotSameDevice syscall.Errno = 17
+
+// isCrossDeviceError reports whether err means the rename crossed devices.
+func isCrossDeviceError(err error) bool {
+	return errors.Is(err, errorNotSameDevice)
+}
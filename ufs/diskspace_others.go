@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package ufs
+
+// availableDiskSpace is not implemented on this platform.
+func availableDiskSpace(dir string) (int64, error) {
+	return 0, ErrDiskSpaceUnsupported
+}
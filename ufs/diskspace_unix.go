@@ -0,0 +1,19 @@
+//go:build linux || darwin
+
+package ufs
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// availableDiskSpace returns the free bytes of the filesystem containing dir using statfs.
+func availableDiskSpace(dir string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, fmt.Errorf("failed to stat filesystem: %v", err)
+	}
+
+	// Blocks available to unprivileged users, not counting reserved blocks
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
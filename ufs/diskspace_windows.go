@@ -0,0 +1,25 @@
+//go:build windows
+
+package ufs
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// availableDiskSpace returns the free bytes of the volume containing dir using GetDiskFreeSpaceEx.
+func availableDiskSpace(dir string) (int64, error) {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, fmt.Errorf("invalid directory path: %v", err)
+	}
+
+	// Bytes available to the calling user, honoring disk quotas
+	var freeBytesAvailable, totalBytes, totalFreeBytes uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &freeBytesAvailable, &totalBytes, &totalFreeBytes); err != nil {
+		return 0, fmt.Errorf("failed to get free disk space: %v", err)
+	}
+
+	return int64(freeBytesAvailable), nil
+}
@@ -0,0 +1,31 @@
+//go:build darwin
+
+package ufs
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// preallocate reserves size bytes for file using fcntl F_PREALLOCATE.
+func preallocate(file *os.File, size int64) error {
+	// Prefer one contiguous region, but accept a fragmented one if there is none
+	store := unix.Fstore_t{
+		Flags:   unix.F_ALLOCATECONTIG | unix.F_ALLOCATEALL,
+		Posmode: unix.F_PEOFPOSMODE,
+		Length:  size,
+	}
+	if err := unix.FcntlFstore(file.Fd(), unix.F_PREALLOCATE, &store); err == nil {
+		return nil
+	}
+
+	store.Flags = unix.F_ALLOCATEALL
+	if err := unix.FcntlFstore(file.Fd(), unix.F_PREALLOCATE, &store); err != nil {
+		if err == unix.ENOTSUP {
+			return ErrPreallocateUnsupported
+		}
+		return err
+	}
+	return nil
+}
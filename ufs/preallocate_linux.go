@@ -0,0 +1,21 @@
+//go:build linux
+
+package ufs
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// fallocKeepSize is FALLOC_FL_KEEP_SIZE, allocate blocks without changing the file size
+const fallocKeepSize = 0x1
+
+// preallocate reserves size bytes for file using fallocate.
+func preallocate(file *os.File, size int64) error {
+	err := syscall.Fallocate(int(file.Fd()), fallocKeepSize, 0, size)
+	if errors.Is(err, syscall.EOPNOTSUPP) || errors.Is(err, syscall.ENOSYS) {
+		return ErrPreallocateUnsupported
+	}
+	return err
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !windows
+
+package ufs
+
+import "os"
+
+// preallocate is not implemented on this platform.
+// Extending the file with os.Truncate instead would break resuming, which relies on the file size.
+func preallocate(file *os.File, size int64) error {
+	return ErrPreallocateUnsupported
+}
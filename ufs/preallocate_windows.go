@@ -0,0 +1,22 @@
+//go:build windows
+
+package ufs
+
+import (
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// preallocate reserves size bytes for file by setting its allocation size,
+// unlike SetEndOfFile this does not move the end of the file.
+func preallocate(file *os.File, size int64) error {
+	info := struct{ AllocationSize int64 }{AllocationSize: size}
+	return windows.SetFileInformationByHandle(
+		windows.Handle(file.Fd()),
+		windows.FileAllocationInfo,
+		(*byte)(unsafe.Pointer(&info)),
+		uint32(unsafe.Sizeof(info)),
+	)
+}